@@ -3,13 +3,8 @@ package gnxaigc
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"strings"
-
-	jsonrepair "github.com/RealAlexandreAI/json-repair"
-	"github.com/openai/openai-go/v3"
-	"github.com/openai/openai-go/v3/shared"
 )
 
 type TTSVoiceItem struct {
@@ -64,6 +59,10 @@ type SummaryChapterInput struct {
 	CharacterFeatures []CharacterFeature
 	// MaxPanelsPerPage 控制单页内的最大分格数量，默认四格，至少一格
 	MaxPanelsPerPage int
+	// StoryMemory 截至上一章的剧情梗概与世界状态，注入提示词时会按长度截断
+	StoryMemory *StoryMemory
+	// ExtraInstruction 用户对本章分镜的额外要求，重新生成分镜时使用
+	ExtraInstruction string
 }
 
 type SourceTextSegment struct {
@@ -79,6 +78,8 @@ type SourceTextSegment struct {
 	IsNarration bool `json:"is_narration,omitempty"`
 	// CharacterNames 记录角色姓名，便于生成端保持一致
 	CharacterNames []string `json:"character_names,omitempty"`
+	// Emotion 说话角色在该片段中的表情，取值见 SheetExpressions，用于挑选角色表情参考图
+	Emotion string `json:"emotion,omitempty"`
 }
 
 type StoryboardPanel struct {
@@ -211,6 +212,11 @@ func buildStoryboardSchema(maxPanelsPerPage int) map[string]any {
 														"type": "string",
 													},
 												},
+												"emotion": map[string]any{
+													"type":        "string",
+													"description": "可选：说话角色在该片段中的表情",
+													"enum":        SheetExpressions,
+												},
 											},
 										},
 									},
@@ -303,6 +309,8 @@ func buildSummaryChapterPrompt(input SummaryChapterInput, voiceStylesJSON, schem
 	novelTitle := input.NovelTitle
 	chapterTitle := input.ChapterTitle
 	existingCharactersJSON := buildCharacterFeaturesJSON(input.CharacterFeatures)
+	storyMemoryText := buildStoryMemoryText(input.StoryMemory)
+	extraInstructionText := buildExtraInstructionText(input.ExtraInstruction)
 	return fmt.Sprintf(`
 你是一个擅长从小说生成动漫分镜和配音选择的设计师，后续用户将给你每一章的小说原文，你需要按指定的输出格式进行输出。
 
@@ -316,11 +324,18 @@ func buildSummaryChapterPrompt(input SummaryChapterInput, voiceStylesJSON, schem
 
 %s
 
+以下为截至上一章的前情提要与世界状态笔记（若为空表示本章为故事开端）：
+
+%s
+
+分镜中的角色伤势、服装、持有物品、所处地点等必须与前情提要保持一致，除非本章原文明确发生了变化。
+
 请根据小说内容和情感，将章节拆分成多页，每一页包含 1 至 %d 个分格（panel）。确保页面之间的剧情推进自然，必要时可以增加页数，避免把大量剧情挤在同一页。为每个分格拆分合适的语音文本片段，并为每个片段选择合适的语音风格和语速比例（1.0 为正常语速，>1.0 为加快语速，<1.0 为放慢语速）。
 
 在每个 source_text_segment 中：
 1. 若有角色参与，请在 character_names 中列出角色姓名（使用与 basic.name 一致的英文名称）。
 2. 若该片段为纯旁白或没有特定角色，可省略 character_names 字段。
+3. 若有角色参与，请在 emotion 中标注说话角色此刻的表情，只能从 neutral、angry、smiling、shocked 中选择一个。
 
 图像生成以“页”为单位，请：
 1. 为每页提供 layout_hint，明确描述分格在页面上的排列方式（如 2x2 grid、三段纵向排版等）。
@@ -334,6 +349,7 @@ func buildSummaryChapterPrompt(input SummaryChapterInput, voiceStylesJSON, schem
 3. 若角色为全新出场，请在 concept_art_notes 中注明 "new character"，并给出灵感来源或与剧情相关的设计理由。
 4. concept_art_prompt 必须避免引导模型生成文字或中文字符，应聚焦于角色造型、服装、配色、光线、姿态等视觉细节。
 5. 请确保 storyboard_pages 中对角色的描写与对应的 concept_art_prompt 一致，避免跨页设定冲突。
+%s
 
 请严格按照以下给定的JSONSchema, 仅输出一个合法的 JSON 对象, 不要包含任何前导或后续的说明文字、代码块标记、引号等进行输出结果的编写，确保输出内容**严格符合JSONSchema的要求**且格式正确:
 
@@ -343,11 +359,22 @@ func buildSummaryChapterPrompt(input SummaryChapterInput, voiceStylesJSON, schem
 		chapterTitle,
 		voiceStylesJSON,
 		existingCharactersJSON,
+		storyMemoryText,
 		maxPanelsPerPage,
+		extraInstructionText,
 		schemaJSON,
 	)
 }
 
+// buildExtraInstructionText 将用户的额外要求整理为提示词段落，未提供时返回空字符串。
+func buildExtraInstructionText(instruction string) string {
+	instruction = strings.TrimSpace(instruction)
+	if instruction == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n用户对本章分镜提出了以下额外要求，请在不违背上述规则的前提下优先满足：\n\n%s\n", instruction)
+}
+
 func (g *GnxAIGC) SummaryChapter(ctx context.Context, input SummaryChapterInput) (*SummaryChapterOutput, error) {
 	maxPanelsPerPage := maxPanelsPerPageOrDefault(input.MaxPanelsPerPage)
 	jsonSchema := buildStoryboardSchema(maxPanelsPerPage)
@@ -358,54 +385,9 @@ func (g *GnxAIGC) SummaryChapter(ctx context.Context, input SummaryChapterInput)
 	voiceStylesJSON := buildVoiceStylesJSON(input.AvailableVoiceStyles)
 	prompt := buildSummaryChapterPrompt(input, voiceStylesJSON, string(jsonSchemaBytes), maxPanelsPerPage)
 
-	resp, err := g.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-		Model: g.LanguageModel,
-		N:     openai.Int(1),
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			{
-				OfSystem: &openai.ChatCompletionSystemMessageParam{
-					Content: openai.ChatCompletionSystemMessageParamContentUnion{
-						OfString: openai.String(prompt),
-					},
-				},
-			},
-			{
-				OfUser: &openai.ChatCompletionUserMessageParam{
-					Content: openai.ChatCompletionUserMessageParamContentUnion{
-						OfString: openai.String(input.Content),
-					},
-				},
-			},
-		},
-		ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
-			OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
-		},
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate chat completion: %w", err)
-	}
-
-	if len(resp.Choices) == 0 {
-		return nil, errors.New("no chat completion choices received")
-	}
-
-	content := resp.Choices[0].Message.Content
-
-	fmt.Printf("SummaryChapter chat completion content: %s\n", content)
-
 	var output SummaryChapterOutput
-	if err := json.Unmarshal([]byte(content), &output); err == nil {
-		return &output, nil
-	}
-
-	// 如果解析失败，则尝试下修复
-	contentFixed, err := jsonrepair.RepairJSON(content)
-	if err != nil {
-		return nil, fmt.Errorf("failed to repair JSON content: %w", err)
-	}
-
-	if err := json.Unmarshal([]byte(contentFixed), &output); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal repaired JSON content: %w", err)
+	if err := g.completeJSON(ctx, "SummaryChapter", prompt, input.Content, &output); err != nil {
+		return nil, err
 	}
 
 	return &output, nil
@@ -450,3 +432,40 @@ func ComposePageImagePrompt(stylePrefix string, page StoryboardPage) string {
 
 	return strings.TrimSpace(builder.String())
 }
+
+// ComposePanelImagePrompt 为逐格渲染生成单个分格的提示词，页面由后端按 LayoutHint 在本地拼版。
+// aspect 描述目标分格的画幅（如 "wide landscape"、"tall portrait"），为空时不做约束。
+func ComposePanelImagePrompt(stylePrefix string, page StoryboardPage, panelIndex int, aspect string) string {
+	var builder strings.Builder
+
+	appendWithSpace := func(text string) {
+		if text == "" {
+			return
+		}
+		if builder.Len() > 0 && !strings.HasSuffix(builder.String(), " ") {
+			builder.WriteString(" ")
+		}
+		builder.WriteString(text)
+	}
+
+	appendWithSpace(strings.TrimSpace(stylePrefix))
+	appendWithSpace(strings.TrimSpace(page.ImagePrompt))
+
+	if panelIndex >= 0 && panelIndex < len(page.Panels) {
+		panel := page.Panels[panelIndex]
+		visual := strings.TrimSpace(panel.VisualPrompt)
+		if visual == "" {
+			visual = strings.TrimSpace(panel.PanelSummary)
+		}
+		appendWithSpace(fmt.Sprintf("Draw only this single comic panel: %s", visual))
+	}
+
+	if aspect = strings.TrimSpace(aspect); aspect != "" {
+		appendWithSpace(fmt.Sprintf("Frame the shot as a %s composition.", aspect))
+	}
+
+	appendWithSpace("Full-bleed illustration without panel borders, gutters or multiple frames.")
+	appendWithSpace("Use English-only descriptive language. No Chinese characters or typography. Avoid rendering any on-screen text.")
+
+	return strings.TrimSpace(builder.String())
+}
@@ -0,0 +1,117 @@
+package gnxaigc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// 角色表情，与分镜片段的 emotion 字段取值一致
+const (
+	EmotionNeutral = "neutral"
+	EmotionAngry   = "angry"
+	EmotionSmiling = "smiling"
+	EmotionShocked = "shocked"
+)
+
+// 角色转面视图
+const (
+	SheetViewFront = "front"
+	SheetViewSide  = "side"
+	SheetViewBack  = "back"
+)
+
+// SheetViews 角色设定表中的转面视图
+var SheetViews = []string{SheetViewFront, SheetViewSide, SheetViewBack}
+
+// SheetExpressions 角色设定表中的表情
+var SheetExpressions = []string{EmotionNeutral, EmotionAngry, EmotionSmiling, EmotionShocked}
+
+// NormalizeEmotion 将模型输出的表情规整为 SheetExpressions 中的取值，无法识别时返回空字符串。
+func NormalizeEmotion(emotion string) string {
+	emotion = strings.ToLower(strings.TrimSpace(emotion))
+	if slices.Contains(SheetExpressions, emotion) {
+		return emotion
+	}
+	return ""
+}
+
+// sheetViewKeywords 分镜画面描述中表示侧面或背面构图的关键词，背面优先
+var sheetViewKeywords = []struct {
+	view     string
+	keywords []string
+}{
+	{SheetViewBack, []string{"back view", "from behind", "back to the viewer", "back to the camera", "facing away", "背影", "背对", "背面", "背向"}},
+	{SheetViewSide, []string{"side view", "profile", "side-on", "侧面", "侧脸", "侧身", "侧影"}},
+}
+
+// DetectSheetView 根据分格的画面描述判断角色以哪个角度出镜，返回 SheetViewSide 或 SheetViewBack；
+// 没有侧面或背面的描述时返回空字符串。
+func DetectSheetView(visualPrompt string) string {
+	text := strings.ToLower(visualPrompt)
+	for _, candidate := range sheetViewKeywords {
+		for _, keyword := range candidate.keywords {
+			if strings.Contains(text, keyword) {
+				return candidate.view
+			}
+		}
+	}
+	return ""
+}
+
+var sheetViewPrompts = map[string]string{
+	SheetViewFront: "full body front view, facing the viewer, standing in a neutral pose",
+	SheetViewSide:  "full body side view in profile, facing left, standing in a neutral pose",
+	SheetViewBack:  "full body back view, facing away from the viewer, standing in a neutral pose",
+}
+
+var sheetExpressionPrompts = map[string]string{
+	EmotionNeutral: "head and shoulders portrait with a calm, neutral expression",
+	EmotionAngry:   "head and shoulders portrait with an angry expression, furrowed brows and clenched jaw",
+	EmotionSmiling: "head and shoulders portrait with a warm, genuine smile",
+	EmotionShocked: "head and shoulders portrait with a shocked expression, wide eyes and open mouth",
+}
+
+// ComposeCharacterSheetPrompt 基于角色原画提示词生成转面视图或表情图的提示词，
+// name 为 SheetViews 或 SheetExpressions 中的取值。生成时应以角色原画作为参考图。
+func ComposeCharacterSheetPrompt(stylePrefix, conceptArtPrompt, name string) string {
+	shot, ok := sheetViewPrompts[name]
+	if !ok {
+		shot, ok = sheetExpressionPrompts[name]
+	}
+	if !ok {
+		shot = name
+	}
+
+	parts := []string{
+		strings.TrimSpace(stylePrefix),
+		strings.TrimSpace(conceptArtPrompt),
+		fmt.Sprintf("Character reference sheet: %s.", shot),
+		"Keep exactly the same face, hairstyle, hair color, skin tone, outfit and color palette as the reference image.",
+		"Plain light gray background, single character only.",
+		"Use English-only descriptive language. No Chinese characters or typography. Avoid rendering any on-screen text.",
+	}
+
+	var builder strings.Builder
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		if builder.Len() > 0 {
+			builder.WriteString(" ")
+		}
+		builder.WriteString(part)
+	}
+	return builder.String()
+}
+
+// ReferenceSheetNote 说明参考图为带角色名标注的设定拼图，附加在图生图提示词末尾，避免模型照搬拼图排版或标注文字。
+func ReferenceSheetNote(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(
+		"The reference image is a character sheet whose tiles are labeled with the names %s; use it only to keep each character's appearance consistent. Do not copy the sheet layout, the tile borders or the name labels.",
+		strings.Join(names, ", "),
+	)
+}
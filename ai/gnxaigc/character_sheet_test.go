@@ -0,0 +1,18 @@
+package gnxaigc
+
+import "testing"
+
+func TestDetectSheetView(t *testing.T) {
+	tests := map[string]string{
+		"萧炎背对镜头，望向远处的山峰":                                   SheetViewBack,
+		"Wide shot from behind as she walks into the rain": SheetViewBack,
+		"Close-up of his face in profile, lit by candles":  SheetViewSide,
+		"药老侧身而立，衣袖翻飞":                                      SheetViewSide,
+		"Medium shot, both characters facing the viewer":   "",
+	}
+	for prompt, want := range tests {
+		if got := DetectSheetView(prompt); got != want {
+			t.Errorf("DetectSheetView(%q) = %q, want %q", prompt, got, want)
+		}
+	}
+}
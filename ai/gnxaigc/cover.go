@@ -0,0 +1,116 @@
+package gnxaigc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultCoverSourceMaxRunes 限制封面提示词生成时注入的章节原文总字数。
+const DefaultCoverSourceMaxRunes = 6000
+
+// CoverChapter 用于封面构思的章节原文
+type CoverChapter struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// CoverCharacter 用于封面构思的角色信息
+type CoverCharacter struct {
+	Name   string `json:"name"`
+	Gender string `json:"gender,omitempty"`
+	Age    string `json:"age,omitempty"`
+	Brief  string `json:"brief,omitempty"`
+}
+
+type GenerateCoverPromptsInput struct {
+	// Novel Title 小说标题
+	NovelTitle string
+	// StylePrompt 用户指定的画风描述
+	StylePrompt string
+	// Chapters 开篇若干章的原文
+	Chapters []CoverChapter
+	// Characters 已识别的角色列表
+	Characters []CoverCharacter
+	// MaxRunes 注入的章节原文总字数上限，默认 DefaultCoverSourceMaxRunes
+	MaxRunes int
+}
+
+// CoverPrompts 语言模型为封面与背景图撰写的英文提示词
+type CoverPrompts struct {
+	CoverPrompt      string `json:"cover_prompt"`
+	BackgroundPrompt string `json:"background_prompt"`
+	// Protagonists 封面与背景图中出现的主角姓名，与角色列表中的 name 一致
+	Protagonists []string `json:"protagonists"`
+}
+
+func buildCoverSourceText(chapters []CoverChapter, maxRunes int) string {
+	var builder strings.Builder
+	remaining := maxRunes
+	for _, chapter := range chapters {
+		if remaining <= 0 {
+			break
+		}
+		content := truncateRunesKeepHead(strings.TrimSpace(chapter.Content), remaining)
+		remaining -= len([]rune(content))
+		fmt.Fprintf(&builder, "《%s》\n%s\n\n", chapter.Title, content)
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+func buildGenerateCoverPromptsPrompt(input GenerateCoverPromptsInput) string {
+	charactersJSON := "[]"
+	if bs, err := json.MarshalIndent(input.Characters, "", "  "); err == nil && len(input.Characters) > 0 {
+		charactersJSON = string(bs)
+	}
+
+	return fmt.Sprintf(`
+你是一名漫画封面美术指导。用户将给你小说《%s》开篇若干章的原文，你需要为这部漫画构思封面与作品背景图。
+
+用户指定的画风为：%s
+
+已识别的角色列表如下：
+
+%s
+
+请输出一个 JSON 对象，包含以下字段：
+1. protagonists：字符串数组，从角色列表中挑选 1 至 3 名主角，姓名必须与角色列表中的 name 完全一致。
+2. cover_prompt：英文的封面图提示词。以竖版构图突出主角的外貌、服装、姿态与气质，并体现故事的核心冲突、世界观与基调。
+3. background_prompt：英文的背景图提示词。以横版构图描绘故事的标志性场景，主角位于场景之中，突出氛围与光线。
+
+提示词中请直接描述角色的外貌特征，不要只写角色姓名；不得出现任何中文字符，也不要提示模型在图像中加入标题、文字或签名。
+仅输出一个合法的 JSON 对象，不要包含任何前导或后续的说明文字、代码块标记。
+`,
+		input.NovelTitle,
+		input.StylePrompt,
+		charactersJSON,
+	)
+}
+
+// GenerateCoverPrompts 阅读开篇章节与角色列表，撰写封面与背景图的英文提示词，并给出应出现在画面中的主角。
+func (g *GnxAIGC) GenerateCoverPrompts(ctx context.Context, input GenerateCoverPromptsInput) (*CoverPrompts, error) {
+	maxRunes := input.MaxRunes
+	if maxRunes <= 0 {
+		maxRunes = DefaultCoverSourceMaxRunes
+	}
+
+	source := buildCoverSourceText(input.Chapters, maxRunes)
+	if source == "" {
+		return nil, errors.New("no chapter content provided")
+	}
+
+	var output CoverPrompts
+	if err := g.completeJSON(ctx, "GenerateCoverPrompts", buildGenerateCoverPromptsPrompt(input), source, &output); err != nil {
+		return nil, err
+	}
+
+	output.CoverPrompt = strings.TrimSpace(output.CoverPrompt)
+	output.BackgroundPrompt = strings.TrimSpace(output.BackgroundPrompt)
+	if output.CoverPrompt == "" || output.BackgroundPrompt == "" {
+		return nil, errors.New("cover or background prompt is empty")
+	}
+
+	return &output, nil
+}
@@ -0,0 +1,19 @@
+package gnxaigc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCoverSourceText(t *testing.T) {
+	chapters := []CoverChapter{
+		{Title: "第一章 山边小村", Content: "二愣子睁大着双眼"},
+		{Title: "第二章 青牛镇", Content: "这是一个小城镇"},
+		{Title: "第三章 七玄门", Content: "不会出现"},
+	}
+
+	text := buildCoverSourceText(chapters, 12)
+	require.Equal(t, "《第一章 山边小村》\n二愣子睁大着双眼\n\n《第二章 青牛镇》\n这是一个", text)
+	require.Empty(t, buildCoverSourceText(nil, 12))
+}
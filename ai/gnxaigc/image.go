@@ -19,6 +19,7 @@ type Config struct {
 	BaseURL       string `json:"base_url,omitempty"`
 	ImageModel    string `json:"image_model,omitempty"`
 	LanguageModel string `json:"language_model,omitempty"`
+	VisionModel   string `json:"vision_model,omitempty"`
 }
 
 func (c *Config) validate() {
@@ -26,6 +27,7 @@ func (c *Config) validate() {
 	c.BaseURL = cmp.Or(c.BaseURL, os.Getenv("OPENAI_BASE_URL"), "https://openai.qiniu.com/v1")
 	c.ImageModel = cmp.Or(c.ImageModel, "gemini-2.5-flash-image")
 	c.LanguageModel = cmp.Or(c.LanguageModel, "deepseek/deepseek-v3.1-terminus")
+	c.VisionModel = cmp.Or(c.VisionModel, "gemini-2.5-flash")
 }
 
 type GnxAIGC struct {
@@ -0,0 +1,75 @@
+package gnxaigc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	jsonrepair "github.com/RealAlexandreAI/json-repair"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/shared"
+)
+
+// completeJSON 以 system + user 两段消息请求语言模型输出 JSON，并在解析失败时尝试修复后再解析到 out。
+func (g *GnxAIGC) completeJSON(ctx context.Context, name, systemPrompt, userContent string, out any) error {
+	return g.completeJSONMessages(ctx, name, g.LanguageModel, []openai.ChatCompletionMessageParamUnion{
+		{
+			OfSystem: &openai.ChatCompletionSystemMessageParam{
+				Content: openai.ChatCompletionSystemMessageParamContentUnion{
+					OfString: openai.String(systemPrompt),
+				},
+			},
+		},
+		{
+			OfUser: &openai.ChatCompletionUserMessageParam{
+				Content: openai.ChatCompletionUserMessageParamContentUnion{
+					OfString: openai.String(userContent),
+				},
+			},
+		},
+	}, out)
+}
+
+// completeJSONMessages 以任意消息请求 model 输出 JSON 并解析到 out，视觉模型的多图消息同样经由此处。
+func (g *GnxAIGC) completeJSONMessages(ctx context.Context, name, model string, messages []openai.ChatCompletionMessageParamUnion, out any) error {
+	resp, err := g.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Model:    model,
+		N:        openai.Int(1),
+		Messages: messages,
+		ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate chat completion: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return errors.New("no chat completion choices received")
+	}
+
+	content := resp.Choices[0].Message.Content
+
+	fmt.Printf("%s chat completion content: %s\n", name, content)
+
+	return decodeJSONContent(content, out)
+}
+
+// decodeJSONContent 解析模型输出的 JSON，如果解析失败，则尝试修复后再解析。
+func decodeJSONContent(content string, out any) error {
+	if err := json.Unmarshal([]byte(content), out); err == nil {
+		return nil
+	}
+
+	contentFixed, err := jsonrepair.RepairJSON(content)
+	if err != nil {
+		return fmt.Errorf("failed to repair JSON content: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(contentFixed), out); err != nil {
+		return fmt.Errorf("failed to unmarshal repaired JSON content: %w", err)
+	}
+
+	return nil
+}
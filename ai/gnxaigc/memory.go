@@ -0,0 +1,118 @@
+package gnxaigc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultStoryMemoryMaxRunes 限制剧情梗概与世界状态各自注入提示词的最大字数。
+const DefaultStoryMemoryMaxRunes = 1500
+
+// StoryMemory 记录跨章节延续的剧情上下文，由语言模型在每章结束后滚动更新。
+type StoryMemory struct {
+	// Synopsis 截至当前章节的剧情梗概
+	Synopsis string `json:"synopsis"`
+	// WorldState 角色伤势、持有物品、所处位置、人物关系等需要延续的状态笔记
+	WorldState string `json:"world_state"`
+}
+
+// IsEmpty 判断是否尚未积累任何剧情记忆。
+func (m *StoryMemory) IsEmpty() bool {
+	return m == nil || (strings.TrimSpace(m.Synopsis) == "" && strings.TrimSpace(m.WorldState) == "")
+}
+
+// Bounded 返回按字数截断后的副本，梗概保留最近的内容。
+func (m *StoryMemory) Bounded(maxRunes int) StoryMemory {
+	if m == nil {
+		return StoryMemory{}
+	}
+	if maxRunes <= 0 {
+		maxRunes = DefaultStoryMemoryMaxRunes
+	}
+	return StoryMemory{
+		Synopsis:   truncateRunesKeepTail(strings.TrimSpace(m.Synopsis), maxRunes),
+		WorldState: truncateRunesKeepHead(strings.TrimSpace(m.WorldState), maxRunes),
+	}
+}
+
+type UpdateStoryMemoryInput struct {
+	// Novel Title 小说标题
+	NovelTitle string
+	// Chapter Title 刚处理完的章节标题
+	ChapterTitle string
+	// 刚处理完的章节原文
+	Content string
+	// Previous 截至上一章的剧情记忆
+	Previous *StoryMemory
+	// MaxRunes 梗概与世界状态各自的字数上限，默认 DefaultStoryMemoryMaxRunes
+	MaxRunes int
+}
+
+func buildStoryMemoryText(memory *StoryMemory) string {
+	if memory.IsEmpty() {
+		return "{}"
+	}
+	bounded := memory.Bounded(DefaultStoryMemoryMaxRunes)
+	bs, err := json.MarshalIndent(bounded, "", "  ")
+	if err != nil {
+		return "{}"
+	}
+	return string(bs)
+}
+
+func buildUpdateStoryMemoryPrompt(input UpdateStoryMemoryInput, maxRunes int) string {
+	return fmt.Sprintf(`
+你是一名负责小说连载设定管理的编辑。用户将给你小说《%s》刚刚完成改编的章节《%s》的原文，你需要结合已有的剧情记忆，输出更新后的剧情记忆。
+
+已有的剧情记忆如下（若为空表示这是第一章）：
+
+%s
+
+请输出一个 JSON 对象，包含以下两个字符串字段：
+1. synopsis：截至本章结束的剧情梗概。保留对后续章节仍有影响的关键事件，压缩或删除已经无关紧要的细节，不超过 %d 字。
+2. world_state：当前的世界状态笔记。逐条列出主要角色的伤势与身体状况、持有或失去的重要物品、所处地点、身份变化以及人物关系，已失效的状态需要删除，不超过 %d 字。
+
+请使用中文书写，仅输出一个合法的 JSON 对象，不要包含任何前导或后续的说明文字、代码块标记。
+`,
+		input.NovelTitle,
+		input.ChapterTitle,
+		buildStoryMemoryText(input.Previous),
+		maxRunes,
+		maxRunes,
+	)
+}
+
+// UpdateStoryMemory 根据刚处理完的章节原文滚动更新剧情梗概与世界状态。
+func (g *GnxAIGC) UpdateStoryMemory(ctx context.Context, input UpdateStoryMemoryInput) (*StoryMemory, error) {
+	maxRunes := input.MaxRunes
+	if maxRunes <= 0 {
+		maxRunes = DefaultStoryMemoryMaxRunes
+	}
+	prompt := buildUpdateStoryMemoryPrompt(input, maxRunes)
+
+	var output StoryMemory
+	if err := g.completeJSON(ctx, "UpdateStoryMemory", prompt, input.Content, &output); err != nil {
+		return nil, err
+	}
+
+	bounded := output.Bounded(maxRunes)
+	return &bounded, nil
+}
+
+func truncateRunesKeepHead(text string, maxRunes int) string {
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return text
+	}
+	return string(runes[:maxRunes])
+}
+
+func truncateRunesKeepTail(text string, maxRunes int) string {
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return text
+	}
+	return string(runes[len(runes)-maxRunes:])
+}
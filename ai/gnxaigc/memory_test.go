@@ -0,0 +1,24 @@
+package gnxaigc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoryMemoryBounded(t *testing.T) {
+	memory := &StoryMemory{
+		Synopsis:   strings.Repeat("甲", 10) + "韩立进入七玄门",
+		WorldState: "韩立：左臂受伤" + strings.Repeat("乙", 10),
+	}
+
+	bounded := memory.Bounded(7)
+	require.Equal(t, "韩立进入七玄门", bounded.Synopsis)
+	require.Equal(t, "韩立：左臂受伤", bounded.WorldState)
+
+	require.True(t, (*StoryMemory)(nil).IsEmpty())
+	require.True(t, (&StoryMemory{Synopsis: "  "}).IsEmpty())
+	require.False(t, memory.IsEmpty())
+	require.Equal(t, "{}", buildStoryMemoryText(nil))
+}
@@ -0,0 +1,140 @@
+package gnxaigc
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// ReviewCharacter 质检时提供给视觉模型的角色参考图
+type ReviewCharacter struct {
+	Name      string
+	ImageData []byte
+}
+
+type ReviewPageImageInput struct {
+	// PageImage 待检查的整页图片
+	PageImage []byte
+	// ExpectedPanels 分镜中该页应有的分格数量
+	ExpectedPanels int
+	// LayoutHint 分镜给出的版式描述
+	LayoutHint string
+	// Characters 本页出场角色的设定图，用于比对外貌一致性
+	Characters []ReviewCharacter
+}
+
+// CharacterMatch 单个角色与设定图的相似度评价
+type CharacterMatch struct {
+	Name string `json:"name"`
+	// Score 0~1，1 表示与设定图完全一致
+	Score   float64 `json:"score"`
+	Comment string  `json:"comment"`
+}
+
+// PageReview 视觉模型对整页图片的质检结果
+type PageReview struct {
+	// HasText 画面中是否出现了文字、字母或乱码
+	HasText bool `json:"has_text"`
+	// DetectedPanels 画面中实际识别出的分格数量
+	DetectedPanels int              `json:"detected_panels"`
+	Characters     []CharacterMatch `json:"characters"`
+	Issues         []string         `json:"issues"`
+}
+
+// CharacterScore 返回所有角色中最低的相似度，没有需要比对的角色时视为满分。
+func (r *PageReview) CharacterScore() float64 {
+	if len(r.Characters) == 0 {
+		return 1
+	}
+	score := 1.0
+	for _, c := range r.Characters {
+		score = min(score, max(c.Score, 0))
+	}
+	return score
+}
+
+// Passed 判断质检是否通过：无文字、分格数量与分镜一致、角色相似度不低于 minCharacterScore。
+// expectedPanels 不大于 0 时跳过分格检查。
+func (r *PageReview) Passed(expectedPanels int, minCharacterScore float64) bool {
+	if r.HasText {
+		return false
+	}
+	if expectedPanels > 0 && r.DetectedPanels != expectedPanels {
+		return false
+	}
+	return r.CharacterScore() >= minCharacterScore
+}
+
+func buildReviewPageImagePrompt(input ReviewPageImageInput) string {
+	names := make([]string, 0, len(input.Characters))
+	for _, c := range input.Characters {
+		names = append(names, c.Name)
+	}
+	characterList := "（本页没有需要比对的角色）"
+	if len(names) > 0 {
+		characterList = strings.Join(names, "、")
+	}
+
+	return fmt.Sprintf(`
+你是一名严格的漫画质检编辑。第一张图片是待检查的漫画页面，其后的图片依次是角色设定图，每张设定图之前会标注角色名。
+
+请按以下清单逐项检查页面：
+1. 文字：页面中是否出现任何文字、字母、数字、对话框内的字迹或难以辨认的乱码。对白会在后期单独排版，因此画面中不应出现任何文字。
+2. 分格：统计页面中实际的分格数量。分镜要求本页共 %d 格，版式描述为「%s」。
+3. 角色：对比页面中出现的角色与设定图（%s），从发型、发色、脸型、肤色、服装等方面给出 0 到 1 之间的相似度，1 表示完全一致。页面中未出现的角色不要评分。
+
+请输出一个 JSON 对象，包含以下字段：
+- has_text：布尔值，页面中出现文字或乱码时为 true
+- detected_panels：整数，实际识别出的分格数量
+- characters：数组，每项包含 name（角色名，必须与设定图标注一致）、score（0 到 1 的小数）、comment（简短说明差异）
+- issues：字符串数组，用中文简要列出发现的问题，没有问题时为空数组
+
+仅输出一个合法的 JSON 对象，不要包含任何前导或后续的说明文字、代码块标记。
+`,
+		input.ExpectedPanels,
+		input.LayoutHint,
+		characterList,
+	)
+}
+
+// ReviewPageImage 将整页图片与角色设定图发送给视觉模型，按清单检查文字、分格数量与角色一致性。
+func (g *GnxAIGC) ReviewPageImage(ctx context.Context, input ReviewPageImageInput) (*PageReview, error) {
+	if len(input.PageImage) == 0 {
+		return nil, errors.New("page image is empty")
+	}
+
+	parts := []openai.ChatCompletionContentPartUnionParam{
+		openai.TextContentPart("待检查的漫画页面："),
+		imageContentPart(input.PageImage),
+	}
+	for _, c := range input.Characters {
+		if len(c.ImageData) == 0 {
+			continue
+		}
+		parts = append(parts,
+			openai.TextContentPart(fmt.Sprintf("角色设定图：%s", c.Name)),
+			imageContentPart(c.ImageData),
+		)
+	}
+
+	var review PageReview
+	err := g.completeJSONMessages(ctx, "ReviewPageImage", g.VisionModel, []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage(buildReviewPageImagePrompt(input)),
+		openai.UserMessage(parts),
+	}, &review)
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// imageContentPart 将图片编码为 data URL 形式的消息内容。
+func imageContentPart(data []byte) openai.ChatCompletionContentPartUnionParam {
+	url := fmt.Sprintf("data:%s;base64,%s", http.DetectContentType(data), base64.StdEncoding.EncodeToString(data))
+	return openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{URL: url})
+}
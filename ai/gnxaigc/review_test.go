@@ -0,0 +1,27 @@
+package gnxaigc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageReviewPassed(t *testing.T) {
+	review := &PageReview{
+		DetectedPanels: 3,
+		Characters: []CharacterMatch{
+			{Name: "韩立", Score: 0.9},
+			{Name: "厉飞雨", Score: 0.6},
+		},
+	}
+	require.Equal(t, 0.6, review.CharacterScore())
+	require.True(t, review.Passed(3, 0.6))
+	require.False(t, review.Passed(3, 0.7))
+	require.False(t, review.Passed(4, 0.6))
+	require.True(t, review.Passed(0, 0.6))
+
+	review.HasText = true
+	require.False(t, review.Passed(3, 0.6))
+
+	require.Equal(t, 1.0, (&PageReview{}).CharacterScore())
+}
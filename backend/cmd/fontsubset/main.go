@@ -0,0 +1,496 @@
+// Command fontsubset builds the lettering font embedded in pkg/imageutil.
+//
+// It keeps ASCII, every GB2312 character and a few extra punctuation marks
+// from a CJK OpenType font, converts the CFF outlines to TrueType quadratic
+// outlines and writes a standalone TrueType file. The result covers the text
+// found in Simplified Chinese novels at a fraction of the source font's size;
+// deployments that need full coverage point LETTERING_FONT_PATH at the
+// original font instead.
+//
+// Usage (from backend/pkg/imageutil, with NotoSansCJKsc-Bold.otf downloaded
+// from https://github.com/notofonts/noto-cjk):
+//
+//	go run ../../cmd/fontsubset -in NotoSansCJKsc-Bold.otf -out fonts/NotoSansCJKsc-Bold-subset.ttf
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"unicode/utf16"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// extraRunes are punctuation marks common in web novels that GB2312 lacks.
+const extraRunes = "—–―…⋯‧•·「」『』〈〉《》〔〕【】～￥€™©®"
+
+// curveTolerance is the largest allowed deviation, in font units, between a
+// cubic segment and the quadratic segments replacing it.
+const curveTolerance = 0.5
+
+func main() {
+	in := flag.String("in", "", "source OpenType font")
+	out := flag.String("out", "", "output TrueType font")
+	flag.Parse()
+	if *in == "" || *out == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := sfnt.Parse(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	subset, err := build(src, subsetRunes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, subset, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %s (%d bytes)", *out, len(subset))
+}
+
+// subsetRunes returns ASCII, the GB2312 repertoire and extraRunes.
+func subsetRunes() []rune {
+	seen := make(map[rune]bool)
+	var runes []rune
+	add := func(r rune) {
+		if !seen[r] && r != '�' {
+			seen[r] = true
+			runes = append(runes, r)
+		}
+	}
+
+	for r := rune(0x20); r < 0x7f; r++ {
+		add(r)
+	}
+	decoder := simplifiedchinese.GBK.NewDecoder()
+	for hi := 0xa1; hi <= 0xf7; hi++ {
+		for lo := 0xa1; lo <= 0xfe; lo++ {
+			decoded, err := decoder.Bytes([]byte{byte(hi), byte(lo)})
+			if err != nil {
+				continue
+			}
+			for _, r := range string(decoded) {
+				add(r)
+			}
+		}
+	}
+	for _, r := range extraRunes {
+		add(r)
+	}
+	return runes
+}
+
+type point struct {
+	x, y    int
+	onCurve bool
+}
+
+type glyph struct {
+	contours [][]point
+	advance  int
+}
+
+func (g *glyph) bounds() (xMin, yMin, xMax, yMax int) {
+	xMin, yMin, xMax, yMax = math.MaxInt, math.MaxInt, math.MinInt, math.MinInt
+	for _, contour := range g.contours {
+		for _, p := range contour {
+			xMin, xMax = min(xMin, p.x), max(xMax, p.x)
+			yMin, yMax = min(yMin, p.y), max(yMax, p.y)
+		}
+	}
+	if xMin > xMax {
+		return 0, 0, 0, 0
+	}
+	return
+}
+
+func build(src *sfnt.Font, runes []rune) ([]byte, error) {
+	var buf sfnt.Buffer
+	upm := int(src.UnitsPerEm())
+	ppem := fixed.I(upm)
+
+	// Glyph 0 stays .notdef; the other glyphs follow in rune order.
+	sourceIDs := []sfnt.GlyphIndex{0}
+	newIDs := map[sfnt.GlyphIndex]int{0: 0}
+	cmap := make(map[rune]int)
+	for _, r := range runes {
+		id, err := src.GlyphIndex(&buf, r)
+		if err != nil {
+			return nil, err
+		}
+		if id == 0 {
+			continue
+		}
+		if _, ok := newIDs[id]; !ok {
+			newIDs[id] = len(sourceIDs)
+			sourceIDs = append(sourceIDs, id)
+		}
+		cmap[r] = newIDs[id]
+	}
+
+	glyphs := make([]glyph, len(sourceIDs))
+	for i, id := range sourceIDs {
+		segments, err := src.LoadGlyph(&buf, id, ppem, nil)
+		if err != nil {
+			return nil, fmt.Errorf("glyph %d: %w", id, err)
+		}
+		advance, err := src.GlyphAdvance(&buf, id, ppem, font.HintingNone)
+		if err != nil {
+			return nil, fmt.Errorf("glyph %d: %w", id, err)
+		}
+		glyphs[i] = glyph{contours: convertSegments(segments), advance: int(math.Round(float64(advance) / 64))}
+	}
+
+	metrics, err := src.Metrics(&buf, ppem, font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[sfnt.NameID]string)
+	for _, id := range []sfnt.NameID{sfnt.NameIDCopyright, sfnt.NameIDLicense, sfnt.NameIDLicenseURL} {
+		if s, err := src.Name(&buf, id); err == nil {
+			names[id] = s
+		}
+	}
+	family, _ := src.Name(&buf, sfnt.NameIDFamily)
+	names[sfnt.NameIDFamily] = family + " Subset"
+	names[sfnt.NameIDSubfamily] = "Bold"
+	names[sfnt.NameIDFull] = family + " Subset Bold"
+	names[sfnt.NameIDPostScript] = "NotoSansCJKsc-Bold-Subset"
+
+	return writeFont(upm, glyphs, cmap, fontMetrics{
+		ascent:    int(math.Round(float64(metrics.Ascent) / 64)),
+		descent:   int(math.Round(float64(metrics.Descent) / 64)),
+		lineGap:   int(math.Round(float64(metrics.Height-metrics.Ascent-metrics.Descent) / 64)),
+		xHeight:   int(math.Round(float64(metrics.XHeight) / 64)),
+		capHeight: int(math.Round(float64(metrics.CapHeight) / 64)),
+	}, names), nil
+}
+
+type vec struct{ x, y float64 }
+
+func (a vec) add(b vec) vec        { return vec{a.x + b.x, a.y + b.y} }
+func (a vec) sub(b vec) vec        { return vec{a.x - b.x, a.y - b.y} }
+func (a vec) scale(k float64) vec  { return vec{a.x * k, a.y * k} }
+func lerp(a, b vec, t float64) vec { return a.add(b.sub(a).scale(t)) }
+func (a vec) length() float64      { return math.Hypot(a.x, a.y) }
+func toVec(p fixed.Point26_6) vec  { return vec{float64(p.X) / 64, -float64(p.Y) / 64} }
+func (a vec) point(on bool) point  { return point{int(math.Round(a.x)), int(math.Round(a.y)), on} }
+
+// convertSegments turns sfnt segments (y down, 26.6 font units) into closed
+// TrueType contours (y up), splitting every cubic into quadratics.
+func convertSegments(segments sfnt.Segments) [][]point {
+	var contours [][]point
+	var current []point
+	var pen vec
+	flush := func() {
+		if len(current) > 1 && current[len(current)-1] == current[0] {
+			current = current[:len(current)-1]
+		}
+		if len(current) > 2 {
+			contours = append(contours, current)
+		}
+		current = nil
+	}
+	appendPoint := func(p point) {
+		if n := len(current); n > 0 && p.onCurve && current[n-1] == p {
+			return
+		}
+		current = append(current, p)
+	}
+
+	for _, seg := range segments {
+		switch seg.Op {
+		case sfnt.SegmentOpMoveTo:
+			flush()
+			pen = toVec(seg.Args[0])
+			appendPoint(pen.point(true))
+		case sfnt.SegmentOpLineTo:
+			pen = toVec(seg.Args[0])
+			appendPoint(pen.point(true))
+		case sfnt.SegmentOpQuadTo:
+			appendPoint(toVec(seg.Args[0]).point(false))
+			pen = toVec(seg.Args[1])
+			appendPoint(pen.point(true))
+		case sfnt.SegmentOpCubeTo:
+			c1, c2, end := toVec(seg.Args[0]), toVec(seg.Args[1]), toVec(seg.Args[2])
+			for _, q := range cubicToQuads(pen, c1, c2, end) {
+				appendPoint(q[0].point(false))
+				appendPoint(q[1].point(true))
+			}
+			pen = end
+		}
+	}
+	flush()
+	return contours
+}
+
+// cubicToQuads approximates a cubic Bézier with n quadratics, choosing n so
+// that the midpoint approximation error stays within curveTolerance.
+func cubicToQuads(p0, c1, c2, p3 vec) [][2]vec {
+	third := p3.sub(c2.scale(3)).add(c1.scale(3)).sub(p0)
+	errEstimate := math.Sqrt(3) / 36 * third.length()
+	n := max(1, int(math.Ceil(math.Cbrt(errEstimate/curveTolerance))))
+
+	quads := make([][2]vec, 0, n)
+	for i := 0; i < n; i++ {
+		t0, t1 := float64(i)/float64(n), float64(i+1)/float64(n)
+		a, b, c, d := subCubic(p0, c1, c2, p3, t0, t1)
+		control := b.add(c).scale(3).sub(a).sub(d).scale(0.25)
+		quads = append(quads, [2]vec{control, d})
+	}
+	return quads
+}
+
+// subCubic returns the control points of the cubic restricted to [t0, t1].
+func subCubic(p0, c1, c2, p3 vec, t0, t1 float64) (vec, vec, vec, vec) {
+	at := func(t float64) vec {
+		a, b, c := lerp(p0, c1, t), lerp(c1, c2, t), lerp(c2, p3, t)
+		d, e := lerp(a, b, t), lerp(b, c, t)
+		return lerp(d, e, t)
+	}
+	deriv := func(t float64) vec {
+		a, b, c := c1.sub(p0), c2.sub(c1), p3.sub(c2)
+		return lerp(lerp(a, b, t), lerp(b, c, t), t).scale(3)
+	}
+	h := t1 - t0
+	start, end := at(t0), at(t1)
+	return start, start.add(deriv(t0).scale(h / 3)), end.sub(deriv(t1).scale(h / 3)), end
+}
+
+type fontMetrics struct {
+	ascent, descent, lineGap, xHeight, capHeight int
+}
+
+func writeFont(upm int, glyphs []glyph, cmap map[rune]int, m fontMetrics, names map[sfnt.NameID]string) []byte {
+	glyf, loca, hmtx := new(bytes.Buffer), new(bytes.Buffer), new(bytes.Buffer)
+	xMin, yMin, xMax, yMax := math.MaxInt, math.MaxInt, math.MinInt, math.MinInt
+	maxPoints, maxContours, maxAdvance := 0, 0, 0
+	for _, g := range glyphs {
+		be(loca, uint32(glyf.Len()))
+		gx0, gy0, gx1, gy1 := g.bounds()
+		be(hmtx, uint16(g.advance), int16(gx0))
+		maxAdvance = max(maxAdvance, g.advance)
+		if len(g.contours) == 0 {
+			continue
+		}
+		xMin, yMin, xMax, yMax = min(xMin, gx0), min(yMin, gy0), max(xMax, gx1), max(yMax, gy1)
+		maxContours = max(maxContours, len(g.contours))
+		writeGlyph(glyf, &g, gx0, gy0, gx1, gy1, &maxPoints)
+	}
+	be(loca, uint32(glyf.Len()))
+
+	tables := map[string][]byte{
+		"glyf": glyf.Bytes(),
+		"loca": loca.Bytes(),
+		"hmtx": hmtx.Bytes(),
+		"cmap": cmapTable(cmap),
+		"name": nameTable(names),
+	}
+
+	head := new(bytes.Buffer)
+	be(head, uint32(0x00010000), uint32(0x00010000), uint32(0), uint32(0x5F0F3CF5),
+		uint16(0x000B), uint16(upm), int64(0), int64(0),
+		int16(xMin), int16(yMin), int16(xMax), int16(yMax),
+		uint16(1), uint16(8), int16(2), int16(1), int16(0))
+	tables["head"] = head.Bytes()
+
+	hhea := new(bytes.Buffer)
+	be(hhea, uint32(0x00010000), int16(m.ascent), int16(-m.descent), int16(m.lineGap),
+		uint16(maxAdvance), int16(xMin), int16(0), int16(xMax),
+		int16(1), int16(0), int16(0), [4]int16{}, int16(0), uint16(len(glyphs)))
+	tables["hhea"] = hhea.Bytes()
+
+	maxp := new(bytes.Buffer)
+	be(maxp, uint32(0x00010000), uint16(len(glyphs)), uint16(maxPoints), uint16(maxContours),
+		uint16(0), uint16(0), uint16(2), uint16(0), uint16(0), uint16(0),
+		uint16(0), uint16(0), uint16(0), uint16(0), uint16(0))
+	tables["maxp"] = maxp.Bytes()
+
+	os2 := new(bytes.Buffer)
+	be(os2, uint16(4), int16(upm/2), uint16(700), uint16(5), uint16(0),
+		[8]int16{}, int16(0), int16(0), int16(0),
+		[10]byte{}, [4]uint32{}, [4]byte{'N', 'O', 'N', 'E'}, uint16(0x20),
+		uint16(0x20), uint16(0xFFFF),
+		int16(m.ascent), int16(-m.descent), int16(m.lineGap),
+		uint16(m.ascent), uint16(m.descent), [2]uint32{},
+		int16(m.xHeight), int16(m.capHeight), uint16(0), uint16(0x20), uint16(0))
+	tables["OS/2"] = os2.Bytes()
+
+	post := new(bytes.Buffer)
+	be(post, uint32(0x00030000), int32(0), int16(-100), int16(50), uint32(0), [4]uint32{})
+	tables["post"] = post.Bytes()
+
+	return assemble(tables)
+}
+
+func writeGlyph(w *bytes.Buffer, g *glyph, xMin, yMin, xMax, yMax int, maxPoints *int) {
+	start := w.Len()
+	be(w, int16(len(g.contours)), int16(xMin), int16(yMin), int16(xMax), int16(yMax))
+
+	var flags []byte
+	xs, ys := new(bytes.Buffer), new(bytes.Buffer)
+	last, end := point{}, -1
+	for _, contour := range g.contours {
+		end += len(contour)
+		be(w, uint16(end))
+		for _, p := range contour {
+			var flag byte
+			if p.onCurve {
+				flag |= 0x01
+			}
+			flag |= encodeDelta(xs, p.x-last.x, 0x02, 0x10)
+			flag |= encodeDelta(ys, p.y-last.y, 0x04, 0x20)
+			flags = append(flags, flag)
+			last = p
+		}
+	}
+	*maxPoints = max(*maxPoints, end+1)
+	be(w, uint16(0))
+	w.Write(flags)
+	w.Write(xs.Bytes())
+	w.Write(ys.Bytes())
+	for (w.Len()-start)%4 != 0 {
+		w.WriteByte(0)
+	}
+}
+
+// encodeDelta writes one coordinate delta and returns its flag bits.
+func encodeDelta(w *bytes.Buffer, d int, short, same byte) byte {
+	switch {
+	case d == 0:
+		return same
+	case d > 0 && d < 256:
+		w.WriteByte(byte(d))
+		return short | same
+	case d < 0 && d > -256:
+		w.WriteByte(byte(-d))
+		return short
+	default:
+		be(w, int16(d))
+		return 0
+	}
+}
+
+// cmapTable writes a single Windows UCS-4 format 12 subtable.
+func cmapTable(cmap map[rune]int) []byte {
+	runes := make([]rune, 0, len(cmap))
+	for r := range cmap {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+
+	type group struct {
+		start, end rune
+		glyph      int
+	}
+	var groups []group
+	for _, r := range runes {
+		if n := len(groups); n > 0 && groups[n-1].end+1 == r && groups[n-1].glyph+int(r-groups[n-1].start) == cmap[r] {
+			groups[n-1].end = r
+			continue
+		}
+		groups = append(groups, group{r, r, cmap[r]})
+	}
+
+	w := new(bytes.Buffer)
+	be(w, uint16(0), uint16(1), uint16(3), uint16(10), uint32(12))
+	be(w, uint16(12), uint16(0), uint32(16+12*len(groups)), uint32(0), uint32(len(groups)))
+	for _, g := range groups {
+		be(w, uint32(g.start), uint32(g.end), uint32(g.glyph))
+	}
+	return w.Bytes()
+}
+
+// nameTable writes Windows English (US) name records in UTF-16BE.
+func nameTable(names map[sfnt.NameID]string) []byte {
+	ids := make([]sfnt.NameID, 0, len(names))
+	for id := range names {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	records, storage := new(bytes.Buffer), new(bytes.Buffer)
+	for _, id := range ids {
+		encoded := utf16.Encode([]rune(names[id]))
+		be(records, uint16(3), uint16(1), uint16(0x409), uint16(id), uint16(2*len(encoded)), uint16(storage.Len()))
+		be(storage, encoded)
+	}
+	w := new(bytes.Buffer)
+	be(w, uint16(0), uint16(len(ids)), uint16(6+records.Len()))
+	w.Write(records.Bytes())
+	w.Write(storage.Bytes())
+	return w.Bytes()
+}
+
+// assemble lays out the table directory and tables, then fixes up the head
+// table's checksum adjustment.
+func assemble(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	numTables := len(tags)
+	entrySelector := int(math.Floor(math.Log2(float64(numTables))))
+	searchRange := (1 << entrySelector) * 16
+
+	w := new(bytes.Buffer)
+	be(w, uint32(0x00010000), uint16(numTables), uint16(searchRange), uint16(entrySelector), uint16(numTables*16-searchRange))
+
+	offset := 12 + 16*numTables
+	headOffset := 0
+	for _, tag := range tags {
+		data := tables[tag]
+		if tag == "head" {
+			headOffset = offset
+		}
+		w.WriteString(tag)
+		be(w, checksum(data), uint32(offset), uint32(len(data)))
+		offset += (len(data) + 3) &^ 3
+	}
+	for _, tag := range tags {
+		data := tables[tag]
+		w.Write(data)
+		w.Write(make([]byte, ((len(data)+3)&^3)-len(data)))
+	}
+
+	out := w.Bytes()
+	binary.BigEndian.PutUint32(out[headOffset+8:], 0xB1B0AFBA-checksum(out))
+	return out
+}
+
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+func be(w *bytes.Buffer, values ...any) {
+	for _, v := range values {
+		if err := binary.Write(w, binary.BigEndian, v); err != nil {
+			panic(err)
+		}
+	}
+}
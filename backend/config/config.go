@@ -2,6 +2,9 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -9,6 +12,10 @@ type Config struct {
 	Database DatabaseConfig
 	Storage  StorageConfig
 	AI       AIConfig
+	Pipeline PipelineConfig
+	Jobs     JobConfig
+	Novel    NovelConfig
+	TTS      TTSConfig
 }
 
 type ServerConfig struct {
@@ -36,6 +43,70 @@ type AIConfig struct {
 	BaseURL       string
 	ImageModel    string
 	LanguageModel string
+	VisionModel   string
+}
+
+const (
+	// RenderModePage 每页调用一次图像模型，由模型按 LayoutHint 自行分格
+	RenderModePage = "page"
+	// RenderModePanel 逐格调用图像模型，再在本地按版式拼合整页
+	RenderModePanel = "panel"
+)
+
+type PipelineConfig struct {
+	RenderMode string
+	// LetteringEnabled 开启后为每页额外生成带对白气泡与旁白框的图层
+	LetteringEnabled bool
+	// LetteringFontPath 对白气泡使用的字体文件，留空时使用内置的 GB2312 子集字体
+	LetteringFontPath string
+	// CharacterSheetsEnabled 开启后为每个角色额外生成正面、侧面、背面视图与常用表情图，用作分格参考图
+	CharacterSheetsEnabled bool
+	// QAEnabled 开启后由视觉模型检查每页的文字、分格数量与角色一致性，不合格时重新生成
+	QAEnabled bool
+	// QAMaxRetries 单页质检不合格时最多重新生成的次数
+	QAMaxRetries int
+	// QAMinCharacterScore 角色与设定图相似度的最低合格分（0~1）
+	QAMinCharacterScore float64
+	// ImageConcurrency 进程内同时调用图像模型的上限，所有页面、分格与角色图共享
+	ImageConcurrency int
+}
+
+type JobConfig struct {
+	// Workers 同时执行的任务数
+	Workers int
+	// Lease 任务租约时长，执行期间按租约的三分之一间隔续期
+	Lease time.Duration
+	// PollInterval 没有可执行任务时的轮询间隔
+	PollInterval time.Duration
+	// MaxAttempts 任务的最大执行次数
+	MaxAttempts int
+	// StageWorkers 各阶段最多占用的工作协程数，未配置的阶段只受 Workers 限制。
+	// 限制图片等耗时阶段可为后续章节的分镜任务保留工作协程，使两者并行推进
+	StageWorkers map[string]int
+}
+
+type NovelConfig struct {
+	// ChapterPatterns 追加到内置规则之后的章节标题正则
+	ChapterPatterns []string
+	// VolumePatterns 追加到内置规则之后的卷标题正则
+	VolumePatterns []string
+	// FallbackChapterRunes 全文没有可识别的标题时，按段落切分的目标章节字数
+	FallbackChapterRunes int
+	// ResectionTargetPages 重新分段时每章的目标页数
+	ResectionTargetPages int
+	// RunesPerPage 估算页数时每页对应的字数
+	RunesPerPage int
+	// CleanupPatterns 追加到内置清理规则之后的正则，匹配到的文本会从正文中删除
+	CleanupPatterns []string
+}
+
+type TTSConfig struct {
+	// CacheEnabled 开启后合成的语音按文本、音色与语速的哈希缓存在对象存储中，参数相同时不再重复合成
+	CacheEnabled bool
+	// Concurrency 进程内同时调用语音合成的上限，预合成任务与实时请求共享
+	Concurrency int
+	// NarratorVoiceType 漫画未设置旁白音色时旁白与未指定音色的对白使用的音色
+	NarratorVoiceType string
 }
 
 func Load() *Config {
@@ -62,6 +133,37 @@ func Load() *Config {
 			BaseURL:       getEnv("OPENAI_BASE_URL", "https://openai.qiniu.com/v1"),
 			ImageModel:    getEnv("OPENAI_IMAGE_MODEL", "gemini-2.5-flash-image"),
 			LanguageModel: getEnv("OPENAI_LANGUAGE_MODEL", "deepseek/deepseek-v3.1-terminus"),
+			VisionModel:   getEnv("OPENAI_VISION_MODEL", "gemini-2.5-flash"),
+		},
+		Pipeline: PipelineConfig{
+			RenderMode:             getEnv("RENDER_MODE", RenderModePage),
+			LetteringEnabled:       getEnvBool("LETTERING_ENABLED", false),
+			LetteringFontPath:      getEnv("LETTERING_FONT_PATH", ""),
+			CharacterSheetsEnabled: getEnvBool("CHARACTER_SHEETS_ENABLED", false),
+			QAEnabled:              getEnvBool("QA_ENABLED", false),
+			QAMaxRetries:           getEnvInt("QA_MAX_RETRIES", 2),
+			QAMinCharacterScore:    getEnvFloat("QA_MIN_CHARACTER_SCORE", 0.6),
+			ImageConcurrency:       getEnvInt("IMAGE_CONCURRENCY", 4),
+		},
+		Jobs: JobConfig{
+			Workers:      getEnvInt("JOB_WORKERS", 4),
+			Lease:        time.Duration(getEnvInt("JOB_LEASE_SECONDS", 120)) * time.Second,
+			PollInterval: time.Duration(getEnvInt("JOB_POLL_SECONDS", 2)) * time.Second,
+			MaxAttempts:  getEnvInt("JOB_MAX_ATTEMPTS", 3),
+			StageWorkers: getEnvStageLimits("JOB_STAGE_WORKERS", "page_image=2,tts=2"),
+		},
+		Novel: NovelConfig{
+			ChapterPatterns:      getEnvList("CHAPTER_PATTERNS", ";;"),
+			VolumePatterns:       getEnvList("VOLUME_PATTERNS", ";;"),
+			FallbackChapterRunes: getEnvInt("FALLBACK_CHAPTER_RUNES", 3000),
+			ResectionTargetPages: getEnvInt("RESECTION_TARGET_PAGES", 12),
+			RunesPerPage:         getEnvInt("RUNES_PER_PAGE", 300),
+			CleanupPatterns:      getEnvList("CLEANUP_PATTERNS", ";;"),
+		},
+		TTS: TTSConfig{
+			CacheEnabled:      getEnvBool("TTS_CACHE_ENABLED", true),
+			Concurrency:       getEnvInt("TTS_CONCURRENCY", 4),
+			NarratorVoiceType: getEnv("TTS_NARRATOR_VOICE_TYPE", "qiniu_zh_male_whxkxg"),
 		},
 	}
 }
@@ -72,3 +174,52 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvList 按 sep 拆分环境变量，忽略空项。正则中常见逗号与竖线，因此由调用方指定分隔符。
+func getEnvList(key, sep string) []string {
+	var values []string
+	for _, item := range strings.Split(os.Getenv(key), sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// getEnvStageLimits 解析形如 "page_image=2,storyboard=1" 的阶段上限配置，忽略格式错误或非正数的项。
+func getEnvStageLimits(key, defaultValue string) map[string]int {
+	limits := make(map[string]int)
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		stage, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n <= 0 {
+			continue
+		}
+		limits[strings.TrimSpace(stage)] = n
+	}
+	return limits
+}
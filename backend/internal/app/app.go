@@ -1,8 +1,15 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/cohesion-dev/GNX/backend_new/config"
 	"github.com/cohesion-dev/GNX/backend_new/internal/handlers"
@@ -10,12 +17,15 @@ import (
 	"github.com/cohesion-dev/GNX/backend_new/internal/services"
 	"github.com/cohesion-dev/GNX/backend_new/pkg/aigc"
 	"github.com/cohesion-dev/GNX/backend_new/pkg/database"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/imageutil"
 	"github.com/cohesion-dev/GNX/backend_new/pkg/storage"
 )
 
 type App struct {
-	config *config.Config
-	router *Router
+	config    *config.Config
+	router    *Router
+	jobRunner *services.JobRunner
+	events    *services.EventBroker
 }
 
 func NewApp() *App {
@@ -30,33 +40,84 @@ func NewApp() *App {
 
 	aigcClient := aigc.NewAIGC(&cfg.AI)
 
+	if cfg.Pipeline.LetteringFontPath != "" {
+		if err := imageutil.SetFontFile(cfg.Pipeline.LetteringFontPath); err != nil {
+			log.Printf("Failed to load lettering font, using the embedded font: %v", err)
+		}
+	}
+
 	comicRepo := repositories.NewComicRepository(db)
 	roleRepo := repositories.NewRoleRepository(db)
+	roleAssetRepo := repositories.NewRoleAssetRepository(db)
 	sectionRepo := repositories.NewSectionRepository(db)
 	pageRepo := repositories.NewPageRepository(db)
+	jobRepo := repositories.NewJobRepository(db)
+	revisionRepo := repositories.NewRevisionRepository(db)
+
+	eventBroker := services.NewEventBroker()
+
+	comicService := services.NewComicService(comicRepo, roleRepo, roleAssetRepo, sectionRepo, pageRepo, jobRepo, revisionRepo, eventBroker, storageClient, aigcClient, &cfg.Pipeline, &cfg.Jobs, &cfg.Novel)
+	jobRunner := services.NewJobRunner(jobRepo, eventBroker, &cfg.Jobs)
+	comicService.RegisterJobHandlers(jobRunner)
 
-	comicService := services.NewComicService(comicRepo, roleRepo, sectionRepo, pageRepo, storageClient, aigcClient)
 	imageService := services.NewImageService(storageClient)
-	ttsService := services.NewTTSService(pageRepo, roleRepo, aigcClient)
+	ttsService := services.NewTTSService(comicRepo, sectionRepo, pageRepo, roleRepo, eventBroker, storageClient, aigcClient, &cfg.TTS)
+	ttsService.RegisterJobHandlers(jobRunner)
 
 	comicHandler := handlers.NewComicHandler(comicService)
 	sectionHandler := handlers.NewSectionHandler(comicService)
 	imageHandler := handlers.NewImageHandler(imageService)
 	ttsHandler := handlers.NewTTSHandler(ttsService)
+	revisionHandler := handlers.NewRevisionHandler(comicService)
+	roleHandler := handlers.NewRoleHandler(comicService)
 
-	router := NewRouter(comicHandler, sectionHandler, imageHandler, ttsHandler)
+	router := NewRouter(comicHandler, sectionHandler, imageHandler, ttsHandler, revisionHandler, roleHandler)
 
 	return &App{
-		config: cfg,
-		router: router,
+		config:    cfg,
+		router:    router,
+		jobRunner: jobRunner,
+		events:    eventBroker,
 	}
 }
 
+// shutdownTimeout 收到退出信号后等待进行中的 HTTP 请求结束的最长时间
+const shutdownTimeout = 30 * time.Second
+
+// Run 启动任务执行器与 HTTP 服务，收到 SIGINT 或 SIGTERM 后停止接收请求，
+// 并等待执行中的任务交还任务表后返回。
 func (a *App) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	a.jobRunner.Start(ctx)
+
 	engine := a.router.Setup()
 
 	addr := fmt.Sprintf(":%s", a.config.Server.Port)
-	fmt.Printf("Server starting on %s\n", addr)
+	server := &http.Server{Addr: addr, Handler: engine}
+	server.RegisterOnShutdown(a.events.Close)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		fmt.Printf("Server starting on %s\n", addr)
+		serveErr <- server.ListenAndServe()
+	}()
 
-	return engine.Run(addr)
+	var err error
+	select {
+	case err = <-serveErr:
+		stop()
+	case <-ctx.Done():
+		log.Printf("Shutting down, waiting for in-flight requests and jobs")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		err = server.Shutdown(shutdownCtx)
+	}
+
+	a.jobRunner.Wait()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
@@ -7,11 +7,13 @@ import (
 )
 
 type Router struct {
-	engine         *gin.Engine
-	comicHandler   *handlers.ComicHandler
-	sectionHandler *handlers.SectionHandler
-	imageHandler   *handlers.ImageHandler
-	ttsHandler     *handlers.TTSHandler
+	engine          *gin.Engine
+	comicHandler    *handlers.ComicHandler
+	sectionHandler  *handlers.SectionHandler
+	imageHandler    *handlers.ImageHandler
+	ttsHandler      *handlers.TTSHandler
+	revisionHandler *handlers.RevisionHandler
+	roleHandler     *handlers.RoleHandler
 }
 
 func NewRouter(
@@ -19,6 +21,8 @@ func NewRouter(
 	sectionHandler *handlers.SectionHandler,
 	imageHandler *handlers.ImageHandler,
 	ttsHandler *handlers.TTSHandler,
+	revisionHandler *handlers.RevisionHandler,
+	roleHandler *handlers.RoleHandler,
 ) *Router {
 	engine := gin.New()
 	engine.Use(middleware.Logger())
@@ -26,22 +30,44 @@ func NewRouter(
 	engine.Use(middleware.CORS())
 
 	return &Router{
-		engine:         engine,
-		comicHandler:   comicHandler,
-		sectionHandler: sectionHandler,
-		imageHandler:   imageHandler,
-		ttsHandler:     ttsHandler,
+		engine:          engine,
+		comicHandler:    comicHandler,
+		sectionHandler:  sectionHandler,
+		imageHandler:    imageHandler,
+		ttsHandler:      ttsHandler,
+		revisionHandler: revisionHandler,
+		roleHandler:     roleHandler,
 	}
 }
 
 func (r *Router) Setup() *gin.Engine {
 	r.engine.GET("/api/comics/", r.comicHandler.ListComics)
 	r.engine.POST("/api/comics/", r.comicHandler.CreateComic)
+	r.engine.POST("/api/comics/preview", r.comicHandler.PreviewComic)
 	r.engine.GET("/api/comics/:comic_id/", r.comicHandler.GetComicDetail)
+	r.engine.GET("/api/comics/:comic_id/events", r.comicHandler.StreamEvents)
+	r.engine.PUT("/api/comics/:comic_id/narrator", r.comicHandler.UpdateNarrator)
+	r.engine.POST("/api/comics/:comic_id/pause", r.comicHandler.PauseComic)
+	r.engine.POST("/api/comics/:comic_id/resume", r.comicHandler.ResumeComic)
+	r.engine.POST("/api/comics/:comic_id/cancel", r.comicHandler.CancelComic)
 
 	r.engine.POST("/api/comics/:comic_id/sections/", r.sectionHandler.CreateSection)
 	r.engine.GET("/api/comics/:comic_id/sections/:section_id/", r.sectionHandler.GetSectionDetail)
 
+	r.engine.GET("/api/comics/:comic_id/roles/", r.roleHandler.ListRoles)
+	r.engine.POST("/api/comics/:comic_id/roles/", r.roleHandler.CreateRole)
+	r.engine.GET("/api/comics/:comic_id/roles/:role_id/", r.roleHandler.GetRole)
+	r.engine.PUT("/api/comics/:comic_id/roles/:role_id/", r.roleHandler.UpdateRole)
+	r.engine.DELETE("/api/comics/:comic_id/roles/:role_id/", r.roleHandler.DeleteRole)
+	r.engine.POST("/api/comics/:comic_id/roles/:role_id/portrait", r.roleHandler.UploadPortrait)
+	r.engine.GET("/api/comics/:comic_id/roles/:role_id/audition", r.ttsHandler.AuditionRole)
+
+	r.engine.POST("/api/comics/:comic_id/sections/:section_id/regenerate", r.revisionHandler.RegenerateSection)
+	r.engine.POST("/api/comics/:comic_id/pages/:page_id/regenerate", r.revisionHandler.RegeneratePage)
+	r.engine.POST("/api/comics/:comic_id/roles/:role_id/regenerate", r.revisionHandler.RegenerateRole)
+	r.engine.GET("/api/comics/:comic_id/revisions", r.revisionHandler.ListRevisions)
+	r.engine.POST("/api/comics/:comic_id/revisions/:revision_id/revert", r.revisionHandler.RevertRevision)
+
 	r.engine.GET("/api/images/:image_id/url", r.imageHandler.GetImageURL)
 
 	r.engine.GET("/api/tts/:tts_id", r.ttsHandler.GetTTSAudio)
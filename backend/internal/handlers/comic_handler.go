@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
+	"unicode/utf8"
 
 	"github.com/cohesion-dev/GNX/backend_new/internal/models"
 	"github.com/cohesion-dev/GNX/backend_new/internal/services"
 	"github.com/cohesion-dev/GNX/backend_new/internal/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type ComicHandler struct {
@@ -48,8 +53,14 @@ func (h *ComicHandler) CreateComic(c *gin.Context) {
 	title := c.PostForm("title")
 	userPrompt := c.PostForm("user_prompt")
 
-	if title == "" || userPrompt == "" {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "title and user_prompt are required")
+	if userPrompt == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "user_prompt is required")
+		return
+	}
+
+	opts, err := parseImportOptions(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", err.Error())
 		return
 	}
 
@@ -66,17 +77,112 @@ func (h *ComicHandler) CreateComic(c *gin.Context) {
 	}
 	defer fileContent.Close()
 
-	comic, err := h.comicService.CreateComic(c.Request.Context(), title, userPrompt, fileContent)
+	comic, report, err := h.comicService.CreateComic(c.Request.Context(), title, userPrompt, opts, fileContent)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidNovel) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Internal Server Error", err.Error())
 		return
 	}
 
 	utils.SuccessResponse(c, gin.H{
-		"id": strconv.FormatUint(uint64(comic.ID), 10),
+		"id":       strconv.FormatUint(uint64(comic.ID), 10),
+		"encoding": comic.SourceEncoding,
+		"cleanup":  report,
 	})
 }
 
+// PreviewComic 解析上传的小说并返回章节拆分与正文清理结果，不创建漫画。
+func (h *ComicHandler) PreviewComic(c *gin.Context) {
+	opts, err := parseImportOptions(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", err.Error())
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "file is required")
+		return
+	}
+
+	fileContent, err := file.Open()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Internal Server Error", err.Error())
+		return
+	}
+	defer fileContent.Close()
+
+	book, report, err := h.comicService.PreviewNovel(fileContent, opts)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidNovel) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Internal Server Error", err.Error())
+		return
+	}
+
+	chapters := make([]gin.H, 0, len(book.Chapters))
+	for _, chapter := range book.Chapters {
+		chapters = append(chapters, gin.H{
+			"title":         chapter.Title,
+			"volume":        chapter.Volume,
+			"runes":         utf8.RuneCountInString(chapter.Content),
+			"source_titles": chapter.SourceTitles,
+		})
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"title":    book.Title,
+		"author":   book.Author,
+		"encoding": book.Encoding,
+		"chapters": chapters,
+		"cleanup":  report,
+	})
+}
+
+// parseImportOptions 读取上传小说时的章节拆分、重新分段与清理选项。
+func parseImportOptions(c *gin.Context) (services.ImportOptions, error) {
+	opts := services.ImportOptions{ChapterPattern: c.PostForm("chapter_pattern")}
+	if value := c.PostForm("resection"); value != "" {
+		resection, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, errors.New("invalid resection")
+		}
+		opts.Resection = resection
+	}
+	if value := c.PostForm("target_pages"); value != "" {
+		targetPages, err := strconv.Atoi(value)
+		if err != nil || targetPages < 1 {
+			return opts, errors.New("invalid target_pages")
+		}
+		opts.TargetPages = targetPages
+	}
+
+	cleanup, err := parseCleanupOptions(c)
+	if err != nil {
+		return opts, err
+	}
+	opts.Cleanup = cleanup
+	return opts, nil
+}
+
+// parseCleanupOptions 读取正文清理选项：cleanup=false 关闭清理，cleanup_patterns 可重复提交多个正则。
+func parseCleanupOptions(c *gin.Context) (services.CleanupOptions, error) {
+	opts := services.CleanupOptions{Patterns: c.PostFormArray("cleanup_patterns")}
+	if value := c.PostForm("cleanup"); value != "" {
+		cleanup, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, errors.New("invalid cleanup")
+		}
+		opts.Skip = !cleanup
+	}
+	return opts, nil
+}
+
 func (h *ComicHandler) GetComicDetail(c *gin.Context) {
 	comicID, err := strconv.ParseUint(c.Param("comic_id"), 10, 32)
 	if err != nil {
@@ -93,9 +199,14 @@ func (h *ComicHandler) GetComicDetail(c *gin.Context) {
 	response := models.ComicDetailResponse{
 		ID:                strconv.FormatUint(uint64(comic.ID), 10),
 		Title:             comic.Title,
+		Author:            comic.Author,
+		SourceEncoding:    comic.SourceEncoding,
 		IconImageID:       comic.IconImageID,
 		BackgroundImageID: comic.BackgroundImageID,
 		Status:            comic.Status,
+		GenerationState:   comic.GenerationState,
+		NarratorVoiceType: comic.NarratorVoiceType,
+		NarratorVoiceName: comic.NarratorVoiceName,
 		Roles:             comic.Roles,
 		Sections:          comic.Sections,
 		CreatedAt:         comic.CreatedAt,
@@ -104,3 +215,112 @@ func (h *ComicHandler) GetComicDetail(c *gin.Context) {
 
 	utils.SuccessResponse(c, response)
 }
+
+// UpdateNarrator 设置漫画的旁白音色，voice_type 为空时恢复为默认音色。
+func (h *ComicHandler) UpdateNarrator(c *gin.Context) {
+	comicID, err := strconv.ParseUint(c.Param("comic_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "invalid comic_id")
+		return
+	}
+
+	comic, err := h.comicService.SetNarratorVoice(c.Request.Context(), uint(comicID), c.PostForm("voice_type"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidVoice) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		respondRevisionError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"id":                  strconv.FormatUint(uint64(comic.ID), 10),
+		"narrator_voice_type": comic.NarratorVoiceType,
+		"narrator_voice_name": comic.NarratorVoiceName,
+	})
+}
+
+func (h *ComicHandler) PauseComic(c *gin.Context) {
+	h.controlGeneration(c, h.comicService.PauseComic)
+}
+
+func (h *ComicHandler) ResumeComic(c *gin.Context) {
+	h.controlGeneration(c, h.comicService.ResumeComic)
+}
+
+func (h *ComicHandler) CancelComic(c *gin.Context) {
+	h.controlGeneration(c, h.comicService.CancelComic)
+}
+
+// controlGeneration 处理暂停、恢复与取消请求，返回漫画最新的生成控制状态。
+func (h *ComicHandler) controlGeneration(c *gin.Context, action func(uint) (*models.Comic, error)) {
+	comicID, err := strconv.ParseUint(c.Param("comic_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "invalid comic_id")
+		return
+	}
+
+	comic, err := action(uint(comicID))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "Not Found", err.Error())
+		case errors.Is(err, services.ErrGenerationCancelled):
+			utils.ErrorResponse(c, http.StatusConflict, "Conflict", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Internal Server Error", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, gin.H{
+		"id":               strconv.FormatUint(uint64(comic.ID), 10),
+		"generation_state": comic.GenerationState,
+	})
+}
+
+const eventKeepAliveInterval = 15 * time.Second
+
+// StreamEvents 以 Server-Sent Events 推送漫画的生成进度，连接建立后先发送一条当前状态的快照。
+func (h *ComicHandler) StreamEvents(c *gin.Context) {
+	comicID, err := strconv.ParseUint(c.Param("comic_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "invalid comic_id")
+		return
+	}
+
+	snapshot, events, unsubscribe, err := h.comicService.SubscribeEvents(uint(comicID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Not Found", err.Error())
+		return
+	}
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.SSEvent(snapshot.Type, snapshot)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		}
+	})
+}
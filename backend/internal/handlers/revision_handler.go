@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"github.com/cohesion-dev/GNX/backend_new/internal/services"
+	"github.com/cohesion-dev/GNX/backend_new/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RevisionHandler 处理页面图片、角色原画与章节分镜的重新生成与回退。
+type RevisionHandler struct {
+	comicService *services.ComicService
+}
+
+func NewRevisionHandler(comicService *services.ComicService) *RevisionHandler {
+	return &RevisionHandler{comicService: comicService}
+}
+
+func (h *RevisionHandler) RegeneratePage(c *gin.Context) {
+	comicID, pageID, ok := parseIDParams(c, "comic_id", "page_id")
+	if !ok {
+		return
+	}
+
+	job, revision, err := h.comicService.RegeneratePageImage(comicID, pageID, services.RegenerateOptions{
+		Prompt:      c.PostForm("prompt"),
+		Instruction: c.PostForm("instruction"),
+	})
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+	respondRegenerateAccepted(c, job, revision)
+}
+
+func (h *RevisionHandler) RegenerateRole(c *gin.Context) {
+	comicID, roleID, ok := parseIDParams(c, "comic_id", "role_id")
+	if !ok {
+		return
+	}
+
+	job, revision, err := h.comicService.RegenerateRolePortrait(comicID, roleID, services.RegenerateOptions{
+		Prompt:      c.PostForm("prompt"),
+		Instruction: c.PostForm("instruction"),
+	})
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+	respondRegenerateAccepted(c, job, revision)
+}
+
+func (h *RevisionHandler) RegenerateSection(c *gin.Context) {
+	comicID, sectionID, ok := parseIDParams(c, "comic_id", "section_id")
+	if !ok {
+		return
+	}
+
+	job, revision, err := h.comicService.RegenerateSectionStoryboard(comicID, sectionID, c.PostForm("instruction"))
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+	respondRegenerateAccepted(c, job, revision)
+}
+
+func (h *RevisionHandler) ListRevisions(c *gin.Context) {
+	comicID, err := strconv.ParseUint(c.Param("comic_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "invalid comic_id")
+		return
+	}
+
+	var targetID uint64
+	if raw := c.Query("target_id"); raw != "" {
+		targetID, err = strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "invalid target_id")
+			return
+		}
+	}
+
+	revisions, err := h.comicService.ListRevisions(uint(comicID), c.Query("target_type"), uint(targetID))
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(revisions))
+	for _, revision := range revisions {
+		items = append(items, revisionResponse(&revision))
+	}
+	utils.SuccessResponse(c, gin.H{"revisions": items})
+}
+
+func (h *RevisionHandler) RevertRevision(c *gin.Context) {
+	comicID, revisionID, ok := parseIDParams(c, "comic_id", "revision_id")
+	if !ok {
+		return
+	}
+
+	current, err := h.comicService.RevertRevision(comicID, revisionID)
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, gin.H{"revision": revisionResponse(current)})
+}
+
+func parseIDParams(c *gin.Context, parentKey, childKey string) (uint, uint, bool) {
+	parentID, err := strconv.ParseUint(c.Param(parentKey), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "invalid "+parentKey)
+		return 0, 0, false
+	}
+	childID, err := strconv.ParseUint(c.Param(childKey), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "invalid "+childKey)
+		return 0, 0, false
+	}
+	return uint(parentID), uint(childID), true
+}
+
+func respondRegenerateAccepted(c *gin.Context, job *models.ComicJob, revision *models.AssetRevision) {
+	c.JSON(http.StatusAccepted, utils.Response{
+		Code:    http.StatusAccepted,
+		Message: "已加入生成队列",
+		Data: gin.H{
+			"job_id":      strconv.FormatUint(uint64(job.ID), 10),
+			"revision_id": strconv.FormatUint(uint64(revision.ID), 10),
+		},
+	})
+}
+
+func respondRevisionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, services.ErrNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "Not Found", err.Error())
+	case errors.Is(err, services.ErrGenerationCancelled), errors.Is(err, services.ErrSectionBusy),
+		errors.Is(err, services.ErrPageBusy), errors.Is(err, services.ErrRoleBusy):
+		utils.ErrorResponse(c, http.StatusConflict, "Conflict", err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Internal Server Error", err.Error())
+	}
+}
+
+func revisionResponse(revision *models.AssetRevision) gin.H {
+	return gin.H{
+		"id":                strconv.FormatUint(uint64(revision.ID), 10),
+		"target_type":       revision.TargetType,
+		"target_id":         strconv.FormatUint(uint64(revision.TargetID), 10),
+		"prompt":            revision.Prompt,
+		"image_id":          revision.ImageID,
+		"lettered_image_id": revision.LetteredImageID,
+		"created_at":        revision.CreatedAt,
+	}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"github.com/cohesion-dev/GNX/backend_new/internal/services"
+	"github.com/cohesion-dev/GNX/backend_new/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// maxPortraitBytes 上传原画的大小上限
+const maxPortraitBytes = 10 << 20
+
+// RoleHandler 处理角色的查看、新建、修改、删除与原画上传。
+type RoleHandler struct {
+	comicService *services.ComicService
+}
+
+func NewRoleHandler(comicService *services.ComicService) *RoleHandler {
+	return &RoleHandler{comicService: comicService}
+}
+
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	comicID, err := strconv.ParseUint(c.Param("comic_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "invalid comic_id")
+		return
+	}
+
+	roles, err := h.comicService.ListRoles(uint(comicID))
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(roles))
+	for i := range roles {
+		items = append(items, roleResponse(&roles[i]))
+	}
+	utils.SuccessResponse(c, gin.H{"roles": items})
+}
+
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	comicID, roleID, ok := parseIDParams(c, "comic_id", "role_id")
+	if !ok {
+		return
+	}
+
+	role, assets, err := h.comicService.GetRole(comicID, roleID)
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	data := roleResponse(role)
+	sheet := make([]gin.H, 0, len(assets))
+	for _, asset := range assets {
+		sheet = append(sheet, gin.H{
+			"kind":     asset.Kind,
+			"name":     asset.Name,
+			"image_id": asset.ImageID,
+		})
+	}
+	data["assets"] = sheet
+	utils.SuccessResponse(c, data)
+}
+
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	comicID, err := strconv.ParseUint(c.Param("comic_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "invalid comic_id")
+		return
+	}
+
+	role, err := h.comicService.CreateRole(c.Request.Context(), uint(comicID), parseRoleInput(c))
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, roleResponse(role))
+}
+
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	comicID, roleID, ok := parseIDParams(c, "comic_id", "role_id")
+	if !ok {
+		return
+	}
+
+	role, err := h.comicService.UpdateRole(c.Request.Context(), comicID, roleID, parseRoleInput(c))
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, roleResponse(role))
+}
+
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	comicID, roleID, ok := parseIDParams(c, "comic_id", "role_id")
+	if !ok {
+		return
+	}
+
+	if err := h.comicService.DeleteRole(comicID, roleID); err != nil {
+		respondRoleError(c, err)
+		return
+	}
+	utils.SuccessResponse(c, gin.H{"id": strconv.FormatUint(uint64(roleID), 10)})
+}
+
+func (h *RoleHandler) UploadPortrait(c *gin.Context) {
+	comicID, roleID, ok := parseIDParams(c, "comic_id", "role_id")
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "image is required")
+		return
+	}
+	if file.Size > maxPortraitBytes {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "image is larger than 10MB")
+		return
+	}
+
+	fileContent, err := file.Open()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Internal Server Error", err.Error())
+		return
+	}
+	defer fileContent.Close()
+
+	imageData, err := io.ReadAll(fileContent)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Internal Server Error", err.Error())
+		return
+	}
+
+	role, revision, err := h.comicService.UploadRolePortrait(comicID, roleID, imageData)
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	data := roleResponse(role)
+	data["revision_id"] = strconv.FormatUint(uint64(revision.ID), 10)
+	utils.SuccessResponse(c, data)
+}
+
+// parseRoleInput 读取提交的角色字段，未提交的字段保持不变。
+func parseRoleInput(c *gin.Context) services.RoleInput {
+	field := func(key string) *string {
+		if value, ok := c.GetPostForm(key); ok {
+			return &value
+		}
+		return nil
+	}
+	return services.RoleInput{
+		Name:             field("name"),
+		Brief:            field("brief"),
+		Gender:           field("gender"),
+		Age:              field("age"),
+		VoiceType:        field("voice_type"),
+		ConceptArtPrompt: field("concept_art_prompt"),
+	}
+}
+
+func roleResponse(role *models.ComicRole) gin.H {
+	return gin.H{
+		"id":                 strconv.FormatUint(uint64(role.ID), 10),
+		"name":               role.Name,
+		"brief":              role.Brief,
+		"image_id":           role.ImageID,
+		"concept_art_prompt": role.ConceptArtPrompt,
+		"gender":             role.Gender,
+		"age":                role.Age,
+		"voice_name":         role.VoiceName,
+		"voice_type":         role.VoiceType,
+		"created_at":         role.CreatedAt,
+		"updated_at":         role.UpdatedAt,
+	}
+}
+
+func respondRoleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRole), errors.Is(err, services.ErrInvalidImage):
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", err.Error())
+	case errors.Is(err, services.ErrRoleNameTaken):
+		utils.ErrorResponse(c, http.StatusConflict, "Conflict", err.Error())
+	default:
+		respondRevisionError(c, err)
+	}
+}
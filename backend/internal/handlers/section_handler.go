@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/cohesion-dev/GNX/backend_new/internal/services"
 	"github.com/cohesion-dev/GNX/backend_new/internal/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type SectionHandler struct {
@@ -27,20 +29,68 @@ func (h *SectionHandler) CreateSection(c *gin.Context) {
 	title := c.PostForm("title")
 	content := c.PostForm("content")
 
+	// 未直接提交正文时读取上传的文件，按识别出的编码转为 UTF-8
+	var encoding string
+	if content == "" {
+		if file, err := c.FormFile("file"); err == nil {
+			fileContent, err := file.Open()
+			if err != nil {
+				utils.ErrorResponse(c, http.StatusInternalServerError, "Internal Server Error", err.Error())
+				return
+			}
+			defer fileContent.Close()
+
+			content, encoding, err = h.comicService.DecodeSectionText(fileContent)
+			if err != nil {
+				utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", err.Error())
+				return
+			}
+		}
+	}
+
 	if title == "" || content == "" {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "title and content are required")
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "title and content (or file) are required")
+		return
+	}
+
+	cleanupOpts, err := parseCleanupOptions(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", err.Error())
+		return
+	}
+	content, report, err := h.comicService.CleanSectionText(title, content, cleanupOpts)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", err.Error())
+		return
+	}
+	if content == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", "content is empty after cleanup")
 		return
 	}
 
-	section, err := h.comicService.CreateSection(c.Request.Context(), uint(comicID), title, content)
+	section, job, err := h.comicService.CreateSection(uint(comicID), title, c.PostForm("volume"), content)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Internal Server Error", err.Error())
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "Not Found", err.Error())
+		case errors.Is(err, services.ErrGenerationCancelled):
+			utils.ErrorResponse(c, http.StatusConflict, "Conflict", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Internal Server Error", err.Error())
+		}
 		return
 	}
 
-	utils.SuccessResponse(c, gin.H{
-		"id":    strconv.FormatUint(uint64(section.ID), 10),
-		"index": section.Index,
+	c.JSON(http.StatusAccepted, utils.Response{
+		Code:    http.StatusAccepted,
+		Message: "已加入生成队列",
+		Data: gin.H{
+			"id":       strconv.FormatUint(uint64(section.ID), 10),
+			"index":    section.Index,
+			"job_id":   strconv.FormatUint(uint64(job.ID), 10),
+			"encoding": encoding,
+			"cleanup":  report,
+		},
 	})
 }
 
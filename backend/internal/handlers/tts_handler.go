@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"unicode/utf8"
 
 	"github.com/cohesion-dev/GNX/backend_new/internal/services"
 	"github.com/cohesion-dev/GNX/backend_new/internal/utils"
@@ -34,3 +37,42 @@ func (h *TTSHandler) GetTTSAudio(c *gin.Context) {
 	c.Header("Content-Length", strconv.Itoa(len(audioData)))
 	c.Data(http.StatusOK, "audio/mpeg", audioData)
 }
+
+// AuditionRole 以候选音色与语速合成角色的试听语音，text 为空时使用角色的一句对白。
+func (h *TTSHandler) AuditionRole(c *gin.Context) {
+	comicID, roleID, ok := parseIDParams(c, "comic_id", "role_id")
+	if !ok {
+		return
+	}
+
+	opts := services.AuditionOptions{
+		VoiceType: c.Query("voice_type"),
+		Text:      c.Query("text"),
+	}
+	if raw := c.Query("speed_ratio"); raw != "" {
+		speedRatio, err := strconv.ParseFloat(raw, 64)
+		if err != nil || speedRatio < services.MinSpeedRatio || speedRatio > services.MaxSpeedRatio {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request",
+				fmt.Sprintf("speed_ratio must be between %.1f and %.1f", services.MinSpeedRatio, services.MaxSpeedRatio))
+			return
+		}
+		opts.SpeedRatio = speedRatio
+	}
+	if utf8.RuneCountInString(opts.Text) > services.MaxAuditionRunes {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", fmt.Sprintf("text is longer than %d characters", services.MaxAuditionRunes))
+		return
+	}
+
+	audioData, err := h.ttsService.AuditionRole(c.Request.Context(), comicID, roleID, opts)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRole) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Bad Request", err.Error())
+			return
+		}
+		respondRevisionError(c, err)
+		return
+	}
+
+	c.Header("Content-Length", strconv.Itoa(len(audioData)))
+	c.Data(http.StatusOK, "audio/mpeg", audioData)
+}
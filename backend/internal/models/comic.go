@@ -3,12 +3,21 @@ package models
 import "time"
 
 type Comic struct {
-	ID                uint      `gorm:"primarykey" json:"id"`
-	Title             string    `gorm:"not null" json:"title"`
-	UserPrompt        string    `gorm:"type:text" json:"user_prompt"`
-	IconImageID       string    `gorm:"" json:"icon_image_id"`
-	BackgroundImageID string    `gorm:"" json:"background_image_id"`
-	Status            string    `gorm:"default:'pending'" json:"status"`
+	ID                uint   `gorm:"primarykey" json:"id"`
+	Title             string `gorm:"not null" json:"title"`
+	Author            string `gorm:"" json:"author,omitempty"`
+	SourceEncoding    string `gorm:"" json:"source_encoding,omitempty"`
+	UserPrompt        string `gorm:"type:text" json:"user_prompt"`
+	IconImageID       string `gorm:"" json:"icon_image_id"`
+	BackgroundImageID string `gorm:"" json:"background_image_id"`
+	Status            string `gorm:"default:'pending'" json:"status"`
+	GenerationState   string `gorm:"default:'running'" json:"generation_state"`
+	// NarratorVoiceType 旁白使用的音色，为空时使用 TTS_NARRATOR_VOICE_TYPE
+	NarratorVoiceType string    `gorm:"" json:"narrator_voice_type,omitempty"`
+	NarratorVoiceName string    `gorm:"" json:"narrator_voice_name,omitempty"`
+	StorySynopsis     string    `gorm:"type:text" json:"-"`
+	StoryWorldState   string    `gorm:"type:text" json:"-"`
+	StoryMemoryIndex  int       `gorm:"default:0" json:"-"`
 	CreatedAt         time.Time `json:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at"`
 
@@ -16,6 +25,15 @@ type Comic struct {
 	Sections []ComicSection `gorm:"foreignKey:ComicID;orderBy:index" json:"sections,omitempty"`
 }
 
+const (
+	// GenerationStateRunning 生成任务正常执行
+	GenerationStateRunning = "running"
+	// GenerationStatePaused 生成任务已暂停，可以恢复
+	GenerationStatePaused = "paused"
+	// GenerationStateCancelled 生成任务已取消，不可恢复
+	GenerationStateCancelled = "cancelled"
+)
+
 func (Comic) TableName() string {
 	return "comics"
 }
@@ -23,9 +41,14 @@ func (Comic) TableName() string {
 type ComicDetailResponse struct {
 	ID                string         `json:"id"`
 	Title             string         `json:"title"`
+	Author            string         `json:"author,omitempty"`
+	SourceEncoding    string         `json:"source_encoding,omitempty"`
 	IconImageID       string         `json:"icon_image_id"`
 	BackgroundImageID string         `json:"background_image_id"`
 	Status            string         `json:"status"`
+	GenerationState   string         `json:"generation_state"`
+	NarratorVoiceType string         `json:"narrator_voice_type,omitempty"`
+	NarratorVoiceName string         `json:"narrator_voice_name,omitempty"`
 	Roles             []ComicRole    `json:"roles,omitempty"`
 	Sections          []ComicSection `json:"sections,omitempty"`
 	CreatedAt         time.Time      `json:"created_at"`
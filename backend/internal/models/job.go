@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+const (
+	// JobStageRoles 从开篇章节中提取角色
+	JobStageRoles = "roles"
+	// JobStageConceptArt 生成角色原画、封面与背景图
+	JobStageConceptArt = "concept_art"
+	// JobStageStoryboard 为单个章节生成分镜、页面与对白
+	JobStageStoryboard = "storyboard"
+	// JobStagePageImage 渲染章节内的页面图片，指定 PageID 时只重新生成该页
+	JobStagePageImage = "page_image"
+	// JobStageRolePortrait 重新生成单个角色的原画
+	JobStageRolePortrait = "role_portrait"
+	// JobStageTTS 预先合成对白语音
+	JobStageTTS = "tts"
+)
+
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+	// JobStatusPaused 漫画暂停生成期间的任务，恢复后重新进入待执行状态
+	JobStatusPaused = "paused"
+	// JobStatusCancelled 漫画取消生成后不再执行的任务
+	JobStatusCancelled = "cancelled"
+)
+
+// ComicJob 持久化的生成任务。Ordered 任务在同一漫画内按 ID 顺序串行执行，
+// 只有排在前面的 Ordered 任务全部结束后才能被领取；其余任务可以并行执行。
+// 任务被领取时写入租约，执行期间由心跳续期，进程退出后租约过期即可被重新领取。
+type ComicJob struct {
+	ID             uint       `gorm:"primarykey" json:"id"`
+	ComicID        uint       `gorm:"not null;index" json:"comic_id"`
+	SectionID      *uint      `gorm:"index" json:"section_id,omitempty"`
+	PageID         *uint      `gorm:"index" json:"page_id,omitempty"`
+	RoleID         *uint      `gorm:"index" json:"role_id,omitempty"`
+	Stage          string     `gorm:"not null;index" json:"stage"`
+	Ordered        bool       `gorm:"not null;default:false" json:"ordered"`
+	Status         string     `gorm:"not null;default:'pending';index" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts    int        `gorm:"not null;default:3" json:"max_attempts"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	LeaseOwner     string     `gorm:"" json:"-"`
+	LeaseExpiresAt *time.Time `gorm:"" json:"-"`
+	RunAfter       time.Time  `gorm:"not null;index" json:"run_after"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (ComicJob) TableName() string {
+	return "comic_jobs"
+}
@@ -3,17 +3,73 @@ package models
 import "time"
 
 type ComicPage struct {
-	ID          uint      `gorm:"primarykey" json:"id"`
-	SectionID   uint      `gorm:"not null;index" json:"section_id"`
-	Index       int       `gorm:"not null" json:"index"`
-	ImagePrompt string    `gorm:"type:text" json:"-"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID        uint `gorm:"primarykey" json:"id"`
+	SectionID uint `gorm:"not null;index" json:"section_id"`
+	Index     int  `gorm:"not null" json:"index"`
+	// RevisionID 非空表示该页面属于被重新生成替换下来的旧分镜，不再出现在章节中
+	RevisionID  *uint  `gorm:"index" json:"-"`
+	ImagePrompt string `gorm:"type:text" json:"-"`
+	// LayoutHint 分格排列方式，如 "2x2 grid"
+	LayoutHint  string      `gorm:"" json:"layout_hint,omitempty"`
+	PageSummary string      `gorm:"type:text" json:"page_summary,omitempty"`
+	PanelRects  []PanelRect `gorm:"serializer:json;type:text" json:"panel_rects,omitempty"`
+	// ImageStatus 页面图片的生成状态，取值见 PageImageStatus*
+	ImageStatus string `gorm:"default:'pending'" json:"image_status"`
+	// ImageID 页面图片的存储 key，每次生成使用新的 key；早期数据为空时图片以页面 ID 存储
+	ImageID string `gorm:"" json:"image_id,omitempty"`
+	// ImageError 最近一次生成失败的原因，成功后清空
+	ImageError    string `gorm:"type:text" json:"image_error,omitempty"`
+	ImageAttempts int    `gorm:"default:0" json:"image_attempts"`
+	// ImageModel 生成当前图片所用的图像模型
+	ImageModel string `gorm:"" json:"image_model,omitempty"`
+	// LetteredImageID 带对白气泡与旁白框的图层，原始画面保持不变
+	LetteredImageID string `gorm:"" json:"lettered_image_id,omitempty"`
+	// QAReport 视觉模型质检结果，未开启质检时为空
+	QAReport  *PageQAReport `gorm:"serializer:json;type:text" json:"qa_report,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
 
 	Section ComicSection      `gorm:"foreignKey:SectionID" json:"-"`
+	Panels  []ComicPanel      `gorm:"foreignKey:PageID" json:"panels,omitempty"`
 	Details []ComicPageDetail `gorm:"foreignKey:PageID;orderBy:index" json:"details,omitempty"`
 }
 
+const (
+	PageImageStatusPending    = "pending"
+	PageImageStatusGenerating = "generating"
+	PageImageStatusCompleted  = "completed"
+	PageImageStatusFailed     = "failed"
+)
+
+// PanelRect 记录分格在整页图片中的像素区域，按阅读顺序排列
+type PanelRect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// PageQAReport 记录最终采用的页面图片的质检得分，各项得分范围为 0~1
+type PageQAReport struct {
+	Passed bool `json:"passed"`
+	// Attempts 包含首次生成在内的生成次数
+	Attempts       int               `json:"attempts"`
+	Score          float64           `json:"score"`
+	TextScore      float64           `json:"text_score"`
+	PanelScore     float64           `json:"panel_score"`
+	CharacterScore float64           `json:"character_score"`
+	ExpectedPanels int               `json:"expected_panels"`
+	DetectedPanels int               `json:"detected_panels"`
+	Characters     []PageQACharacter `json:"characters,omitempty"`
+	Issues         []string          `json:"issues,omitempty"`
+}
+
+type PageQACharacter struct {
+	Name    string  `json:"name"`
+	Score   float64 `json:"score"`
+	Comment string  `json:"comment,omitempty"`
+}
+
 func (ComicPage) TableName() string {
 	return "comic_pages"
 }
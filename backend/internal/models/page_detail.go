@@ -3,13 +3,26 @@ package models
 import "time"
 
 type ComicPageDetail struct {
-	ID        uint      `gorm:"primarykey" json:"id"`
-	PageID    uint      `gorm:"not null;index" json:"page_id"`
-	Index     int       `gorm:"not null" json:"index"`
-	Content   string    `gorm:"type:text;not null" json:"content"`
-	RoleID    *uint     `gorm:"index" json:"role_id,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID      uint   `gorm:"primarykey" json:"id"`
+	PageID  uint   `gorm:"not null;index" json:"page_id"`
+	Index   int    `gorm:"not null" json:"index"`
+	Content string `gorm:"type:text;not null" json:"content"`
+	RoleID  *uint  `gorm:"index" json:"role_id,omitempty"`
+	// PanelID 对白所属的分格，旧数据为空时按 Index/100 归入分格
+	PanelID *uint `gorm:"index" json:"panel_id,omitempty"`
+	// 以下为分镜为该片段选择的配音与表情
+	VoiceName      string   `gorm:"" json:"voice_name,omitempty"`
+	VoiceType      string   `gorm:"" json:"voice_type,omitempty"`
+	SpeedRatio     float64  `gorm:"default:1" json:"speed_ratio,omitempty"`
+	IsNarration    bool     `gorm:"default:false" json:"is_narration"`
+	CharacterNames []string `gorm:"serializer:json;type:text" json:"character_names,omitempty"`
+	Emotion        string   `gorm:"" json:"emotion,omitempty"`
+	// AudioKey 预先合成的语音的存储 key，与合成参数的缓存 key 相同，音色或语速变化后不再匹配
+	AudioKey string `gorm:"" json:"audio_key,omitempty"`
+	// AudioDurationMs 预先合成的语音时长（毫秒）
+	AudioDurationMs int       `gorm:"default:0" json:"audio_duration_ms,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 
 	Page ComicPage  `gorm:"foreignKey:PageID" json:"-"`
 	Role *ComicRole `gorm:"foreignKey:RoleID" json:"-"`
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// ComicPanel 分镜中的单个分格，页面图片按分格记录渲染，对白通过 ComicPageDetail.PanelID 归属到分格
+type ComicPanel struct {
+	ID           uint   `gorm:"primarykey" json:"id"`
+	PageID       uint   `gorm:"not null;index" json:"page_id"`
+	Index        int    `gorm:"not null" json:"index"`
+	PanelSummary string `gorm:"type:text" json:"panel_summary,omitempty"`
+	// VisualPrompt 英文的分格画面描述，仅用于图像生成
+	VisualPrompt string    `gorm:"type:text" json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	Page ComicPage `gorm:"foreignKey:PageID" json:"-"`
+}
+
+func (ComicPanel) TableName() string {
+	return "comic_panels"
+}
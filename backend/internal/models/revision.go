@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+const (
+	// RevisionTargetPageImage 页面图片
+	RevisionTargetPageImage = "page_image"
+	// RevisionTargetRolePortrait 角色原画
+	RevisionTargetRolePortrait = "role_portrait"
+	// RevisionTargetSectionStoryboard 章节分镜（页面与对白）
+	RevisionTargetSectionStoryboard = "section_storyboard"
+)
+
+// AssetRevision 重新生成前保存的旧版本，用于回退。
+// 页面图片与角色原画每次生成都使用新的存储 key，修订记录直接引用旧的 ImageID 与 LetteredImageID；
+// 章节分镜的旧页面保留在原表中，以 ComicPage.RevisionID 指向该记录。
+type AssetRevision struct {
+	ID         uint   `gorm:"primarykey" json:"id"`
+	ComicID    uint   `gorm:"not null;index" json:"comic_id"`
+	TargetType string `gorm:"not null;index:idx_asset_revisions_target" json:"target_type"`
+	TargetID   uint   `gorm:"not null;index:idx_asset_revisions_target" json:"target_id"`
+	// Prompt 旧版本使用的提示词；章节分镜为旧版本的额外要求
+	Prompt          string      `gorm:"type:text" json:"prompt,omitempty"`
+	ImageID         string      `gorm:"" json:"image_id,omitempty"`
+	LetteredImageID string      `gorm:"" json:"lettered_image_id,omitempty"`
+	PanelRects      []PanelRect `gorm:"serializer:json;type:text" json:"panel_rects,omitempty"`
+	CreatedAt       time.Time   `json:"created_at"`
+}
+
+func (AssetRevision) TableName() string {
+	return "asset_revisions"
+}
@@ -3,17 +3,19 @@ package models
 import "time"
 
 type ComicRole struct {
-	ID        uint      `gorm:"primarykey" json:"id"`
-	ComicID   uint      `gorm:"not null;index" json:"comic_id"`
-	Name      string    `gorm:"not null" json:"name"`
-	Brief     string    `gorm:"type:text" json:"brief"`
-	ImageID   string    `gorm:"" json:"image_id"`
-	Gender    string    `gorm:"" json:"gender"`
-	Age       string    `gorm:"" json:"age"`
-	VoiceName string    `gorm:"" json:"voice_name"`
-	VoiceType string    `gorm:"" json:"voice_type"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID      uint   `gorm:"primarykey" json:"id"`
+	ComicID uint   `gorm:"not null;index" json:"comic_id"`
+	Name    string `gorm:"not null" json:"name"`
+	Brief   string `gorm:"type:text" json:"brief"`
+	ImageID string `gorm:"" json:"image_id"`
+	// ConceptArtPrompt 重新生成原画时使用的提示词，为空时按角色简介生成
+	ConceptArtPrompt string    `gorm:"type:text" json:"concept_art_prompt,omitempty"`
+	Gender           string    `gorm:"" json:"gender"`
+	Age              string    `gorm:"" json:"age"`
+	VoiceName        string    `gorm:"" json:"voice_name"`
+	VoiceType        string    `gorm:"" json:"voice_type"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
 
 	Comic Comic `gorm:"foreignKey:ComicID" json:"-"`
 }
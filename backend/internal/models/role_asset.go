@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+const (
+	// RoleAssetKindView 角色转面视图（正面、侧面、背面）
+	RoleAssetKindView = "view"
+	// RoleAssetKindExpression 角色表情图
+	RoleAssetKindExpression = "expression"
+)
+
+// ComicRoleAsset 角色设定表中的单张图片，Name 为视图或表情名称
+type ComicRoleAsset struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	RoleID    uint      `gorm:"not null;index" json:"role_id"`
+	Kind      string    `gorm:"not null" json:"kind"`
+	Name      string    `gorm:"not null" json:"name"`
+	ImageID   string    `gorm:"" json:"image_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Role ComicRole `gorm:"foreignKey:RoleID" json:"-"`
+}
+
+func (ComicRoleAsset) TableName() string {
+	return "comic_role_assets"
+}
@@ -3,17 +3,43 @@ package models
 import "time"
 
 type ComicSection struct {
-	ID        uint      `gorm:"primarykey" json:"id"`
-	ComicID   uint      `gorm:"not null;index" json:"comic_id"`
-	Title     string    `gorm:"" json:"title"`
-	Index     int       `gorm:"not null" json:"index"`
-	Content   string    `gorm:"type:text;not null" json:"-"`
-	Status    string    `gorm:"default:'pending'" json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID      uint   `gorm:"primarykey" json:"id"`
+	ComicID uint   `gorm:"not null;index" json:"comic_id"`
+	Title   string `gorm:"" json:"title"`
+	Volume  string `gorm:"" json:"volume,omitempty"`
+	Index   int    `gorm:"not null" json:"index"`
+	Content string `gorm:"type:text;not null" json:"-"`
+	Status  string `gorm:"default:'pending'" json:"status"`
+	// AudioStatus 章节对白语音的预合成状态，取值见 SectionAudioStatus*，早期数据为空
+	AudioStatus string `gorm:"default:''" json:"audio_status,omitempty"`
+	// SourceTitles 重新分段后本章对应的原始章节标题
+	SourceTitles []string `gorm:"serializer:json;type:text" json:"source_titles,omitempty"`
+	// StoryboardInstruction 用户对本章分镜的额外要求，重新生成分镜时写入
+	StoryboardInstruction string `gorm:"type:text" json:"storyboard_instruction,omitempty"`
+	// StorySynopsis 与 StoryWorldState 为读完本章后的剧情记忆快照，生成下一章分镜时注入
+	StorySynopsis   string    `gorm:"type:text" json:"-"`
+	StoryWorldState string    `gorm:"type:text" json:"-"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 
 	Comic Comic       `gorm:"foreignKey:ComicID" json:"-"`
 	Pages []ComicPage `gorm:"foreignKey:SectionID;orderBy:index" json:"pages,omitempty"`
+	// Jobs 章节的分镜与页面图片任务，仅在章节详情中返回
+	Jobs []ComicJob `gorm:"-" json:"jobs,omitempty"`
+	// Ready 分镜已完成且对白语音全部合成完毕，仅在漫画与章节详情中返回
+	Ready bool `gorm:"-" json:"ready"`
+}
+
+const (
+	SectionAudioStatusPending    = "pending"
+	SectionAudioStatusGenerating = "generating"
+	SectionAudioStatusCompleted  = "completed"
+	SectionAudioStatusFailed     = "failed"
+)
+
+// IsReady 章节的分镜与对白语音均已完成，可以完整阅读与播放。
+func (s *ComicSection) IsReady() bool {
+	return s.Status == "completed" && s.AudioStatus == SectionAudioStatusCompleted
 }
 
 func (ComicSection) TableName() string {
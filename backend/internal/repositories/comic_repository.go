@@ -44,3 +44,34 @@ func (r *ComicRepository) List(page, limit int, status string) ([]models.Comic,
 func (r *ComicRepository) Update(comic *models.Comic) error {
 	return r.db.Save(comic).Error
 }
+
+func (r *ComicRepository) UpdateStoryMemory(id uint, synopsis, worldState string, sectionIndex int) error {
+	return r.db.Model(&models.Comic{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"story_synopsis":     synopsis,
+		"story_world_state":  worldState,
+		"story_memory_index": sectionIndex,
+	}).Error
+}
+
+func (r *ComicRepository) UpdateStatus(id uint, status string) error {
+	return r.db.Model(&models.Comic{}).Where("id = ?", id).Update("status", status).Error
+}
+
+func (r *ComicRepository) UpdateIconImageID(id uint, imageID string) error {
+	return r.db.Model(&models.Comic{}).Where("id = ?", id).Update("icon_image_id", imageID).Error
+}
+
+func (r *ComicRepository) UpdateBackgroundImageID(id uint, imageID string) error {
+	return r.db.Model(&models.Comic{}).Where("id = ?", id).Update("background_image_id", imageID).Error
+}
+
+func (r *ComicRepository) UpdateGenerationState(id uint, state string) error {
+	return r.db.Model(&models.Comic{}).Where("id = ?", id).Update("generation_state", state).Error
+}
+
+func (r *ComicRepository) UpdateNarratorVoice(id uint, voiceName, voiceType string) error {
+	return r.db.Model(&models.Comic{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"narrator_voice_name": voiceName,
+		"narrator_voice_type": voiceType,
+	}).Error
+}
@@ -0,0 +1,257 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"gorm.io/gorm"
+)
+
+type JobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// CreateUnlessActive 写入任务，若同一漫画、章节、页面与角色已存在同阶段的未结束（含暂停）任务则直接返回该任务。
+func (r *JobRepository) CreateUnlessActive(job *models.ComicJob) (*models.ComicJob, error) {
+	return r.createUnless(job, []string{models.JobStatusPending, models.JobStatusRunning, models.JobStatusPaused})
+}
+
+// CreateUnlessQueued 与 CreateUnlessActive 相同，但只与尚未开始的（待执行或暂停的）任务去重。
+// 用于输入数据变化后需要重新执行的任务：执行中的任务读到的可能是旧数据，必须再排一次。
+func (r *JobRepository) CreateUnlessQueued(job *models.ComicJob) (*models.ComicJob, error) {
+	return r.createUnless(job, []string{models.JobStatusPending, models.JobStatusPaused})
+}
+
+// FindActive 返回与 job 同一漫画、章节、页面与角色的同阶段未结束（含暂停）任务，没有时返回 nil。
+func (r *JobRepository) FindActive(job *models.ComicJob) (*models.ComicJob, error) {
+	var existing models.ComicJob
+	err := r.sameTarget(job, []string{models.JobStatusPending, models.JobStatusRunning, models.JobStatusPaused}).
+		Order("id").First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func (r *JobRepository) createUnless(job *models.ComicJob, statuses []string) (*models.ComicJob, error) {
+	var existing models.ComicJob
+	err := r.sameTarget(job, statuses).Order("id").First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err := r.db.Create(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// sameTarget 查询与 job 同一漫画、章节、页面与角色，且处于 statuses 状态的同阶段任务。
+func (r *JobRepository) sameTarget(job *models.ComicJob, statuses []string) *gorm.DB {
+	query := r.db.Where("comic_id = ? AND stage = ? AND status IN ?", job.ComicID, job.Stage, statuses)
+	if job.SectionID != nil {
+		query = query.Where("section_id = ?", *job.SectionID)
+	} else {
+		query = query.Where("section_id IS NULL")
+	}
+	if job.PageID != nil {
+		query = query.Where("page_id = ?", *job.PageID)
+	} else {
+		query = query.Where("page_id IS NULL")
+	}
+	if job.RoleID != nil {
+		query = query.Where("role_id = ?", *job.RoleID)
+	} else {
+		query = query.Where("role_id IS NULL")
+	}
+	return query
+}
+
+func (r *JobRepository) FindByID(id uint) (*models.ComicJob, error) {
+	var job models.ComicJob
+	err := r.db.First(&job, id).Error
+	return &job, err
+}
+
+func (r *JobRepository) FindByComicID(comicID uint) ([]models.ComicJob, error) {
+	var jobs []models.ComicJob
+	err := r.db.Where("comic_id = ?", comicID).Order("id").Find(&jobs).Error
+	return jobs, err
+}
+
+// FindBySectionID 查询章节的全部任务，按写入顺序排列。
+func (r *JobRepository) FindBySectionID(sectionID uint) ([]models.ComicJob, error) {
+	var jobs []models.ComicJob
+	err := r.db.Where("section_id = ?", sectionID).Order("id").Find(&jobs).Error
+	return jobs, err
+}
+
+// Claim 以 FOR UPDATE SKIP LOCKED 领取一个可执行的任务并写入租约，没有可执行任务时返回 nil。
+// 可执行任务包括到期的待执行任务与租约已过期的执行中任务；Ordered 任务还要求同一漫画中
+// ID 更小的 Ordered 任务都已结束（暂停的任务视为未结束）。
+func (r *JobRepository) Claim(stages []string, owner string, lease time.Duration) (*models.ComicJob, error) {
+	now := time.Now()
+	var jobs []models.ComicJob
+	err := r.db.Raw(`
+UPDATE comic_jobs SET
+	status = ?,
+	lease_owner = ?,
+	lease_expires_at = ?,
+	attempts = attempts + 1,
+	updated_at = ?
+WHERE id = (
+	SELECT j.id FROM comic_jobs j
+	WHERE j.stage IN ?
+		AND j.run_after <= ?
+		AND (j.status = ? OR (j.status = ? AND j.lease_expires_at < ?))
+		AND (NOT j.ordered OR NOT EXISTS (
+			SELECT 1 FROM comic_jobs p
+			WHERE p.comic_id = j.comic_id AND p.ordered AND p.id < j.id AND p.status IN ?
+		))
+	ORDER BY j.id
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING *`,
+		models.JobStatusRunning, owner, now.Add(lease), now,
+		stages,
+		now,
+		models.JobStatusPending, models.JobStatusRunning, now,
+		[]string{models.JobStatusPending, models.JobStatusRunning, models.JobStatusPaused},
+	).Scan(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return &jobs[0], nil
+}
+
+// ExtendLease 为仍由 owner 持有的任务续期租约，返回 false 表示租约已经丢失。
+func (r *JobRepository) ExtendLease(id uint, owner string, lease time.Duration) (bool, error) {
+	result := r.db.Model(&models.ComicJob{}).
+		Where("id = ? AND lease_owner = ? AND status = ?", id, owner, models.JobStatusRunning).
+		Update("lease_expires_at", time.Now().Add(lease))
+	return result.RowsAffected > 0, result.Error
+}
+
+// Complete 将仍由 owner 持有的任务标记为已完成。
+func (r *JobRepository) Complete(id uint, owner string) error {
+	return r.db.Model(&models.ComicJob{}).
+		Where("id = ? AND lease_owner = ?", id, owner).
+		Updates(map[string]interface{}{
+			"status":           models.JobStatusCompleted,
+			"lease_owner":      "",
+			"lease_expires_at": nil,
+			"last_error":       "",
+		}).Error
+}
+
+// Retry 释放租约并在 runAfter 之后重新执行任务。
+func (r *JobRepository) Retry(id uint, owner string, lastError string, runAfter time.Time) error {
+	return r.db.Model(&models.ComicJob{}).
+		Where("id = ? AND lease_owner = ?", id, owner).
+		Updates(map[string]interface{}{
+			"status":           models.JobStatusPending,
+			"lease_owner":      "",
+			"lease_expires_at": nil,
+			"last_error":       lastError,
+			"run_after":        runAfter,
+		}).Error
+}
+
+// Fail 将任务标记为最终失败，不再重试。
+func (r *JobRepository) Fail(id uint, owner string, lastError string) error {
+	return r.db.Model(&models.ComicJob{}).
+		Where("id = ? AND lease_owner = ?", id, owner).
+		Updates(map[string]interface{}{
+			"status":           models.JobStatusFailed,
+			"lease_owner":      "",
+			"lease_expires_at": nil,
+			"last_error":       lastError,
+		}).Error
+}
+
+// Release 交还仍由 owner 持有的任务，任务立即回到待执行状态，供进程退出时让出被中断的任务，
+// 而不必等待租约过期。被中断的执行不计入重试次数。
+func (r *JobRepository) Release(id uint, owner string) error {
+	return r.db.Model(&models.ComicJob{}).
+		Where("id = ? AND lease_owner = ? AND status = ?", id, owner, models.JobStatusRunning).
+		Updates(map[string]interface{}{
+			"status":           models.JobStatusPending,
+			"lease_owner":      "",
+			"lease_expires_at": nil,
+			"attempts":         gorm.Expr("GREATEST(attempts - 1, 0)"),
+			"run_after":        time.Now(),
+		}).Error
+}
+
+// CountByStatus 统计漫画各状态的任务数量。
+func (r *JobRepository) CountByStatus(comicID uint) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.Model(&models.ComicJob{}).
+		Select("status, COUNT(*) AS count").
+		Where("comic_id = ?", comicID).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// PauseByComicID 暂停漫画所有未结束的任务并释放租约，被中断的执行不计入重试次数。
+func (r *JobRepository) PauseByComicID(comicID uint) (int64, error) {
+	result := r.db.Model(&models.ComicJob{}).
+		Where("comic_id = ? AND status IN ?", comicID, []string{models.JobStatusPending, models.JobStatusRunning}).
+		Updates(map[string]interface{}{
+			"attempts":         gorm.Expr("CASE WHEN status = ? THEN GREATEST(attempts - 1, 0) ELSE attempts END", models.JobStatusRunning),
+			"status":           models.JobStatusPaused,
+			"lease_owner":      "",
+			"lease_expires_at": nil,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// ResumeByComicID 将漫画暂停的任务恢复为待执行。
+func (r *JobRepository) ResumeByComicID(comicID uint) (int64, error) {
+	result := r.db.Model(&models.ComicJob{}).
+		Where("comic_id = ? AND status = ?", comicID, models.JobStatusPaused).
+		Updates(map[string]interface{}{
+			"status":    models.JobStatusPending,
+			"run_after": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// CancelByComicID 取消漫画所有未结束的任务并释放租约。
+func (r *JobRepository) CancelByComicID(comicID uint) (int64, error) {
+	result := r.db.Model(&models.ComicJob{}).
+		Where("comic_id = ? AND status IN ?", comicID,
+			[]string{models.JobStatusPending, models.JobStatusRunning, models.JobStatusPaused}).
+		Updates(map[string]interface{}{
+			"status":           models.JobStatusCancelled,
+			"lease_owner":      "",
+			"lease_expires_at": nil,
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,249 @@
+package repositories
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 任务领取依赖 Postgres 的 FOR UPDATE SKIP LOCKED，需要真实数据库，
+// 设置 GNX_TEST_DATABASE_DSN 后运行，例如：
+//
+//	GNX_TEST_DATABASE_DSN="host=localhost user=postgres password=postgres dbname=gnx_test sslmode=disable" go test ./internal/repositories/
+func newTestJobRepository(t *testing.T) (*JobRepository, *gorm.DB, string, uint) {
+	t.Helper()
+	dsn := os.Getenv("GNX_TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("GNX_TEST_DATABASE_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.ComicJob{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 每个测试使用独立的阶段名与漫画 ID，领取与 Ordered 判断只会看到本测试写入的任务
+	now := time.Now().UnixNano()
+	stage := fmt.Sprintf("test_%d", now)
+	comicBase := uint(now%1_000_000_000) * 10
+	t.Cleanup(func() {
+		db.Where("stage LIKE ?", stage+"%").Delete(&models.ComicJob{})
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return NewJobRepository(db), db, stage, comicBase
+}
+
+func createTestJob(t *testing.T, db *gorm.DB, job *models.ComicJob) *models.ComicJob {
+	t.Helper()
+	if job.Status == "" {
+		job.Status = models.JobStatusPending
+	}
+	if job.MaxAttempts == 0 {
+		job.MaxAttempts = 3
+	}
+	if job.RunAfter.IsZero() {
+		job.RunAfter = time.Now().Add(-time.Second)
+	}
+	if err := db.Create(job).Error; err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func mustClaim(t *testing.T, repo *JobRepository, stages []string, owner string, lease time.Duration) *models.ComicJob {
+	t.Helper()
+	job, err := repo.Claim(stages, owner, lease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func claimedID(job *models.ComicJob) uint {
+	if job == nil {
+		return 0
+	}
+	return job.ID
+}
+
+func TestClaimOrderedGate(t *testing.T) {
+	repo, db, stage, comic := newTestJobRepository(t)
+	stages := []string{stage}
+
+	first := createTestJob(t, db, &models.ComicJob{ComicID: comic + 1, Stage: stage, Ordered: true})
+	second := createTestJob(t, db, &models.ComicJob{ComicID: comic + 1, Stage: stage, Ordered: true})
+	parallel := createTestJob(t, db, &models.ComicJob{ComicID: comic + 1, Stage: stage})
+	otherComic := createTestJob(t, db, &models.ComicJob{ComicID: comic + 2, Stage: stage, Ordered: true})
+
+	job := mustClaim(t, repo, stages, "a", time.Minute)
+	if claimedID(job) != first.ID || job.Status != models.JobStatusRunning || job.Attempts != 1 || job.LeaseOwner != "a" {
+		t.Fatalf("first claim = %+v, want job %d running with one attempt", job, first.ID)
+	}
+	// 第二个 Ordered 任务要等第一个结束，非 Ordered 任务与其他漫画的任务不受影响
+	if got := claimedID(mustClaim(t, repo, stages, "a", time.Minute)); got != parallel.ID {
+		t.Fatalf("second claim = %d, want unordered job %d", got, parallel.ID)
+	}
+	if got := claimedID(mustClaim(t, repo, stages, "a", time.Minute)); got != otherComic.ID {
+		t.Fatalf("third claim = %d, want other comic's job %d", got, otherComic.ID)
+	}
+	if got := claimedID(mustClaim(t, repo, stages, "a", time.Minute)); got != 0 {
+		t.Fatalf("claim while ordered predecessor runs = %d, want none", got)
+	}
+
+	if err := repo.Complete(first.ID, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if got := claimedID(mustClaim(t, repo, stages, "a", time.Minute)); got != second.ID {
+		t.Fatalf("claim after predecessor completed = %d, want %d", got, second.ID)
+	}
+}
+
+func TestClaimPausedPredecessorBlocksOrderedJobs(t *testing.T) {
+	repo, db, stage, comic := newTestJobRepository(t)
+
+	createTestJob(t, db, &models.ComicJob{ComicID: comic + 1, Stage: stage, Ordered: true, Status: models.JobStatusPaused})
+	createTestJob(t, db, &models.ComicJob{ComicID: comic + 1, Stage: stage, Ordered: true})
+
+	if got := claimedID(mustClaim(t, repo, []string{stage}, "a", time.Minute)); got != 0 {
+		t.Fatalf("claim behind a paused ordered job = %d, want none", got)
+	}
+}
+
+func TestClaimStageFilterAndRunAfter(t *testing.T) {
+	repo, db, stage, comic := newTestJobRepository(t)
+	other := stage + "_other"
+
+	createTestJob(t, db, &models.ComicJob{ComicID: comic + 1, Stage: other})
+	createTestJob(t, db, &models.ComicJob{ComicID: comic + 1, Stage: stage, RunAfter: time.Now().Add(time.Hour)})
+
+	if got := claimedID(mustClaim(t, repo, []string{stage}, "a", time.Minute)); got != 0 {
+		t.Fatalf("claim = %d, want none: other stage filtered out and run_after in the future", got)
+	}
+	if job := mustClaim(t, repo, []string{stage, other}, "a", time.Minute); job == nil || job.Stage != other {
+		t.Fatalf("claim with both stages = %+v, want the %s job", job, other)
+	}
+}
+
+func TestClaimTakesOverExpiredLease(t *testing.T) {
+	repo, db, stage, comic := newTestJobRepository(t)
+	stages := []string{stage}
+	created := createTestJob(t, db, &models.ComicJob{ComicID: comic + 1, Stage: stage})
+
+	if got := claimedID(mustClaim(t, repo, stages, "a", -time.Second)); got != created.ID {
+		t.Fatalf("claim = %d, want %d", got, created.ID)
+	}
+	job := mustClaim(t, repo, stages, "b", time.Minute)
+	if claimedID(job) != created.ID || job.LeaseOwner != "b" || job.Attempts != 2 {
+		t.Fatalf("takeover = %+v, want job %d owned by b on attempt 2", job, created.ID)
+	}
+	if got := claimedID(mustClaim(t, repo, stages, "c", time.Minute)); got != 0 {
+		t.Fatalf("claim of a live lease = %d, want none", got)
+	}
+
+	// 原持有者的续期与状态更新都不再生效
+	held, err := repo.ExtendLease(created.ID, "a", time.Minute)
+	if err != nil || held {
+		t.Fatalf("ExtendLease by previous owner = %v, %v; want false", held, err)
+	}
+	if err := repo.Complete(created.ID, "a"); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := repo.FindByID(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.JobStatusRunning || stored.LeaseOwner != "b" {
+		t.Fatalf("job after stale Complete = %s owned by %q, want running owned by b", stored.Status, stored.LeaseOwner)
+	}
+}
+
+func TestClaimSkipsLockedRows(t *testing.T) {
+	repo, db, stage, comic := newTestJobRepository(t)
+	locked := createTestJob(t, db, &models.ComicJob{ComicID: comic + 1, Stage: stage})
+	free := createTestJob(t, db, &models.ComicJob{ComicID: comic + 2, Stage: stage})
+
+	tx := db.Begin()
+	defer tx.Rollback()
+	if err := tx.Exec("SELECT id FROM comic_jobs WHERE id = ? FOR UPDATE", locked.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan *models.ComicJob, 1)
+	go func() {
+		job, err := repo.Claim([]string{stage}, "a", time.Minute)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- job
+	}()
+	select {
+	case job := <-done:
+		if got := claimedID(job); got != free.ID {
+			t.Fatalf("claim = %d, want unlocked job %d", got, free.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Claim blocked on a locked row")
+	}
+}
+
+func TestRetryAndReleaseTransitions(t *testing.T) {
+	repo, db, stage, comic := newTestJobRepository(t)
+	stages := []string{stage}
+	created := createTestJob(t, db, &models.ComicJob{ComicID: comic + 1, Stage: stage})
+
+	mustClaim(t, repo, stages, "a", time.Minute)
+	if err := repo.Retry(created.ID, "a", "boom", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := repo.FindByID(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.JobStatusPending || stored.LeaseOwner != "" || stored.LastError != "boom" || stored.Attempts != 1 {
+		t.Fatalf("job after Retry = %+v, want pending without lease, last error kept, one attempt", stored)
+	}
+	if got := claimedID(mustClaim(t, repo, stages, "a", time.Minute)); got != 0 {
+		t.Fatalf("claim before run_after = %d, want none", got)
+	}
+
+	if err := db.Model(&models.ComicJob{}).Where("id = ?", created.ID).Update("run_after", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if job := mustClaim(t, repo, stages, "a", time.Minute); claimedID(job) != created.ID || job.Attempts != 2 {
+		t.Fatalf("claim after run_after = %+v, want attempt 2", job)
+	}
+
+	// 进程退出时交还的任务立即可被领取，且不计入执行次数
+	if err := repo.Release(created.ID, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := repo.FindByID(created.ID); stored.LeaseOwner != "a" {
+		t.Fatalf("Release by another owner changed the lease to %q", stored.LeaseOwner)
+	}
+	if err := repo.Release(created.ID, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if job := mustClaim(t, repo, stages, "b", time.Minute); claimedID(job) != created.ID || job.Attempts != 2 {
+		t.Fatalf("claim after Release = %+v, want attempt 2 again", job)
+	}
+
+	if err := repo.Fail(created.ID, "b", "fatal"); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := repo.FindByID(created.ID); stored.Status != models.JobStatusFailed || stored.LastError != "fatal" {
+		t.Fatalf("job after Fail = %s (%q), want failed", stored.Status, stored.LastError)
+	}
+	if got := claimedID(mustClaim(t, repo, stages, "b", time.Minute)); got != 0 {
+		t.Fatalf("claim of a failed job = %d, want none", got)
+	}
+}
@@ -19,16 +19,20 @@ func (r *PageRepository) Create(page *models.ComicPage) error {
 
 func (r *PageRepository) FindByID(id uint) (*models.ComicPage, error) {
 	var page models.ComicPage
-	err := r.db.Preload("Details").First(&page, id).Error
+	err := r.db.Preload("Panels").Preload("Details").First(&page, id).Error
 	return &page, err
 }
 
 func (r *PageRepository) FindBySectionID(sectionID uint) ([]models.ComicPage, error) {
 	var pages []models.ComicPage
-	err := r.db.Where("section_id = ?", sectionID).Order("index ASC").Preload("Details").Find(&pages).Error
+	err := r.db.Where("section_id = ? AND revision_id IS NULL", sectionID).Order("index ASC").Preload("Panels").Preload("Details").Find(&pages).Error
 	return pages, err
 }
 
+func (r *PageRepository) CreatePanel(panel *models.ComicPanel) error {
+	return r.db.Create(panel).Error
+}
+
 func (r *PageRepository) CreateDetail(detail *models.ComicPageDetail) error {
 	return r.db.Create(detail).Error
 }
@@ -39,6 +43,156 @@ func (r *PageRepository) FindDetailByID(id uint) (*models.ComicPageDetail, error
 	return &detail, err
 }
 
+// UpdateDetailAudio 记录对白预先合成的语音存储 key 与时长。
+func (r *PageRepository) UpdateDetailAudio(detailID uint, audioKey string, durationMs int) error {
+	return r.db.Model(&models.ComicPageDetail{}).Where("id = ?", detailID).Updates(map[string]interface{}{
+		"audio_key":         audioKey,
+		"audio_duration_ms": durationMs,
+	}).Error
+}
+
 func (r *PageRepository) Update(page *models.ComicPage) error {
 	return r.db.Save(page).Error
 }
+
+func (r *PageRepository) UpdatePanelRects(pageID uint, rects []models.PanelRect) error {
+	return r.db.Model(&models.ComicPage{ID: pageID}).Select("PanelRects").Updates(&models.ComicPage{PanelRects: rects}).Error
+}
+
+func (r *PageRepository) UpdateLetteredImageID(pageID uint, imageID string) error {
+	return r.db.Model(&models.ComicPage{}).Where("id = ?", pageID).Update("lettered_image_id", imageID).Error
+}
+
+func (r *PageRepository) UpdateQAReport(pageID uint, report *models.PageQAReport) error {
+	return r.db.Model(&models.ComicPage{ID: pageID}).Select("QAReport").Updates(&models.ComicPage{QAReport: report}).Error
+}
+
+// MarkImageGenerating 标记页面图片开始生成并累加生成次数，已有图片在新图片完成前保持可用。
+func (r *PageRepository) MarkImageGenerating(pageID uint) error {
+	return r.db.Model(&models.ComicPage{ID: pageID}).Updates(map[string]interface{}{
+		"image_status":   models.PageImageStatusGenerating,
+		"image_attempts": gorm.Expr("image_attempts + 1"),
+	}).Error
+}
+
+// MarkImageCompleted 记录新生成的页面图片，嵌字图层随之失效，由调用方重新生成。
+func (r *PageRepository) MarkImageCompleted(pageID uint, imageID, model string) error {
+	return r.db.Model(&models.ComicPage{ID: pageID}).Updates(map[string]interface{}{
+		"image_status":      models.PageImageStatusCompleted,
+		"image_id":          imageID,
+		"image_model":       model,
+		"image_error":       "",
+		"lettered_image_id": "",
+	}).Error
+}
+
+// MarkImageFailed 记录页面图片生成失败的原因。页面已有图片时保留图片。
+func (r *PageRepository) MarkImageFailed(pageID uint, lastError string) error {
+	return r.db.Model(&models.ComicPage{ID: pageID}).Updates(map[string]interface{}{
+		"image_status": gorm.Expr("CASE WHEN image_id <> '' THEN ? ELSE ? END", models.PageImageStatusCompleted, models.PageImageStatusFailed),
+		"image_error":  lastError,
+	}).Error
+}
+
+// ResetImageStatus 页面图片生成被中断时恢复为待生成，已有图片时保持完成，不记录错误。
+func (r *PageRepository) ResetImageStatus(pageID uint) error {
+	return r.db.Model(&models.ComicPage{ID: pageID}).Updates(map[string]interface{}{
+		"image_status": gorm.Expr("CASE WHEN image_id <> '' THEN ? ELSE ? END", models.PageImageStatusCompleted, models.PageImageStatusPending),
+	}).Error
+}
+
+// RestoreImage 恢复修订记录中的页面图片、嵌字图层与分格区域。
+func (r *PageRepository) RestoreImage(pageID uint, revision *models.AssetRevision) error {
+	status := models.PageImageStatusPending
+	if revision.ImageID != "" {
+		status = models.PageImageStatusCompleted
+	}
+	return r.db.Model(&models.ComicPage{ID: pageID}).
+		Select("ImageStatus", "ImageID", "ImageError", "LetteredImageID", "PanelRects", "ImagePrompt", "QAReport").
+		Updates(&models.ComicPage{
+			ImageStatus:     status,
+			ImageID:         revision.ImageID,
+			LetteredImageID: revision.LetteredImageID,
+			PanelRects:      revision.PanelRects,
+			ImagePrompt:     revision.Prompt,
+		}).Error
+}
+
+// DeleteBySectionID 删除章节当前的页面及其分格与对白，用于重新生成分镜前清理旧数据。已归档到修订记录的旧页面保留。
+func (r *PageRepository) DeleteBySectionID(sectionID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		pageIDs := tx.Model(&models.ComicPage{}).Select("id").Where("section_id = ? AND revision_id IS NULL", sectionID)
+		if err := tx.Where("page_id IN (?)", pageIDs).Delete(&models.ComicPageDetail{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("page_id IN (?)", pageIDs).Delete(&models.ComicPanel{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("section_id = ? AND revision_id IS NULL", sectionID).Delete(&models.ComicPage{}).Error
+	})
+}
+
+func (r *PageRepository) UpdateImagePrompt(pageID uint, prompt string) error {
+	return r.db.Model(&models.ComicPage{ID: pageID}).Update("image_prompt", prompt).Error
+}
+
+// RenameCharacter 将漫画中对白记录的出场角色名由 oldName 改为 newName，包括已归档的旧页面。
+func (r *PageRepository) RenameCharacter(comicID uint, oldName, newName string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		sectionIDs := tx.Model(&models.ComicSection{}).Select("id").Where("comic_id = ?", comicID)
+		pageIDs := tx.Model(&models.ComicPage{}).Select("id").Where("section_id IN (?)", sectionIDs)
+
+		var details []models.ComicPageDetail
+		if err := tx.Where("page_id IN (?) AND character_names LIKE ?", pageIDs, "%"+oldName+"%").Find(&details).Error; err != nil {
+			return err
+		}
+		for i := range details {
+			names := details[i].CharacterNames
+			changed := false
+			for j := range names {
+				if names[j] == oldName {
+					names[j], changed = newName, true
+				}
+			}
+			if !changed {
+				continue
+			}
+			if err := tx.Model(&details[i]).Select("CharacterNames").Updates(&models.ComicPageDetail{CharacterNames: names}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateRoleVoice 将角色已有对白（旁白除外）的音色改为角色当前的音色。
+func (r *PageRepository) UpdateRoleVoice(roleID uint, voiceName, voiceType string) error {
+	return r.db.Model(&models.ComicPageDetail{}).Where("role_id = ? AND is_narration = ?", roleID, false).Updates(map[string]interface{}{
+		"voice_name": voiceName,
+		"voice_type": voiceType,
+	}).Error
+}
+
+// FindComicIDByDetailID 返回对白所属漫画的 ID。
+func (r *PageRepository) FindComicIDByDetailID(detailID uint) (uint, error) {
+	var comicIDs []uint
+	err := r.db.Model(&models.ComicPageDetail{}).
+		Joins("JOIN comic_pages ON comic_pages.id = comic_page_details.page_id").
+		Joins("JOIN comic_sections ON comic_sections.id = comic_pages.section_id").
+		Where("comic_page_details.id = ?", detailID).
+		Pluck("comic_sections.comic_id", &comicIDs).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(comicIDs) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return comicIDs[0], nil
+}
+
+// FindDialogueByRoleID 返回角色的非旁白对白记录。
+func (r *PageRepository) FindDialogueByRoleID(roleID uint) ([]models.ComicPageDetail, error) {
+	var details []models.ComicPageDetail
+	err := r.db.Where("role_id = ? AND is_narration = ?", roleID, false).Order("id").Find(&details).Error
+	return details, err
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"gorm.io/gorm"
+)
+
+type RevisionRepository struct {
+	db *gorm.DB
+}
+
+func NewRevisionRepository(db *gorm.DB) *RevisionRepository {
+	return &RevisionRepository{db: db}
+}
+
+func (r *RevisionRepository) Create(revision *models.AssetRevision) error {
+	return r.db.Create(revision).Error
+}
+
+func (r *RevisionRepository) FindByID(id uint) (*models.AssetRevision, error) {
+	var revision models.AssetRevision
+	err := r.db.First(&revision, id).Error
+	return &revision, err
+}
+
+// FindByComicID 按时间倒序列出漫画的修订记录，targetType 与 targetID 为空值时不过滤。
+func (r *RevisionRepository) FindByComicID(comicID uint, targetType string, targetID uint) ([]models.AssetRevision, error) {
+	query := r.db.Where("comic_id = ?", comicID)
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if targetID != 0 {
+		query = query.Where("target_id = ?", targetID)
+	}
+
+	var revisions []models.AssetRevision
+	err := query.Order("id DESC").Find(&revisions).Error
+	return revisions, err
+}
+
+func (r *RevisionRepository) Delete(id uint) error {
+	return r.db.Delete(&models.AssetRevision{}, id).Error
+}
+
+// ArchiveStoryboard 写入章节分镜的修订记录，并把章节当前的页面归档到该记录下。
+func (r *RevisionRepository) ArchiveStoryboard(revision *models.AssetRevision) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return archiveStoryboard(tx, revision)
+	})
+}
+
+// RevertStoryboard 把章节当前的页面归档到新的修订记录 current 下，恢复 target 归档的页面并删除 target。
+func (r *RevisionRepository) RevertStoryboard(target, current *models.AssetRevision) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := archiveStoryboard(tx, current); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ComicPage{}).
+			Where("revision_id = ?", target.ID).
+			Update("revision_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.AssetRevision{}, target.ID).Error
+	})
+}
+
+func archiveStoryboard(tx *gorm.DB, revision *models.AssetRevision) error {
+	if err := tx.Create(revision).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.ComicPage{}).
+		Where("section_id = ? AND revision_id IS NULL", revision.TargetID).
+		Update("revision_id", revision.ID).Error
+}
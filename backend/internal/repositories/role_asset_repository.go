@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"gorm.io/gorm"
+)
+
+type RoleAssetRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleAssetRepository(db *gorm.DB) *RoleAssetRepository {
+	return &RoleAssetRepository{db: db}
+}
+
+func (r *RoleAssetRepository) Create(asset *models.ComicRoleAsset) error {
+	return r.db.Create(asset).Error
+}
+
+func (r *RoleAssetRepository) FindByRoleID(roleID uint) ([]models.ComicRoleAsset, error) {
+	var assets []models.ComicRoleAsset
+	err := r.db.Where("role_id = ?", roleID).Order("id").Find(&assets).Error
+	return assets, err
+}
+
+func (r *RoleAssetRepository) Update(asset *models.ComicRoleAsset) error {
+	return r.db.Save(asset).Error
+}
+
+// DeleteByRoleID 删除角色的设定表图片，角色原画更换后由下次渲染按新原画重新生成。
+func (r *RoleAssetRepository) DeleteByRoleID(roleID uint) error {
+	return r.db.Where("role_id = ?", roleID).Delete(&models.ComicRoleAsset{}).Error
+}
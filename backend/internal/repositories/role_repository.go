@@ -32,3 +32,20 @@ func (r *RoleRepository) FindByID(id uint) (*models.ComicRole, error) {
 func (r *RoleRepository) Update(role *models.ComicRole) error {
 	return r.db.Save(role).Error
 }
+
+func (r *RoleRepository) UpdateConceptArtPrompt(id uint, prompt string) error {
+	return r.db.Model(&models.ComicRole{}).Where("id = ?", id).Update("concept_art_prompt", prompt).Error
+}
+
+// Delete 删除角色及其设定表图片，引用该角色的对白记录改为不关联角色。
+func (r *RoleRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ComicPageDetail{}).Where("role_id = ?", id).Update("role_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", id).Delete(&models.ComicRoleAsset{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.ComicRole{}, id).Error
+	})
+}
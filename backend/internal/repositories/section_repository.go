@@ -17,9 +17,27 @@ func (r *SectionRepository) Create(section *models.ComicSection) error {
 	return r.db.Create(section).Error
 }
 
+// CreateWithJobs 在同一事务中写入章节与生成任务。jobsFor 在章节写入后调用，此时章节 ID 已回填；
+// 任一写入失败时整体回滚，不会留下没有任务的章节。
+func (r *SectionRepository) CreateWithJobs(sections []*models.ComicSection, jobsFor func() []*models.ComicJob) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, section := range sections {
+			if err := tx.Create(section).Error; err != nil {
+				return err
+			}
+		}
+		for _, job := range jobsFor() {
+			if err := tx.Create(job).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (r *SectionRepository) FindByID(id uint) (*models.ComicSection, error) {
 	var section models.ComicSection
-	err := r.db.Preload("Pages.Details").First(&section, id).Error
+	err := r.db.Preload("Pages", "revision_id IS NULL").Preload("Pages.Panels").Preload("Pages.Details").First(&section, id).Error
 	return &section, err
 }
 
@@ -29,10 +47,44 @@ func (r *SectionRepository) FindByComicID(comicID uint) ([]models.ComicSection,
 	return sections, err
 }
 
+// FindByComicIDAndIndex 按章节序号查找章节，不加载页面。
+func (r *SectionRepository) FindByComicIDAndIndex(comicID uint, index int) (*models.ComicSection, error) {
+	var section models.ComicSection
+	err := r.db.Where("comic_id = ? AND index = ?", comicID, index).First(&section).Error
+	return &section, err
+}
+
 func (r *SectionRepository) Update(section *models.ComicSection) error {
 	return r.db.Save(section).Error
 }
 
+func (r *SectionRepository) UpdateStoryboardInstruction(id uint, instruction string) error {
+	return r.db.Model(&models.ComicSection{}).Where("id = ?", id).Update("storyboard_instruction", instruction).Error
+}
+
+func (r *SectionRepository) UpdateStoryMemory(id uint, synopsis, worldState string) error {
+	return r.db.Model(&models.ComicSection{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"story_synopsis":    synopsis,
+		"story_world_state": worldState,
+	}).Error
+}
+
+func (r *SectionRepository) UpdateAudioStatus(id uint, status string) error {
+	return r.db.Model(&models.ComicSection{}).Where("id = ?", id).Update("audio_status", status).Error
+}
+
+// UpdateAudioStatusForJob 由语音预合成任务写入章节的 audio_status。该章节已有 ID 更大的未结束语音任务时
+// 说明对白在本任务开始后又有变化，结果已经过时，不做更新并返回 false。
+func (r *SectionRepository) UpdateAudioStatusForJob(id uint, jobID uint, status string) (bool, error) {
+	newer := r.db.Model(&models.ComicJob{}).Select("1").
+		Where("section_id = ? AND stage = ? AND id > ? AND status IN ?", id, models.JobStageTTS, jobID,
+			[]string{models.JobStatusPending, models.JobStatusRunning, models.JobStatusPaused})
+	result := r.db.Model(&models.ComicSection{}).
+		Where("id = ? AND NOT EXISTS (?)", id, newer).
+		Update("audio_status", status)
+	return result.RowsAffected > 0, result.Error
+}
+
 func (r *SectionRepository) CountByComicID(comicID uint) (int64, error) {
 	var count int64
 	err := r.db.Model(&models.ComicSection{}).Where("comic_id = ?", comicID).Count(&count).Error
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/cohesion-dev/GNX/ai/gnxaigc"
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/logger"
+)
+
+// syncCharacterSheet 以角色原画为参考，补齐角色的转面视图与表情图并保存为角色素材。
+// refresh 为 true 表示原画刚刚重新生成，已有的设定表图片全部重新生成。
+func (s *ComicService) syncCharacterSheet(
+	ctx context.Context,
+	role *models.ComicRole,
+	asset *CharacterAsset,
+	imageStyle string,
+	refresh bool,
+) {
+	existing, err := s.roleAssetRepo.FindByRoleID(role.ID)
+	if err != nil {
+		logger.Error("[Character Sheet] Character %s: failed to load role assets: %v", role.Name, err)
+		return
+	}
+
+	records := make(map[string]*models.ComicRoleAsset)
+	for i := range existing {
+		records[existing[i].Kind+"/"+existing[i].Name] = &existing[i]
+	}
+
+	asset.Views = make(map[string][]byte)
+	asset.Expressions = make(map[string][]byte)
+
+	type sheetItem struct {
+		kind string
+		name string
+	}
+	var items []sheetItem
+	for _, name := range gnxaigc.SheetViews {
+		items = append(items, sheetItem{kind: models.RoleAssetKindView, name: name})
+	}
+	for _, name := range gnxaigc.SheetExpressions {
+		items = append(items, sheetItem{kind: models.RoleAssetKindExpression, name: name})
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, item := range items {
+		wg.Add(1)
+		go func(item sheetItem) {
+			defer wg.Done()
+
+			record := records[item.kind+"/"+item.name]
+			imageData, err := s.loadOrGenerateSheetImage(ctx, role, asset, record, item.kind, item.name, imageStyle, refresh)
+			if err != nil {
+				logger.Error("[Character Sheet] Character %s: failed to prepare %s %s: %v", role.Name, item.kind, item.name, err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if item.kind == models.RoleAssetKindView {
+				asset.Views[item.name] = imageData
+			} else {
+				asset.Expressions[item.name] = imageData
+			}
+		}(item)
+	}
+	wg.Wait()
+
+	logger.Info("[Character Sheet] Character %s: %d views and %d expressions ready", role.Name, len(asset.Views), len(asset.Expressions))
+}
+
+func (s *ComicService) loadOrGenerateSheetImage(
+	ctx context.Context,
+	role *models.ComicRole,
+	asset *CharacterAsset,
+	record *models.ComicRoleAsset,
+	kind, name, imageStyle string,
+	refresh bool,
+) ([]byte, error) {
+	if record != nil && record.ImageID != "" && !refresh {
+		imageData, err := s.storage.DownloadBytes(record.ImageID)
+		if err == nil && len(imageData) > 0 {
+			return imageData, nil
+		}
+		logger.Warn("[Character Sheet] Character %s: cannot read %s %s (%v), regenerating", role.Name, kind, name, err)
+	}
+
+	prompt := gnxaigc.ComposeCharacterSheetPrompt(imageStyle, asset.Feature.ConceptArtPrompt, name)
+	imageData, err := s.generateImageByImage(ctx, asset.ImageData, prompt)
+	if err != nil {
+		logger.Warn("[Character Sheet] Character %s: img2img for %s %s failed (%v), falling back to text generation", role.Name, kind, name, err)
+		imageData, err = s.generateImageByText(ctx, prompt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	imageID := fmt.Sprintf("character_%d_%s_%s", role.ID, kind, name)
+	if err := s.storage.UploadBytes(imageData, imageID); err != nil {
+		return nil, fmt.Errorf("failed to upload image: %w", err)
+	}
+
+	if record == nil {
+		record = &models.ComicRoleAsset{RoleID: role.ID, Kind: kind, Name: name, ImageID: imageID}
+		err = s.roleAssetRepo.Create(record)
+	} else {
+		record.ImageID = imageID
+		err = s.roleAssetRepo.Update(record)
+	}
+	if err != nil {
+		logger.Error("[Character Sheet] Character %s: failed to save %s %s: %v", role.Name, kind, name, err)
+	} else {
+		logger.Info("[Character Sheet] Character %s: %s %s uploaded (imageID=%s)", role.Name, kind, name, imageID)
+	}
+
+	return imageData, nil
+}
+
+// loadCharacterSheet 读取已保存的角色设定表图片，不会生成缺失的图片。
+func (s *ComicService) loadCharacterSheet(role *models.ComicRole, asset *CharacterAsset) {
+	records, err := s.roleAssetRepo.FindByRoleID(role.ID)
+	if err != nil {
+		logger.Warn("[Character Sheet] Character %s: failed to load role assets: %v", role.Name, err)
+		return
+	}
+
+	asset.Views = make(map[string][]byte)
+	asset.Expressions = make(map[string][]byte)
+	for _, record := range records {
+		imageData, err := s.storage.DownloadBytes(record.ImageID)
+		if err != nil {
+			logger.Warn("[Character Sheet] Character %s: failed to download %s %s: %v", role.Name, record.Kind, record.Name, err)
+			continue
+		}
+		if record.Kind == models.RoleAssetKindView {
+			asset.Views[record.Name] = imageData
+		} else {
+			asset.Expressions[record.Name] = imageData
+		}
+	}
+}
+
+// ReferenceImage 返回与出镜角度和表情匹配的参考图。侧面或背面出镜时优先使用对应的转面视图，
+// 其次使用表情图，没有表情时使用正面视图，都没有时使用角色原画。
+func (a *CharacterAsset) ReferenceImage(emotion, view string) []byte {
+	if view == gnxaigc.SheetViewSide || view == gnxaigc.SheetViewBack {
+		if data := a.Views[view]; len(data) > 0 {
+			return data
+		}
+	}
+	if data := a.Expressions[gnxaigc.NormalizeEmotion(emotion)]; len(data) > 0 {
+		return data
+	}
+	if data := a.Views[gnxaigc.SheetViewFront]; len(data) > 0 {
+		return data
+	}
+	return a.ImageData
+}
+
+// characterView 返回角色在给定分格中首次以侧面或背面出镜的角度。角色出现在分格的对白片段或画面描述中时，
+// 按该分格的画面描述判断角度。
+func characterView(panels []gnxaigc.StoryboardPanel, name string) string {
+	for _, panel := range panels {
+		appears := strings.Contains(panel.VisualPrompt, name)
+		for _, segment := range panel.SourceTextSegments {
+			appears = appears || slices.Contains(segment.CharacterNames, name)
+		}
+		if !appears {
+			continue
+		}
+		if view := gnxaigc.DetectSheetView(panel.VisualPrompt); view != "" {
+			return view
+		}
+	}
+	return ""
+}
+
+// characterEmotions 记录每个角色在给定分格中首次出现时标注的表情。
+func characterEmotions(panels []gnxaigc.StoryboardPanel) map[string]string {
+	emotions := make(map[string]string)
+	for _, panel := range panels {
+		for _, segment := range panel.SourceTextSegments {
+			emotion := gnxaigc.NormalizeEmotion(segment.Emotion)
+			if emotion == "" {
+				continue
+			}
+			for _, name := range segment.CharacterNames {
+				if _, ok := emotions[name]; !ok {
+					emotions[name] = emotion
+				}
+			}
+		}
+	}
+	return emotions
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/logger"
+)
+
+// ErrGenerationCancelled 漫画已取消生成，不能再暂停、恢复或追加任务。
+var ErrGenerationCancelled = errors.New("comic generation has been cancelled")
+
+// PauseComic 暂停漫画的生成：未结束的任务转为暂停状态，执行中的任务通过 context 中断 AI 调用。
+// 已完成的阶段保留结果，恢复后从第一个未完成的阶段继续。
+func (s *ComicService) PauseComic(comicID uint) (*models.Comic, error) {
+	comic, err := s.comicRepo.FindByID(comicID)
+	if err != nil {
+		return nil, err
+	}
+	switch comic.GenerationState {
+	case models.GenerationStateCancelled:
+		return nil, ErrGenerationCancelled
+	case models.GenerationStatePaused:
+		return comic, nil
+	}
+
+	// 先改写状态再写入任务表，避免暂停期间新追加的任务以待执行状态写入
+	if err := s.comicRepo.UpdateGenerationState(comicID, models.GenerationStatePaused); err != nil {
+		return nil, err
+	}
+	paused, err := s.jobRepo.PauseByComicID(comicID)
+	if err != nil {
+		return nil, err
+	}
+	stopped := s.stopInflightJobs(comicID)
+	logger.Info("[Comic Control] Paused comic ID=%d: %d jobs paused, %d running jobs interrupted", comicID, paused, stopped)
+
+	comic.GenerationState = models.GenerationStatePaused
+	s.publishGenerationState(comic, EventGenerationPaused)
+	return comic, nil
+}
+
+// ResumeComic 恢复已暂停的漫画，暂停的任务重新进入待执行状态。
+func (s *ComicService) ResumeComic(comicID uint) (*models.Comic, error) {
+	comic, err := s.comicRepo.FindByID(comicID)
+	if err != nil {
+		return nil, err
+	}
+	switch comic.GenerationState {
+	case models.GenerationStateCancelled:
+		return nil, ErrGenerationCancelled
+	case models.GenerationStateRunning:
+		return comic, nil
+	}
+
+	if err := s.comicRepo.UpdateGenerationState(comicID, models.GenerationStateRunning); err != nil {
+		return nil, err
+	}
+	resumed, err := s.jobRepo.ResumeByComicID(comicID)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("[Comic Control] Resumed comic ID=%d: %d jobs queued", comicID, resumed)
+
+	comic.GenerationState = models.GenerationStateRunning
+	s.publishGenerationState(comic, EventGenerationResumed)
+	return comic, nil
+}
+
+// CancelComic 取消漫画的生成：所有未结束的任务被取消且不再执行，执行中的任务立即中断。
+func (s *ComicService) CancelComic(comicID uint) (*models.Comic, error) {
+	comic, err := s.comicRepo.FindByID(comicID)
+	if err != nil {
+		return nil, err
+	}
+	if comic.GenerationState == models.GenerationStateCancelled {
+		return comic, nil
+	}
+
+	if err := s.comicRepo.UpdateGenerationState(comicID, models.GenerationStateCancelled); err != nil {
+		return nil, err
+	}
+	cancelled, err := s.jobRepo.CancelByComicID(comicID)
+	if err != nil {
+		return nil, err
+	}
+	stopped := s.stopInflightJobs(comicID)
+	logger.Info("[Comic Control] Cancelled comic ID=%d: %d jobs cancelled, %d running jobs interrupted", comicID, cancelled, stopped)
+
+	comic.GenerationState = models.GenerationStateCancelled
+	s.publishGenerationState(comic, EventGenerationCanceled)
+	return comic, nil
+}
+
+// stopInflightJobs 中断本进程中该漫画执行中的任务。其他进程中的任务会在下次续期租约时发现租约丢失而中断。
+func (s *ComicService) stopInflightJobs(comicID uint) int {
+	if s.runner == nil {
+		return 0
+	}
+	return s.runner.StopComic(comicID)
+}
+
+func (s *ComicService) publishGenerationState(comic *models.Comic, eventType string) {
+	s.events.Publish(comic.ID, ProgressEvent{
+		Type:            eventType,
+		Status:          comic.Status,
+		GenerationState: comic.GenerationState,
+		Progress:        loadJobProgress(s.jobRepo, comic.ID),
+	})
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/logger"
+)
+
+// RegisterJobHandlers 注册漫画生成流程各阶段的任务处理函数。
+func (s *ComicService) RegisterJobHandlers(runner *JobRunner) {
+	s.runner = runner
+	runner.Register(models.JobStageRoles, s.runRolesJob)
+	runner.Register(models.JobStageConceptArt, s.runConceptArtJob)
+	runner.Register(models.JobStageStoryboard, s.runStoryboardJob)
+	runner.Register(models.JobStagePageImage, s.runPageImageJob)
+	runner.Register(models.JobStageRolePortrait, s.runRolePortraitJob)
+}
+
+// comicJobs 返回新导入漫画的角色提取、原画生成以及每个章节的分镜任务，章节须已写入并有 ID。
+// 这些任务均为 Ordered，按写入顺序串行执行，保证剧情记忆按章节顺序滚动；页面图片任务由分镜任务完成后追加。
+func (s *ComicService) comicJobs(comicID uint, sections []*models.ComicSection) []*models.ComicJob {
+	jobs := []*models.ComicJob{
+		s.newJob(&models.ComicJob{ComicID: comicID, Stage: models.JobStageRoles, Ordered: true}),
+		s.newJob(&models.ComicJob{ComicID: comicID, Stage: models.JobStageConceptArt, Ordered: true}),
+	}
+	for _, section := range sections {
+		jobs = append(jobs, s.newJob(&models.ComicJob{ComicID: comicID, SectionID: &section.ID, Stage: models.JobStageStoryboard, Ordered: true}))
+	}
+	return jobs
+}
+
+// enqueueJob 写入单个任务，同一目标已有未结束的同阶段任务时不重复写入。
+// 漫画已暂停时任务以暂停状态写入，恢复后再执行；漫画已取消时不再写入。
+func (s *ComicService) enqueueJob(job *models.ComicJob) (*models.ComicJob, error) {
+	return s.enqueue(job, s.jobRepo.CreateUnlessActive)
+}
+
+func (s *ComicService) enqueue(job *models.ComicJob, create func(*models.ComicJob) (*models.ComicJob, error)) (*models.ComicJob, error) {
+	comic, err := s.comicRepo.FindByID(job.ComicID)
+	if err != nil {
+		return nil, err
+	}
+	if comic.GenerationState == models.GenerationStateCancelled {
+		return nil, ErrGenerationCancelled
+	}
+
+	job = s.newJob(job)
+	if comic.GenerationState == models.GenerationStatePaused {
+		job.Status = models.JobStatusPaused
+	}
+	job, err = create(job)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("[Comic Jobs] Job ID=%d stage=%s queued for comic ID=%d", job.ID, job.Stage, job.ComicID)
+	return job, nil
+}
+
+// enqueueSectionAudio 分镜或音色变化后追加对白语音的预合成任务，章节的 audio_status 回到 pending，
+// 合成完毕前章节不算完整可用。已有待执行的语音任务时复用该任务；执行中的任务读到的是旧对白，
+// 因此仍会排入新任务，旧任务结束时发现有更新的任务便不再改写 audio_status。
+// 先写入任务再改写状态，保证旧任务无论何时结束都不会把状态覆盖为 completed。
+func (s *ComicService) enqueueSectionAudio(comicID uint, section *models.ComicSection) {
+	status := models.SectionAudioStatusPending
+	if _, err := s.enqueue(&models.ComicJob{ComicID: comicID, SectionID: &section.ID, Stage: models.JobStageTTS}, s.jobRepo.CreateUnlessQueued); err != nil {
+		logger.Error("[Comic Jobs] Failed to enqueue audio synthesis for section ID=%d: %v", section.ID, err)
+		status = models.SectionAudioStatusFailed
+	}
+	if err := s.sectionRepo.UpdateAudioStatus(section.ID, status); err != nil {
+		logger.Warn("[Comic Jobs] Failed to set audio status of section ID=%d to %s: %v", section.ID, status, err)
+	}
+}
+
+// refreshComicAudio 为已完成分镜的章节重新写入语音预合成任务。任务只合成音色或语速有变化的对白，
+// 未变化的对白直接复用已有语音。
+func (s *ComicService) refreshComicAudio(comicID uint) {
+	sections, err := s.sectionRepo.FindByComicID(comicID)
+	if err != nil {
+		logger.Warn("[Comic Jobs] Failed to load sections of comic ID=%d: %v", comicID, err)
+		return
+	}
+	for i := range sections {
+		if sections[i].Status == "completed" && sections[i].AudioStatus != "" {
+			s.enqueueSectionAudio(comicID, &sections[i])
+		}
+	}
+}
+
+func (s *ComicService) newJob(job *models.ComicJob) *models.ComicJob {
+	job.Status = models.JobStatusPending
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = max(1, s.jobCfg.MaxAttempts)
+	}
+	if job.RunAfter.IsZero() {
+		job.RunAfter = time.Now()
+	}
+	return job
+}
+
+func (s *ComicService) runRolesJob(ctx context.Context, job *models.ComicJob) error {
+	return s.extractComicRoles(ctx, job.ComicID)
+}
+
+func (s *ComicService) runConceptArtJob(ctx context.Context, job *models.ComicJob) error {
+	return s.processComicImages(ctx, job.ComicID)
+}
+
+// runStoryboardJob 为章节生成分镜。已完成的章节直接跳过；否则先清理上次中断时留下的页面，保证重试幂等。
+func (s *ComicService) runStoryboardJob(ctx context.Context, job *models.ComicJob) error {
+	if job.SectionID == nil {
+		return errors.New("storyboard job has no section")
+	}
+
+	comic, err := s.comicRepo.FindByID(job.ComicID)
+	if err != nil {
+		return fmt.Errorf("failed to get comic %d: %w", job.ComicID, err)
+	}
+	section, err := s.sectionRepo.FindByID(*job.SectionID)
+	if err != nil {
+		return fmt.Errorf("failed to get section %d: %w", *job.SectionID, err)
+	}
+
+	if section.Status == "completed" {
+		logger.Info("[Comic Jobs] Section ID=%d already completed, skipping storyboard", section.ID)
+		return nil
+	}
+
+	if err := s.pageRepo.DeleteBySectionID(section.ID); err != nil {
+		return fmt.Errorf("failed to clear pages of section %d: %w", section.ID, err)
+	}
+
+	return s.processSectionSync(ctx, comic, section)
+}
+
+// runPageImageJob 渲染章节内全部页面的图片；指定 PageID 时只重新生成该页。
+func (s *ComicService) runPageImageJob(ctx context.Context, job *models.ComicJob) error {
+	if job.SectionID == nil {
+		return errors.New("page image job has no section")
+	}
+
+	comic, err := s.comicRepo.FindByID(job.ComicID)
+	if err != nil {
+		return fmt.Errorf("failed to get comic %d: %w", job.ComicID, err)
+	}
+
+	if job.PageID != nil {
+		return s.regeneratePageImage(ctx, comic, *job.PageID)
+	}
+	return s.processSectionImages(ctx, comic, *job.SectionID)
+}
+
+func (s *ComicService) runRolePortraitJob(ctx context.Context, job *models.ComicJob) error {
+	if job.RoleID == nil {
+		return errors.New("role portrait job has no role")
+	}
+
+	comic, err := s.comicRepo.FindByID(job.ComicID)
+	if err != nil {
+		return fmt.Errorf("failed to get comic %d: %w", job.ComicID, err)
+	}
+
+	return s.regenerateRolePortrait(ctx, comic, *job.RoleID)
+}
@@ -2,85 +2,181 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"regexp"
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/cohesion-dev/GNX/ai/gnxaigc"
+	"github.com/cohesion-dev/GNX/backend_new/config"
 	"github.com/cohesion-dev/GNX/backend_new/internal/models"
 	"github.com/cohesion-dev/GNX/backend_new/internal/repositories"
-	"github.com/cohesion-dev/GNX/backend_new/pkg/imageutil"
 	"github.com/cohesion-dev/GNX/backend_new/pkg/logger"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/novel"
 	"github.com/cohesion-dev/GNX/backend_new/pkg/storage"
 	"github.com/google/uuid"
 )
 
-var chapterHeadingPattern = regexp.MustCompile(`^第[零〇一二三四五六七八九十百千万0-9]+章.*$`)
-
 type CharacterAsset struct {
 	Feature   gnxaigc.CharacterFeature
 	ImageData []byte
 	Prompt    string
+	// Views 与 Expressions 为角色设定表图片，仅在开启角色设定表时填充
+	Views       map[string][]byte
+	Expressions map[string][]byte
 }
 
 type ComicService struct {
-	comicRepo   *repositories.ComicRepository
-	roleRepo    *repositories.RoleRepository
-	sectionRepo *repositories.SectionRepository
-	pageRepo    *repositories.PageRepository
-	storage     *storage.Storage
-	aigc        *gnxaigc.GnxAIGC
+	comicRepo     *repositories.ComicRepository
+	roleRepo      *repositories.RoleRepository
+	roleAssetRepo *repositories.RoleAssetRepository
+	sectionRepo   *repositories.SectionRepository
+	pageRepo      *repositories.PageRepository
+	jobRepo       *repositories.JobRepository
+	revisionRepo  *repositories.RevisionRepository
+	events        *EventBroker
+	storage       *storage.Storage
+	aigc          *gnxaigc.GnxAIGC
+	cfg           *config.PipelineConfig
+	jobCfg        *config.JobConfig
+	novelCfg      *config.NovelConfig
+	runner        *JobRunner
+	// imageSlots 限制同时调用图像模型的数量
+	imageSlots chan struct{}
 }
 
 func NewComicService(
 	comicRepo *repositories.ComicRepository,
 	roleRepo *repositories.RoleRepository,
+	roleAssetRepo *repositories.RoleAssetRepository,
 	sectionRepo *repositories.SectionRepository,
 	pageRepo *repositories.PageRepository,
+	jobRepo *repositories.JobRepository,
+	revisionRepo *repositories.RevisionRepository,
+	events *EventBroker,
 	storage *storage.Storage,
 	aigc *gnxaigc.GnxAIGC,
+	cfg *config.PipelineConfig,
+	jobCfg *config.JobConfig,
+	novelCfg *config.NovelConfig,
 ) *ComicService {
 	return &ComicService{
-		comicRepo:   comicRepo,
-		roleRepo:    roleRepo,
-		sectionRepo: sectionRepo,
-		pageRepo:    pageRepo,
-		storage:     storage,
-		aigc:        aigc,
+		comicRepo:     comicRepo,
+		roleRepo:      roleRepo,
+		roleAssetRepo: roleAssetRepo,
+		sectionRepo:   sectionRepo,
+		pageRepo:      pageRepo,
+		jobRepo:       jobRepo,
+		revisionRepo:  revisionRepo,
+		events:        events,
+		storage:       storage,
+		aigc:          aigc,
+		cfg:           cfg,
+		jobCfg:        jobCfg,
+		novelCfg:      novelCfg,
+		imageSlots:    make(chan struct{}, max(1, cfg.ImageConcurrency)),
 	}
 }
 
-func (s *ComicService) CreateComic(ctx context.Context, title, userPrompt string, file io.Reader) (*models.Comic, error) {
+// ErrInvalidNovel 上传的小说文件无法解析，或缺少标题。
+var ErrInvalidNovel = errors.New("invalid novel file")
+
+// ImportOptions 导入小说时的拆分选项。
+type ImportOptions struct {
+	// ChapterPattern 非空时只按该正则识别章节标题
+	ChapterPattern string
+	// Resection 拆分章节后合并过短的章节、在场景切换处拆开过长的章节
+	Resection bool
+	// TargetPages 重新分段时每章的目标页数，为 0 时使用配置
+	TargetPages int
+	// Cleanup 正文清理选项
+	Cleanup CleanupOptions
+}
+
+// CleanupOptions 正文清理选项。
+type CleanupOptions struct {
+	// Skip 为 true 时保留原文，不做清理
+	Skip bool
+	// Patterns 追加在配置之后的清理正则，匹配到的文本会被删除
+	Patterns []string
+}
+
+// CreateComic 解析上传的小说并创建漫画。EPUB 文件按目录拆分章节，并读取书名与作者；
+// 其余文件按纯文本识别章节标题拆分。拆分后的正文先清理广告、网址等杂质，返回的清理报告列出删除的内容。
+// title 为空时使用 EPUB 中的书名。
+func (s *ComicService) CreateComic(ctx context.Context, title, userPrompt string, opts ImportOptions, file io.Reader) (*models.Comic, *novel.CleanReport, error) {
 	logger.Info("[Comic Creation] Starting comic creation: title=%s", title)
 
+	book, report, err := s.importNovel(file, opts)
+	if err != nil {
+		logger.Error("[Comic Creation] Failed to parse novel: %v", err)
+		return nil, nil, err
+	}
+	if title == "" {
+		title = book.Title
+	}
+	if title == "" {
+		return nil, nil, fmt.Errorf("%w: title is required", ErrInvalidNovel)
+	}
+
 	comic := &models.Comic{
-		Title:      title,
-		UserPrompt: userPrompt,
-		Status:     "pending",
+		Title:          title,
+		Author:         book.Author,
+		SourceEncoding: book.Encoding,
+		UserPrompt:     userPrompt,
+		Status:         "pending",
 	}
 
 	if err := s.comicRepo.Create(comic); err != nil {
 		logger.Error("[Comic Creation] Failed to create comic: %v", err)
-		return nil, fmt.Errorf("failed to create comic: %w", err)
+		return nil, nil, fmt.Errorf("failed to create comic: %w", err)
 	}
 	logger.Info("[Comic Creation] Comic created with ID=%d, status=pending", comic.ID)
 
+	logger.Info("[Comic Creation] Processing novel content for comic ID=%d", comic.ID)
+	if err := s.processComicSync(ctx, comic.ID, book.Chapters); err != nil {
+		logger.Error("[Comic Creation] Failed to process comic: %v", err)
+		return nil, nil, fmt.Errorf("failed to process comic: %w", err)
+	}
+
+	logger.Info("[Comic Creation] Comic ID=%d created successfully", comic.ID)
+	return comic, report, nil
+}
+
+// PreviewNovel 按与创建漫画相同的流程解析小说，不写入数据库，用于预览章节拆分与清理结果。
+func (s *ComicService) PreviewNovel(file io.Reader, opts ImportOptions) (*novel.Book, *novel.CleanReport, error) {
+	return s.importNovel(file, opts)
+}
+
+// importNovel 读取小说文件，拆分章节后依次清理正文、按需重新分段。
+func (s *ComicService) importNovel(file io.Reader, opts ImportOptions) (*novel.Book, *novel.CleanReport, error) {
 	content, err := io.ReadAll(file)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return nil, nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	logger.Info("[Comic Creation] Processing novel content for comic ID=%d", comic.ID)
-	if err := s.processComicSync(ctx, comic.ID, string(content)); err != nil {
-		logger.Error("[Comic Creation] Failed to process comic: %v", err)
-		return nil, fmt.Errorf("failed to process comic: %w", err)
+	book, err := s.parseNovel(content, opts.ChapterPattern)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	logger.Info("[Comic Creation] Comic ID=%d created successfully", comic.ID)
-	return comic, nil
+	report := &novel.CleanReport{Counts: map[string]int{}}
+	if !opts.Cleanup.Skip {
+		cleaner, err := s.newCleaner(opts.Cleanup.Patterns)
+		if err != nil {
+			return nil, nil, err
+		}
+		book.Chapters, report = cleaner.Clean(book.Chapters)
+		logger.Info("[Comic Creation] Cleaned novel text: %v", report.Counts)
+	}
+
+	if opts.Resection {
+		book.Chapters = s.resection(book.Chapters, opts.TargetPages)
+	}
+	return book, report, nil
 }
 
 func (s *ComicService) GetComicList(page, limit int, status string) ([]models.Comic, int64, error) {
@@ -96,74 +192,102 @@ func (s *ComicService) GetComicDetail(id uint) (*models.Comic, error) {
 	slices.SortFunc(ret.Sections, func(a, b models.ComicSection) int {
 		return a.Index - b.Index
 	})
+	for i := range ret.Sections {
+		ret.Sections[i].Ready = ret.Sections[i].IsReady()
+	}
 	return ret, nil
 }
 
-type novelChapter struct {
-	Title   string
-	Content string
-}
-
-func splitChaptersFromText(raw string) []novelChapter {
-	lines := strings.Split(raw, "\n")
-	var chapters []novelChapter
-	var currentTitle string
-	var buffer []string
-
-	flush := func() {
-		if currentTitle == "" && len(buffer) == 0 {
-			return
+// parseNovel 按文件内容选择解析方式：EPUB 读取目录与元数据，其余识别编码转为 UTF-8 后按纯文本拆分章节。
+func (s *ComicService) parseNovel(content []byte, chapterPattern string) (*novel.Book, error) {
+	if novel.IsEPUB(content) {
+		book, err := novel.ParseEPUB(content)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidNovel, err)
 		}
-		content := strings.Trim(strings.Join(buffer, "\n"), "\n")
-		chapters = append(chapters, novelChapter{Title: currentTitle, Content: content})
+		logger.Info("[Comic Creation] Parsed EPUB: title=%s, author=%s, %d chapters", book.Title, book.Author, len(book.Chapters))
+		return book, nil
 	}
 
-	for _, line := range lines {
-		trimmed := normalizeHeadingCandidate(line)
-		if chapterHeadingPattern.MatchString(trimmed) {
-			flush()
-			currentTitle = trimmed
-			buffer = buffer[:0]
-			continue
-		}
-		if currentTitle == "" && len(strings.TrimSpace(line)) == 0 {
-			continue
-		}
-		buffer = append(buffer, line)
+	splitter, err := s.newSplitter(chapterPattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNovel, err)
 	}
+	text, encoding, err := novel.DecodeText(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode %s text: %v", ErrInvalidNovel, encoding, err)
+	}
+	logger.Info("[Comic Creation] Detected text encoding: %s", encoding)
+	return &novel.Book{Encoding: encoding, Chapters: splitter.Split(text)}, nil
+}
 
-	flush()
-	return chapters
+// newSplitter 按配置创建章节拆分器：配置中的正则追加在内置规则之后，上传时指定的 chapterPattern 则替换章节规则。
+func (s *ComicService) newSplitter(chapterPattern string) (*novel.Splitter, error) {
+	opts := novel.SplitOptions{
+		ChapterPatterns: append(slices.Clone(novel.DefaultChapterPatterns), s.novelCfg.ChapterPatterns...),
+		VolumePatterns:  append(slices.Clone(novel.DefaultVolumePatterns), s.novelCfg.VolumePatterns...),
+		FallbackRunes:   s.novelCfg.FallbackChapterRunes,
+	}
+	if chapterPattern = strings.TrimSpace(chapterPattern); chapterPattern != "" {
+		opts.ChapterPatterns = []string{chapterPattern}
+	}
+	return novel.NewSplitter(opts)
 }
 
-// normalizeHeadingCandidate strips zero-width characters that often wrap headings in web-crawled novels.
-func normalizeHeadingCandidate(line string) string {
-	trimmed := strings.TrimSpace(line)
-	if trimmed == "" {
-		return trimmed
+// newCleaner 创建正文清理器：内置规则之后依次为配置中的正则与本次上传指定的正则。
+func (s *ComicService) newCleaner(patterns []string) (*novel.Cleaner, error) {
+	cleaner, err := novel.NewCleaner(append(slices.Clone(s.novelCfg.CleanupPatterns), patterns...))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNovel, err)
 	}
+	return cleaner, nil
+}
 
-	var b strings.Builder
-	b.Grow(len(trimmed))
-	for _, r := range trimmed {
-		switch r {
-		case '\u200B', '\u200C', '\u200D', '\uFEFF':
-			continue
-		default:
-			b.WriteRune(r)
-		}
+// CleanSectionText 按与创建漫画相同的规则清理单个章节的正文。
+func (s *ComicService) CleanSectionText(title, content string, opts CleanupOptions) (string, *novel.CleanReport, error) {
+	report := &novel.CleanReport{Counts: map[string]int{}}
+	if opts.Skip {
+		return content, report, nil
+	}
+	cleaner, err := s.newCleaner(opts.Patterns)
+	if err != nil {
+		return "", nil, err
 	}
+	return cleaner.CleanText(title, content, report), report, nil
+}
 
-	return b.String()
+// resection 按目标页数折算字数，合并过短的章节并拆开过长的章节，保留原始章节标题。
+func (s *ComicService) resection(chapters []novel.Chapter, targetPages int) []novel.Chapter {
+	if targetPages <= 0 {
+		targetPages = s.novelCfg.ResectionTargetPages
+	}
+	targetRunes := targetPages * s.novelCfg.RunesPerPage
+	if targetRunes <= 0 {
+		return chapters
+	}
+	sections := novel.Resection(chapters, novel.ResectionOptions{TargetRunes: targetRunes})
+	logger.Info("[Comic Creation] Re-sectioned %d chapters into %d sections (target %d runes)", len(chapters), len(sections), targetRunes)
+	return sections
 }
 
-func (s *ComicService) processComicSync(ctx context.Context, comicID uint, content string) error {
-	comic, err := s.comicRepo.FindByID(comicID)
+// DecodeSectionText 读取上传的章节文件，识别编码后转为 UTF-8，返回正文与识别出的编码。
+func (s *ComicService) DecodeSectionText(file io.Reader) (string, string, error) {
+	content, err := io.ReadAll(file)
 	if err != nil {
+		return "", "", fmt.Errorf("failed to read file: %w", err)
+	}
+	text, encoding, err := novel.DecodeText(content)
+	if err != nil {
+		return "", encoding, fmt.Errorf("%w: failed to decode %s text: %v", ErrInvalidNovel, encoding, err)
+	}
+	return text, encoding, nil
+}
+
+func (s *ComicService) processComicSync(ctx context.Context, comicID uint, chapters []novel.Chapter) error {
+	if _, err := s.comicRepo.FindByID(comicID); err != nil {
 		return fmt.Errorf("failed to get comic %d: %w", comicID, err)
 	}
 
-	chapters := splitChaptersFromText(content)
 	logger.Info("[Comic Processing] Split novel into %d chapters for comic ID=%d", len(chapters), comicID)
 	if len(chapters) == 0 {
 		logger.Error("[Comic Processing] No chapters found in novel for comic ID=%d", comicID)
@@ -171,67 +295,75 @@ func (s *ComicService) processComicSync(ctx context.Context, comicID uint, conte
 		return fmt.Errorf("no chapters found in the novel")
 	}
 
-	logger.Info("[Comic Processing] Synchronously inserting %d sections for comic ID=%d", len(chapters), comicID)
+	logger.Info("[Comic Processing] Inserting %d sections and their jobs for comic ID=%d", len(chapters), comicID)
+	sections := make([]*models.ComicSection, 0, len(chapters))
 	for i, chapter := range chapters {
 		title := chapter.Title
 		if title == "" {
 			title = fmt.Sprintf("第%d章", i+1)
 		}
 
-		section := &models.ComicSection{
-			ComicID: comicID,
-			Title:   title,
-			Index:   i + 1,
-			Content: chapter.Content,
-			Status:  "pending",
-		}
+		sections = append(sections, &models.ComicSection{
+			ComicID:      comicID,
+			Title:        title,
+			Volume:       chapter.Volume,
+			Index:        i + 1,
+			Content:      chapter.Content,
+			Status:       "pending",
+			SourceTitles: chapter.SourceTitles,
+		})
+	}
 
-		if err := s.sectionRepo.Create(section); err != nil {
-			logger.Error("[Comic Processing] Failed to create section %d (%s): %v", i+1, title, err)
-			return fmt.Errorf("failed to create section %d: %w", i+1, err)
-		}
-		logger.Info("[Comic Processing] Created section %d/%d: %s (ID=%d)", i+1, len(chapters), title, section.ID)
+	var jobCount int
+	err := s.sectionRepo.CreateWithJobs(sections, func() []*models.ComicJob {
+		jobs := s.comicJobs(comicID, sections)
+		jobCount = len(jobs)
+		return jobs
+	})
+	if err != nil {
+		logger.Error("[Comic Processing] Failed to insert sections and jobs for comic ID=%d: %v", comicID, err)
+		s.updateComicStatus(comicID, "failed")
+		return fmt.Errorf("failed to create sections: %w", err)
 	}
+	logger.Info("[Comic Processing] Created %d sections and enqueued %d jobs for comic ID=%d", len(sections), jobCount, comicID)
 
-	comic.Status = "completed"
-	if err := s.comicRepo.Update(comic); err != nil {
+	if err := s.comicRepo.UpdateStatus(comicID, "completed"); err != nil {
 		logger.Error("[Comic Processing] Failed to update comic status: %v", err)
-	} else {
-		logger.Info("[Comic Processing] Comic ID=%d status updated to completed", comicID)
+		return fmt.Errorf("failed to update comic status: %w", err)
 	}
-
-	logger.Info("[Comic AI Processing] Starting AI processing for comic ID=%d", comicID)
-	go s.processComicAI(context.Background(), comicID)
+	logger.Info("[Comic Processing] Comic ID=%d status updated to completed", comicID)
 
 	return nil
 }
 
-func (s *ComicService) processComicAI(ctx context.Context, comicID uint) {
+// extractComicRoles 读取第一章生成分镜，从中提取角色并写入角色表。已有角色时直接跳过，便于任务重试。
+func (s *ComicService) extractComicRoles(ctx context.Context, comicID uint) error {
 	logger.Info("[Comic AI Processing] Loading comic data for ID=%d", comicID)
 	comic, err := s.comicRepo.FindByID(comicID)
 	if err != nil {
-		logger.Error("[Comic AI Processing] Failed to get comic %d: %v", comicID, err)
-		return
+		return fmt.Errorf("failed to get comic %d: %w", comicID, err)
+	}
+
+	if len(comic.Roles) > 0 {
+		logger.Info("[Comic AI Processing] Comic ID=%d already has %d roles, skipping extraction", comicID, len(comic.Roles))
+		return nil
 	}
 
 	logger.Info("[Comic AI Processing] Querying all sections from database for comic ID=%d", comicID)
 	sections, err := s.sectionRepo.FindByComicID(comicID)
 	if err != nil {
-		logger.Error("[Comic AI Processing] Failed to get sections for comic %d: %v", comicID, err)
-		return
+		return fmt.Errorf("failed to get sections for comic %d: %w", comicID, err)
 	}
 
 	if len(sections) == 0 {
-		logger.Error("[Comic AI Processing] No sections found for comic ID=%d", comicID)
-		return
+		return fmt.Errorf("no sections found for comic %d", comicID)
 	}
 	logger.Info("[Comic AI Processing] Found %d sections for comic ID=%d", len(sections), comicID)
 
 	logger.Info("[Comic AI Processing] Fetching available voice list for comic ID=%d", comicID)
 	voices, err := s.aigc.GetVoiceList(ctx)
 	if err != nil {
-		logger.Error("[Comic AI Processing] Failed to get voice list for comic %d: %v", comicID, err)
-		return
+		return fmt.Errorf("failed to get voice list for comic %d: %w", comicID, err)
 	}
 
 	voiceItems := make([]gnxaigc.TTSVoiceItem, 0, len(voices))
@@ -253,8 +385,7 @@ func (s *ComicService) processComicAI(ctx context.Context, comicID uint) {
 		MaxPanelsPerPage:     4,
 	})
 	if err != nil {
-		logger.Error("[Comic AI Processing] Failed to generate AI summary for comic %d: %v", comicID, err)
-		return
+		return fmt.Errorf("failed to generate AI summary for comic %d: %w", comicID, err)
 	}
 	logger.Info("[Comic AI Processing] AI summary generated: %d characters, %d pages", len(summary.CharacterFeatures), len(summary.StoryboardPages))
 
@@ -268,6 +399,8 @@ func (s *ComicService) processComicAI(ctx context.Context, comicID uint) {
 			Age:       charFeature.Basic.Age,
 			VoiceName: charFeature.TTS.VoiceName,
 			VoiceType: charFeature.TTS.VoiceType,
+
+			ConceptArtPrompt: strings.TrimSpace(charFeature.ConceptArtPrompt),
 		}
 
 		if err := s.roleRepo.Create(role); err != nil {
@@ -277,126 +410,144 @@ func (s *ComicService) processComicAI(ctx context.Context, comicID uint) {
 		}
 	}
 
-	logger.Info("[Comic Image Processing] Starting image generation for comic ID=%d", comicID)
-	s.processComicImages(ctx, comicID)
-
-	logger.Info("[Comic AI Processing] Serially processing %d sections for comic ID=%d", len(sections), comicID)
-	for i, section := range sections {
-		logger.Info("[Comic AI Processing] Processing section %d/%d: %s (ID=%d)", i+1, len(sections), section.Title, section.ID)
-		if err := s.processSectionSync(ctx, comic, &section); err != nil {
-			logger.Error("[Comic AI Processing] Failed to process section %d (%s): %v", i+1, section.Title, err)
-		} else {
-			logger.Info("[Comic AI Processing] Section %d/%d processed successfully: %s", i+1, len(sections), section.Title)
-		}
-	}
-
-	logger.Info("[Comic AI Processing] All sections processed for comic ID=%d", comicID)
+	s.events.Publish(comicID, ProgressEvent{Type: EventRolesReady, Message: fmt.Sprintf("%d roles extracted", len(summary.CharacterFeatures))})
+	return nil
 }
 
-func (s *ComicService) processComicImages(ctx context.Context, comicID uint) {
+// processComicImages 生成角色设定图、封面与背景图。已生成的图片会被跳过，
+// 任一图片失败时继续处理其余图片，最后返回汇总的错误，交由任务队列重试缺失的部分。
+func (s *ComicService) processComicImages(ctx context.Context, comicID uint) error {
 	logger.Info("[Comic Image Processing] Loading comic data for ID=%d", comicID)
 	comic, err := s.comicRepo.FindByID(comicID)
 	if err != nil {
-		logger.Error("[Comic Image Processing] Failed to get comic %d: %v", comicID, err)
-		return
+		return fmt.Errorf("failed to get comic %d: %w", comicID, err)
 	}
 
 	roles, err := s.roleRepo.FindByComicID(comicID)
 	if err != nil {
-		logger.Error("[Comic Image Processing] Failed to get roles for comic %d: %v", comicID, err)
-		return
+		return fmt.Errorf("failed to get roles for comic %d: %w", comicID, err)
 	}
 	logger.Info("[Comic Image Processing] Generating concept art for %d characters", len(roles))
 
+	var failures []error
 	for _, role := range roles {
 		if role.ImageID != "" {
 			logger.Info("[Comic Image Processing] Character %s already has image, skipping", role.Name)
 			continue
 		}
 
-		conceptArtPrompt := fmt.Sprintf("Character concept art for %s: %s", role.Name, role.Brief)
+		conceptArtPrompt := role.ConceptArtPrompt
+		if conceptArtPrompt == "" {
+			conceptArtPrompt = defaultConceptArtPrompt(&role)
+		}
 		logger.Info("[Comic Image Processing] Generating concept art for character: %s", role.Name)
-		imageData, err := s.aigc.GenerateImageByText(ctx, conceptArtPrompt)
+		imageData, err := s.generateImageByText(ctx, conceptArtPrompt)
 		if err != nil {
 			logger.Error("[Comic Image Processing] Failed to generate role image for %s: %v", role.Name, err)
+			failures = append(failures, fmt.Errorf("role %s image: %w", role.Name, err))
 			continue
 		}
 
 		imageID := uuid.New().String()
 		if err := s.storage.UploadBytes(imageData, imageID); err != nil {
 			logger.Error("[Comic Image Processing] Failed to upload role image for %s: %v", role.Name, err)
+			failures = append(failures, fmt.Errorf("role %s image upload: %w", role.Name, err))
 			continue
 		}
 
 		role.ImageID = imageID
 		if err := s.roleRepo.Update(&role); err != nil {
 			logger.Error("[Comic Image Processing] Failed to update role image ID for %s: %v", role.Name, err)
-		} else {
-			logger.Info("[Comic Image Processing] Character %s concept art uploaded: imageID=%s", role.Name, imageID)
+			failures = append(failures, fmt.Errorf("role %s image update: %w", role.Name, err))
+			continue
 		}
+		logger.Info("[Comic Image Processing] Character %s concept art uploaded: imageID=%s", role.Name, imageID)
+		s.events.Publish(comicID, ProgressEvent{Type: EventRolePortraitReady, RoleID: formatID(role.ID), ImageID: imageID, Message: role.Name})
 	}
 
+	if comic.IconImageID != "" && comic.BackgroundImageID != "" {
+		if err := errors.Join(failures...); err != nil {
+			return fmt.Errorf("failed to generate images for comic %d: %w", comicID, err)
+		}
+		logger.Info("[Comic Image Processing] Image processing completed for comic ID=%d", comicID)
+		return nil
+	}
+
+	if updated, err := s.roleRepo.FindByComicID(comicID); err == nil {
+		roles = updated
+	}
+	prompts := s.composeCoverPrompts(ctx, comic, roles)
+	references := s.collectCoverReferences(roles, prompts.Protagonists)
+
 	if comic.IconImageID == "" {
 		logger.Info("[Comic Image Processing] Generating cover image for comic ID=%d", comicID)
-		iconImageData, err := s.aigc.GenerateImageByText(ctx, fmt.Sprintf("Comic book cover for: %s, %s", comic.Title, comic.UserPrompt))
+		iconImageID, err := s.generateComicImage(ctx, prompts.CoverPrompt, references, "Cover")
 		if err == nil {
-			iconImageID := uuid.New().String()
-			if err := s.storage.UploadBytes(iconImageData, iconImageID); err != nil {
-				logger.Error("[Comic Image Processing] Failed to upload icon image: %v", err)
-			} else {
-				comic.IconImageID = iconImageID
-				s.comicRepo.Update(comic)
-				logger.Info("[Comic Image Processing] Cover image uploaded: imageID=%s", iconImageID)
-			}
-		} else {
+			comic.IconImageID = iconImageID
+			err = s.comicRepo.UpdateIconImageID(comicID, iconImageID)
+		}
+		if err != nil {
 			logger.Error("[Comic Image Processing] Failed to generate cover image: %v", err)
+			comic.IconImageID = ""
+			failures = append(failures, fmt.Errorf("cover image: %w", err))
+		} else {
+			logger.Info("[Comic Image Processing] Cover image uploaded: imageID=%s", iconImageID)
+			s.events.Publish(comicID, ProgressEvent{Type: EventCoverReady, ImageID: iconImageID})
 		}
 	}
 
 	if comic.BackgroundImageID == "" {
 		logger.Info("[Comic Image Processing] Generating background image for comic ID=%d", comicID)
-		bgImageData, err := s.aigc.GenerateImageByText(ctx, fmt.Sprintf("Comic background scene for: %s, %s", comic.Title, comic.UserPrompt))
+		bgImageID, err := s.generateComicImage(ctx, prompts.BackgroundPrompt, references, "Background")
 		if err == nil {
-			bgImageID := uuid.New().String()
-			if err := s.storage.UploadBytes(bgImageData, bgImageID); err != nil {
-				logger.Error("[Comic Image Processing] Failed to upload background image: %v", err)
-			} else {
-				comic.BackgroundImageID = bgImageID
-				s.comicRepo.Update(comic)
-				logger.Info("[Comic Image Processing] Background image uploaded: imageID=%s", bgImageID)
-			}
-		} else {
+			comic.BackgroundImageID = bgImageID
+			err = s.comicRepo.UpdateBackgroundImageID(comicID, bgImageID)
+		}
+		if err != nil {
 			logger.Error("[Comic Image Processing] Failed to generate background image: %v", err)
+			comic.BackgroundImageID = ""
+			failures = append(failures, fmt.Errorf("background image: %w", err))
+		} else {
+			logger.Info("[Comic Image Processing] Background image uploaded: imageID=%s", bgImageID)
+			s.events.Publish(comicID, ProgressEvent{Type: EventBackgroundReady, ImageID: bgImageID})
 		}
 	}
+
+	if err := errors.Join(failures...); err != nil {
+		return fmt.Errorf("failed to generate images for comic %d: %w", comicID, err)
+	}
 	logger.Info("[Comic Image Processing] Image processing completed for comic ID=%d", comicID)
+	return nil
 }
 
 func (s *ComicService) updateComicStatus(comicID uint, status string) {
-	comic, err := s.comicRepo.FindByID(comicID)
-	if err != nil {
-		return
+	if err := s.comicRepo.UpdateStatus(comicID, status); err != nil {
+		logger.Error("[Comic Processing] Failed to update status of comic ID=%d: %v", comicID, err)
 	}
-	comic.Status = status
-	s.comicRepo.Update(comic)
 }
 
-func (s *ComicService) CreateSection(ctx context.Context, comicID uint, title, content string) (*models.ComicSection, error) {
+// CreateSection 保存新章节并写入分镜任务后立即返回，分镜与页面图片由后台任务生成。
+// 分镜任务为 Ordered，排在漫画已有的分镜任务之后执行，保证剧情记忆按章节顺序滚动。
+func (s *ComicService) CreateSection(comicID uint, title, volume, content string) (*models.ComicSection, *models.ComicJob, error) {
 	logger.Info("[Section Creation] Starting section creation: comicID=%d, title=%s", comicID, title)
 	comic, err := s.comicRepo.FindByID(comicID)
 	if err != nil {
 		logger.Error("[Section Creation] Comic not found: comicID=%d, error=%v", comicID, err)
-		return nil, fmt.Errorf("comic not found: %w", err)
+		return nil, nil, fmt.Errorf("comic not found: %w", err)
+	}
+	if comic.GenerationState == models.GenerationStateCancelled {
+		return nil, nil, ErrGenerationCancelled
 	}
 
 	count, err := s.sectionRepo.CountByComicID(comicID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to count sections: %w", err)
+		return nil, nil, fmt.Errorf("failed to count sections: %w", err)
 	}
 
 	section := &models.ComicSection{
 		ComicID: comicID,
 		Title:   title,
+		Volume:  volume,
 		Index:   int(count) + 1,
 		Content: content,
 		Status:  "pending",
@@ -404,18 +555,19 @@ func (s *ComicService) CreateSection(ctx context.Context, comicID uint, title, c
 
 	if err := s.sectionRepo.Create(section); err != nil {
 		logger.Error("[Section Creation] Failed to create section: %v", err)
-		return nil, fmt.Errorf("failed to create section: %w", err)
+		return nil, nil, fmt.Errorf("failed to create section: %w", err)
 	}
 	logger.Info("[Section Creation] Section created: ID=%d, index=%d, status=pending", section.ID, section.Index)
 
-	logger.Info("[Section Processing] Starting AI processing for section ID=%d", section.ID)
-	if err := s.processSectionSync(ctx, comic, section); err != nil {
-		logger.Error("[Section Processing] Failed to process section: %v", err)
-		return nil, fmt.Errorf("failed to process section: %w", err)
+	job, err := s.enqueueJob(&models.ComicJob{ComicID: comicID, SectionID: &section.ID, Stage: models.JobStageStoryboard, Ordered: true})
+	if err != nil {
+		logger.Error("[Section Creation] Failed to enqueue storyboard for section ID=%d: %v", section.ID, err)
+		s.updateSectionStatus(section.ID, "failed")
+		return nil, nil, fmt.Errorf("failed to enqueue storyboard: %w", err)
 	}
 
-	logger.Info("[Section Creation] Section ID=%d created successfully", section.ID)
-	return section, nil
+	logger.Info("[Section Creation] Section ID=%d created, storyboard queued as job ID=%d", section.ID, job.ID)
+	return section, job, nil
 }
 
 func (s *ComicService) GetSectionDetail(comicID, sectionID uint) (*models.ComicSection, error) {
@@ -428,12 +580,22 @@ func (s *ComicService) GetSectionDetail(comicID, sectionID uint) (*models.ComicS
 		return nil, fmt.Errorf("section does not belong to comic")
 	}
 
+	jobs, err := s.jobRepo.FindBySectionID(sectionID)
+	if err != nil {
+		logger.Warn("[Section Detail] Failed to load jobs of section ID=%d: %v", sectionID, err)
+	}
+	section.Jobs = jobs
+	section.Ready = section.IsReady()
+
 	// 对 pages 按 index 排序
 	slices.SortFunc(section.Pages, func(a, b models.ComicPage) int {
 		return a.Index - b.Index
 	})
-	// 对每个 page 的 details 按 index 排序
+	// 对每个 page 的 panels 与 details 按 index 排序
 	for i := range section.Pages {
+		slices.SortFunc(section.Pages[i].Panels, func(a, b models.ComicPanel) int {
+			return a.Index - b.Index
+		})
 		slices.SortFunc(section.Pages[i].Details, func(a, b models.ComicPageDetail) int {
 			return a.Index - b.Index
 		})
@@ -441,12 +603,18 @@ func (s *ComicService) GetSectionDetail(comicID, sectionID uint) (*models.ComicS
 	return section, nil
 }
 
-func (s *ComicService) processSectionSync(ctx context.Context, comic *models.Comic, section *models.ComicSection) error {
+func (s *ComicService) processSectionSync(ctx context.Context, comic *models.Comic, section *models.ComicSection) (err error) {
+	defer func() {
+		if event, ok := storyboardResultEvent(ctx, section, err); ok {
+			s.events.Publish(comic.ID, event)
+		}
+	}()
+
 	logger.Info("[Section Processing] Loading character roles for section ID=%d", section.ID)
 	roles, err := s.roleRepo.FindByComicID(comic.ID)
 	if err != nil {
 		logger.Error("[Section Processing] Failed to get roles for section %d: %v", section.ID, err)
-		s.updateSectionStatus(section.ID, "failed")
+		s.failSection(ctx, section.ID)
 		return fmt.Errorf("failed to get roles for section %d: %w", section.ID, err)
 	}
 	logger.Info("[Section Processing] Loaded %d character roles", len(roles))
@@ -455,7 +623,7 @@ func (s *ComicService) processSectionSync(ctx context.Context, comic *models.Com
 	voices, err := s.aigc.GetVoiceList(ctx)
 	if err != nil {
 		logger.Error("[Section Processing] Failed to get voice list: %v", err)
-		s.updateSectionStatus(section.ID, "failed")
+		s.failSection(ctx, section.ID)
 		return fmt.Errorf("failed to get voice list for section %d: %w", section.ID, err)
 	}
 
@@ -467,22 +635,9 @@ func (s *ComicService) processSectionSync(ctx context.Context, comic *models.Com
 		})
 	}
 
-	charFeatures := make([]gnxaigc.CharacterFeature, 0, len(roles))
-	for _, role := range roles {
-		charFeatures = append(charFeatures, gnxaigc.CharacterFeature{
-			Basic: gnxaigc.CharacterBasicProfile{
-				Name:   role.Name,
-				Gender: role.Gender,
-				Age:    role.Age,
-			},
-			TTS: gnxaigc.CharacterTTSProfile{
-				VoiceName:  role.VoiceName,
-				VoiceType:  role.VoiceType,
-				SpeedRatio: 1.0,
-			},
-			Comment: role.Brief,
-		})
-	}
+	charFeatures := characterFeaturesFromRoles(roles)
+
+	storyMemory := s.loadStoryMemory(comic.ID, section.Index)
 
 	logger.Info("[Section Processing] Generating AI summary for section ID=%d", section.ID)
 	summary, err := s.aigc.SummaryChapter(ctx, gnxaigc.SummaryChapterInput{
@@ -492,142 +647,106 @@ func (s *ComicService) processSectionSync(ctx context.Context, comic *models.Com
 		AvailableVoiceStyles: voiceItems,
 		CharacterFeatures:    charFeatures,
 		MaxPanelsPerPage:     4,
+		StoryMemory:          storyMemory,
+		ExtraInstruction:     section.StoryboardInstruction,
 	})
 	if err != nil {
 		logger.Error("[Section Processing] Failed to generate AI summary: %v", err)
-		s.updateSectionStatus(section.ID, "failed")
+		s.failSection(ctx, section.ID)
 		return fmt.Errorf("failed to generate summary for section %d: %w", section.ID, err)
 	}
 	logger.Info("[Section Processing] AI summary generated: %d storyboard pages", len(summary.StoryboardPages))
 
 	logger.Info("[Section Processing] Creating %d pages for section ID=%d", len(summary.StoryboardPages), section.ID)
 	for pageIndex, storyboardPage := range summary.StoryboardPages {
-		page := &models.ComicPage{
-			SectionID:   section.ID,
-			Index:       pageIndex + 1,
-			ImagePrompt: storyboardPage.ImagePrompt,
+		if err := s.saveStoryboardPage(section.ID, pageIndex+1, storyboardPage, roles); err != nil {
+			logger.Error("[Section Processing] Failed to save page %d: %v", pageIndex+1, err)
 		}
+	}
 
-		if err := s.pageRepo.Create(page); err != nil {
-			logger.Error("[Section Processing] Failed to create page %d: %v", pageIndex+1, err)
-			continue
-		}
-		logger.Info("[Section Processing] Created page %d (ID=%d) with %d panels", pageIndex+1, page.ID, len(storyboardPage.Panels))
+	s.saveConceptArtPrompts(roles, summary.CharacterFeatures)
 
-		for panelIndex, panel := range storyboardPage.Panels {
-			for segmentIndex, segment := range panel.SourceTextSegments {
-				var roleID *uint
-				if len(segment.CharacterNames) > 0 {
-					for _, role := range roles {
-						if role.Name == segment.CharacterNames[0] {
-							roleID = &role.ID
-							break
-						}
-					}
-				}
-
-				detail := &models.ComicPageDetail{
-					PageID:  page.ID,
-					Index:   (panelIndex * 100) + segmentIndex,
-					Content: segment.Text,
-					RoleID:  roleID,
-				}
+	s.updateStoryMemory(ctx, comic, section, storyMemory)
 
-				if err := s.pageRepo.CreateDetail(detail); err != nil {
-					logger.Error("[Section Processing] Failed to create page detail: %v", err)
-				}
-			}
-		}
+	logger.Info("[Section Image Processing] Enqueuing image generation for section ID=%d", section.ID)
+	if _, err := s.enqueueJob(&models.ComicJob{ComicID: comic.ID, SectionID: &section.ID, Stage: models.JobStagePageImage}); err != nil {
+		s.failSection(ctx, section.ID)
+		return fmt.Errorf("failed to enqueue image generation for section %d: %w", section.ID, err)
 	}
 
 	s.updateSectionStatus(section.ID, "completed")
 	logger.Info("[Section Processing] Section ID=%d marked as completed", section.ID)
 
-	logger.Info("[Section Image Processing] Starting image generation for section ID=%d", section.ID)
-	go s.processSectionImages(context.Background(), comic, section.ID)
+	logger.Info("[Section Processing] Enqueuing audio synthesis for section ID=%d", section.ID)
+	s.enqueueSectionAudio(comic.ID, section)
 
 	return nil
 }
 
-func (s *ComicService) processSectionImages(ctx context.Context, comic *models.Comic, sectionID uint) {
-	logger.Info("[Section Image Processing] Loading section data for ID=%d", sectionID)
-	section, err := s.sectionRepo.FindByID(sectionID)
+// storyboardResultEvent 生成分镜任务结束时推送的事件。漫画被暂停或取消导致的中断不推送事件。
+func storyboardResultEvent(ctx context.Context, section *models.ComicSection, err error) (ProgressEvent, bool) {
+	event := ProgressEvent{Type: EventStoryboardReady, SectionID: formatID(section.ID), SectionIndex: section.Index}
 	if err != nil {
-		logger.Error("[Section Image Processing] Failed to get section %d: %v", sectionID, err)
-		return
+		if jobStopped(ctx) {
+			return event, false
+		}
+		event.Type, event.Error = EventStoryboardFailed, err.Error()
 	}
+	return event, true
+}
 
-	roles, err := s.roleRepo.FindByComicID(comic.ID)
-	if err != nil {
-		logger.Error("[Section Image Processing] Failed to get roles: %v", err)
+// failSection 将章节标记为生成失败。漫画被暂停或取消导致的中断保持章节原状态，恢复生成后重新处理。
+func (s *ComicService) failSection(ctx context.Context, sectionID uint) {
+	if jobStopped(ctx) {
+		logger.Info("[Section Processing] Section ID=%d: Generation stopped, leaving status unchanged", sectionID)
 		return
 	}
+	s.updateSectionStatus(sectionID, "failed")
+}
 
-	voices, err := s.aigc.GetVoiceList(ctx)
+// processSectionImages 按数据库中保存的分镜记录渲染章节内全部页面的图片。
+func (s *ComicService) processSectionImages(ctx context.Context, comic *models.Comic, sectionID uint) error {
+	logger.Info("[Section Image Processing] Loading section data for ID=%d", sectionID)
+	section, err := s.sectionRepo.FindByID(sectionID)
 	if err != nil {
-		logger.Error("[Section Image Processing] Failed to get voice list: %v", err)
-		return
+		return fmt.Errorf("failed to get section %d: %w", sectionID, err)
 	}
 
-	voiceItems := make([]gnxaigc.TTSVoiceItem, 0, len(voices))
-	for _, v := range voices {
-		voiceItems = append(voiceItems, gnxaigc.TTSVoiceItem{
-			VoiceName: v.VoiceName,
-			VoiceType: v.VoiceType,
-		})
+	roles, err := s.roleRepo.FindByComicID(comic.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get roles: %w", err)
 	}
 
-	charFeatures := make([]gnxaigc.CharacterFeature, 0, len(roles))
-	for _, role := range roles {
-		charFeatures = append(charFeatures, gnxaigc.CharacterFeature{
-			Basic: gnxaigc.CharacterBasicProfile{
-				Name:   role.Name,
-				Gender: role.Gender,
-				Age:    role.Age,
-			},
-			TTS: gnxaigc.CharacterTTSProfile{
-				VoiceName:  role.VoiceName,
-				VoiceType:  role.VoiceType,
-				SpeedRatio: 1.0,
-			},
-			Comment: role.Brief,
-		})
+	pages, err := s.pageRepo.FindBySectionID(sectionID)
+	if err != nil {
+		return fmt.Errorf("failed to get pages: %w", err)
 	}
 
-	summary, err := s.aigc.SummaryChapter(ctx, gnxaigc.SummaryChapterInput{
-		NovelTitle:           comic.Title,
-		ChapterTitle:         section.Title,
-		Content:              section.Content,
-		AvailableVoiceStyles: voiceItems,
-		CharacterFeatures:    charFeatures,
-		MaxPanelsPerPage:     4,
-	})
-	if err != nil {
-		logger.Error("[Section Image Processing] Failed to generate summary: %v", err)
-		return
+	storyboardPages := make([]gnxaigc.StoryboardPage, len(pages))
+	for i := range pages {
+		storyboardPages[i] = storyboardPageFromRecords(&pages[i], roles)
 	}
+	features := characterFeaturesFromRoles(roles)
 
 	logger.Info("[Section Image Processing] Syncing character assets for section ID=%d", sectionID)
-	characterAssets, err := s.SyncCharacterAssets(ctx, comic.ID, comic.UserPrompt, summary.CharacterFeatures)
+	characterAssets, err := s.SyncCharacterAssets(ctx, comic.ID, comic.UserPrompt, appearingFeatures(storyboardPages, features))
 	if err != nil {
 		logger.Error("[Section Image Processing] Failed to sync character assets: %v", err)
 		characterAssets = make(map[string]*CharacterAsset)
 	}
 
-	pages, err := s.pageRepo.FindBySectionID(sectionID)
-	if err != nil {
-		logger.Error("[Section Image Processing] Failed to get pages: %v", err)
-		return
-	}
-	logger.Info("[Section Image Processing] Starting parallel image generation for %d pages", len(pages))
-
-	var wg sync.WaitGroup
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Int32
+	)
 
-	totalPages := len(summary.StoryboardPages)
+	totalPages := len(pages)
 	logger.Info("[Section Image Processing] Processing %d pages in parallel", totalPages)
-	for pageIndex, storyboardPage := range summary.StoryboardPages {
-		if pageIndex >= len(pages) {
-			break
+	for pageIndex := range pages {
+		// 任务重试时跳过已经生成成功的页面
+		if pages[pageIndex].ImageStatus == models.PageImageStatusCompleted {
+			logger.Info("[Section Image Processing] Page %d/%d: Image already generated, skipping", pageIndex+1, totalPages)
+			continue
 		}
 
 		wg.Add(1)
@@ -636,64 +755,34 @@ func (s *ComicService) processSectionImages(ctx context.Context, comic *models.C
 
 			logger.Info("[Section Image Processing] Page %d/%d: Generating image", pageIndex+1, totalPages)
 
-			fullPrompt := gnxaigc.ComposePageImagePrompt(comic.UserPrompt, storyboardPage)
-
-			referenceKeys := s.collectPageCharacterKeys(storyboardPage, summary.CharacterFeatures)
-			var referenceImages [][]byte
-			for _, key := range referenceKeys {
-				asset := characterAssets[key]
-				if asset == nil || len(asset.ImageData) == 0 {
-					continue
-				}
-				referenceImages = append(referenceImages, asset.ImageData)
+			label := fmt.Sprintf("Page %d/%d", pageIndex+1, totalPages)
+			if err := s.pageRepo.MarkImageGenerating(page.ID); err != nil {
+				logger.Warn("[Section Image Processing] %s: Failed to mark image generating: %v", label, err)
 			}
 
-			var (
-				imageData []byte
-				err       error
-			)
-
-			switch len(referenceImages) {
-			case 0:
-				imageData, err = s.aigc.GenerateImageByText(ctx, fullPrompt)
-			case 1:
-				logger.Info("[Section Image Processing] Page %d/%d: Using single reference image", pageIndex+1, totalPages)
-				imageData, err = s.aigc.GenerateImageByImage(ctx, referenceImages[0], fullPrompt)
-				if err != nil {
-					logger.Warn("[Section Image Processing] Page %d/%d: img2img failed (%v), falling back to text-to-image", pageIndex+1, totalPages, err)
-					imageData, err = s.aigc.GenerateImageByText(ctx, fullPrompt)
-				}
-			default:
-				composite, mergeErr := imageutil.MergeImagesSideBySide(referenceImages)
-				if mergeErr != nil {
-					logger.Warn("[Section Image Processing] Page %d/%d: Failed to merge %d reference images (%v), using text-to-image", pageIndex+1, totalPages, len(referenceImages), mergeErr)
-					imageData, err = s.aigc.GenerateImageByText(ctx, fullPrompt)
-					break
-				}
-
-				logger.Info("[Section Image Processing] Page %d/%d: Using %d merged reference images", pageIndex+1, totalPages, len(referenceImages))
-				imageData, err = s.aigc.GenerateImageByImage(ctx, composite, fullPrompt)
-				if err != nil {
-					logger.Warn("[Section Image Processing] Page %d/%d: merged img2img failed (%v), falling back to text-to-image", pageIndex+1, totalPages, err)
-					imageData, err = s.aigc.GenerateImageByText(ctx, fullPrompt)
-				}
-			}
-
-			if err != nil {
+			imageData, rects, err := s.renderPageImageWithQA(ctx, comic, page.ID, storyboardPage, features, characterAssets, label)
+			if err != nil && jobStopped(ctx) {
+				s.stopPageImage(page.ID, label)
+			} else if err != nil {
 				logger.Error("[Section Image Processing] Page %d/%d: Failed to generate image: %v", pageIndex+1, totalPages, err)
-			} else {
-				imageID := fmt.Sprintf("%d", page.ID)
-				if err := s.storage.UploadBytes(imageData, imageID); err != nil {
-					logger.Error("[Section Image Processing] Page %d/%d: Failed to upload image: %v", pageIndex+1, totalPages, err)
-				} else {
-					logger.Info("[Section Image Processing] Page %d/%d: Image uploaded successfully (imageID=%s)", pageIndex+1, totalPages, imageID)
-				}
+				failed.Add(1)
+				s.failPageImage(comic.ID, section, page.ID, err, label)
+			} else if err := s.storePageImage(comic.ID, section, page.ID, imageData, storyboardPage, rects, label); err != nil {
+				failed.Add(1)
 			}
-		}(pageIndex, pages[pageIndex], storyboardPage)
+		}(pageIndex, pages[pageIndex], storyboardPages[pageIndex])
 	}
 
 	wg.Wait()
+	if jobStopped(ctx) {
+		return context.Cause(ctx)
+	}
+	if n := failed.Load(); n > 0 {
+		return fmt.Errorf("%d of %d pages failed to render", n, totalPages)
+	}
 	logger.Info("[Section Image Processing] Completed image generation for section ID=%d", sectionID)
+	s.events.Publish(comic.ID, ProgressEvent{Type: EventSectionImagesReady, SectionID: formatID(sectionID), SectionIndex: section.Index})
+	return nil
 }
 
 func (s *ComicService) collectPageCharacterKeys(page gnxaigc.StoryboardPage, features []gnxaigc.CharacterFeature) []string {
@@ -791,17 +880,17 @@ func (s *ComicService) SyncCharacterAssets(
 				baseData, err := s.storage.DownloadBytes(role.ImageID)
 				if err != nil {
 					logger.Warn("[Character Assets] Character %s: cannot read existing concept art (%v), using text generation", name, err)
-					imageData, err = s.aigc.GenerateImageByText(ctx, fullPrompt)
+					imageData, err = s.generateImageByText(ctx, fullPrompt)
 					if err != nil {
 						logger.Error("[Character Assets] Character %s: failed to generate concept art: %v", name, err)
 						continue
 					}
 				} else {
 					logger.Info("[Character Assets] Character %s: Refining concept art via img2img", name)
-					imageData, err = s.aigc.GenerateImageByImage(ctx, baseData, fullPrompt)
+					imageData, err = s.generateImageByImage(ctx, baseData, fullPrompt)
 					if err != nil {
 						logger.Warn("[Character Assets] Character %s: img2img refinement failed (%v), falling back to text generation", name, err)
-						imageData, err = s.aigc.GenerateImageByText(ctx, fullPrompt)
+						imageData, err = s.generateImageByText(ctx, fullPrompt)
 						if err != nil {
 							logger.Error("[Character Assets] Character %s: failed to generate concept art: %v", name, err)
 							continue
@@ -810,7 +899,7 @@ func (s *ComicService) SyncCharacterAssets(
 				}
 			} else {
 				logger.Info("[Character Assets] Character %s: Generating concept art from scratch", name)
-				imageData, err = s.aigc.GenerateImageByText(ctx, fullPrompt)
+				imageData, err = s.generateImageByText(ctx, fullPrompt)
 				if err != nil {
 					logger.Error("[Character Assets] Character %s: failed to generate concept art: %v", name, err)
 					continue
@@ -828,6 +917,7 @@ func (s *ComicService) SyncCharacterAssets(
 				logger.Error("[Character Assets] Character %s: failed to update role with imageID: %v", name, err)
 			} else {
 				logger.Info("[Character Assets] Character %s: Concept art uploaded (imageID=%s)", name, imageID)
+				s.events.Publish(comicID, ProgressEvent{Type: EventRolePortraitReady, RoleID: formatID(role.ID), ImageID: imageID, Message: name})
 			}
 		} else {
 			logger.Info("[Character Assets] Character %s: Reusing existing concept art", name)
@@ -838,6 +928,10 @@ func (s *ComicService) SyncCharacterAssets(
 			ImageData: imageData,
 			Prompt:    fullPrompt,
 		}
+
+		if s.cfg.CharacterSheetsEnabled {
+			s.syncCharacterSheet(ctx, role, assets[name], trimmedStyle, shouldGenerate)
+		}
 	}
 
 	return assets, nil
@@ -868,6 +962,10 @@ func (s *ComicService) LoadCharacterAssets(
 		assets[role.Name] = &CharacterAsset{
 			ImageData: imageData,
 		}
+
+		if s.cfg.CharacterSheetsEnabled {
+			s.loadCharacterSheet(role, assets[role.Name])
+		}
 	}
 
 	return assets, nil
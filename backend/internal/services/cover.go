@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cohesion-dev/GNX/ai/gnxaigc"
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/imageutil"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// coverSourceChapters 封面构思读取的开篇章节数
+	coverSourceChapters = 3
+	// coverMaxReferences 封面与背景图最多参考的角色原画数
+	coverMaxReferences = 3
+)
+
+// composeCoverPrompts 让语言模型根据开篇章节与角色列表撰写封面与背景图提示词，失败时退回到仅用标题与画风拼接的提示词。
+func (s *ComicService) composeCoverPrompts(ctx context.Context, comic *models.Comic, roles []models.ComicRole) *gnxaigc.CoverPrompts {
+	fallback := &gnxaigc.CoverPrompts{
+		CoverPrompt:      fmt.Sprintf("Comic book cover for: %s, %s", comic.Title, comic.UserPrompt),
+		BackgroundPrompt: fmt.Sprintf("Comic background scene for: %s, %s", comic.Title, comic.UserPrompt),
+	}
+
+	sections, err := s.sectionRepo.FindByComicID(comic.ID)
+	if err != nil {
+		logger.Warn("[Comic Image Processing] Failed to load sections for cover prompts (%v), using default prompts", err)
+		return fallback
+	}
+
+	chapters := make([]gnxaigc.CoverChapter, 0, coverSourceChapters)
+	for _, section := range sections[:min(len(sections), coverSourceChapters)] {
+		chapters = append(chapters, gnxaigc.CoverChapter{Title: section.Title, Content: section.Content})
+	}
+
+	characters := make([]gnxaigc.CoverCharacter, 0, len(roles))
+	for _, role := range roles {
+		characters = append(characters, gnxaigc.CoverCharacter{
+			Name:   role.Name,
+			Gender: role.Gender,
+			Age:    role.Age,
+			Brief:  role.Brief,
+		})
+	}
+
+	prompts, err := s.aigc.GenerateCoverPrompts(ctx, gnxaigc.GenerateCoverPromptsInput{
+		NovelTitle:  comic.Title,
+		StylePrompt: comic.UserPrompt,
+		Chapters:    chapters,
+		Characters:  characters,
+	})
+	if err != nil {
+		logger.Warn("[Comic Image Processing] Failed to generate cover prompts (%v), using default prompts", err)
+		return fallback
+	}
+
+	prompts.CoverPrompt = fmt.Sprintf("%s %s", comic.UserPrompt, prompts.CoverPrompt)
+	prompts.BackgroundPrompt = fmt.Sprintf("%s %s", comic.UserPrompt, prompts.BackgroundPrompt)
+	logger.Info("[Comic Image Processing] Cover prompts generated, protagonists=%v", prompts.Protagonists)
+	return prompts
+}
+
+// collectCoverReferences 下载主角的角色原画作为封面参考图，模型未指明主角时按角色顺序选取。
+func (s *ComicService) collectCoverReferences(roles []models.ComicRole, protagonists []string) []imageutil.ReferenceTile {
+	var references []imageutil.ReferenceTile
+	for _, role := range roles {
+		if len(references) >= coverMaxReferences {
+			break
+		}
+		if role.ImageID == "" || (len(protagonists) > 0 && !slices.Contains(protagonists, role.Name)) {
+			continue
+		}
+
+		imageData, err := s.storage.DownloadBytes(role.ImageID)
+		if err != nil {
+			logger.Warn("[Comic Image Processing] Failed to download concept art for %s: %v", role.Name, err)
+			continue
+		}
+		references = append(references, imageutil.ReferenceTile{Label: role.Name, Image: imageData})
+	}
+	return references
+}
+
+// generateComicImage 按提示词与参考图生成封面或背景图并上传，返回图片 ID。
+func (s *ComicService) generateComicImage(ctx context.Context, prompt string, references []imageutil.ReferenceTile, label string) (string, error) {
+	imageData, err := s.generateImageWithReferences(ctx, prompt, references, label)
+	if err != nil {
+		return "", err
+	}
+	imageID := uuid.New().String()
+	if err := s.storage.UploadBytes(imageData, imageID); err != nil {
+		return "", fmt.Errorf("failed to upload %s image: %w", strings.ToLower(label), err)
+	}
+	return imageID, nil
+}
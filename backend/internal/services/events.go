@@ -0,0 +1,172 @@
+package services
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"github.com/cohesion-dev/GNX/backend_new/internal/repositories"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/logger"
+)
+
+// 推送给前端的生成进度事件类型
+const (
+	EventSnapshot           = "snapshot"
+	EventJobCompleted       = "job_completed"
+	EventJobRetrying        = "job_retrying"
+	EventJobFailed          = "job_failed"
+	EventRolesReady         = "roles_ready"
+	EventRolePortraitReady  = "role_portrait_ready"
+	EventCoverReady         = "cover_ready"
+	EventBackgroundReady    = "background_ready"
+	EventStoryboardReady    = "storyboard_ready"
+	EventStoryboardFailed   = "storyboard_failed"
+	EventPageImageUploaded  = "page_image_uploaded"
+	EventPageImageFailed    = "page_image_failed"
+	EventSectionImagesReady = "section_images_ready"
+	EventSectionAudioReady  = "section_audio_ready"
+	EventGenerationPaused   = "generation_paused"
+	EventGenerationResumed  = "generation_resumed"
+	EventGenerationCanceled = "generation_cancelled"
+)
+
+const eventSubscriberBuffer = 64
+
+// ProgressEvent 单条生成进度事件，ID 字段与其他接口一致使用字符串形式。
+type ProgressEvent struct {
+	Type         string `json:"type"`
+	ComicID      string `json:"comic_id"`
+	SectionID    string `json:"section_id,omitempty"`
+	SectionIndex int    `json:"section_index,omitempty"`
+	PageID       string `json:"page_id,omitempty"`
+	RoleID       string `json:"role_id,omitempty"`
+	JobID        string `json:"job_id,omitempty"`
+	Stage        string `json:"stage,omitempty"`
+	ImageID      string `json:"image_id,omitempty"`
+	Message      string `json:"message,omitempty"`
+	Error        string `json:"error,omitempty"`
+	Status       string `json:"status,omitempty"`
+	// GenerationState 漫画的生成控制状态：running、paused 或 cancelled
+	GenerationState string       `json:"generation_state,omitempty"`
+	Progress        *JobProgress `json:"progress,omitempty"`
+	Time            time.Time    `json:"time"`
+}
+
+// JobProgress 漫画生成任务的完成情况，用于前端绘制进度条。
+type JobProgress struct {
+	Total     int64 `json:"total"`
+	Completed int64 `json:"completed"`
+	Failed    int64 `json:"failed"`
+	Running   int64 `json:"running"`
+}
+
+// EventBroker 进程内的事件分发器，按漫画分组推送给订阅者。订阅者消费过慢时丢弃事件，不阻塞生成流程。
+type EventBroker struct {
+	mu          sync.RWMutex
+	subscribers map[uint]map[chan ProgressEvent]struct{}
+}
+
+func NewEventBroker() *EventBroker {
+	return &EventBroker{subscribers: make(map[uint]map[chan ProgressEvent]struct{})}
+}
+
+// Subscribe 订阅漫画的进度事件，返回的函数用于取消订阅并关闭通道。
+func (b *EventBroker) Subscribe(comicID uint) (<-chan ProgressEvent, func()) {
+	ch := make(chan ProgressEvent, eventSubscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[comicID] == nil {
+		b.subscribers[comicID] = make(map[chan ProgressEvent]struct{})
+	}
+	b.subscribers[comicID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[comicID][ch]; !ok {
+			return
+		}
+		delete(b.subscribers[comicID], ch)
+		if len(b.subscribers[comicID]) == 0 {
+			delete(b.subscribers, comicID)
+		}
+		close(ch)
+	}
+}
+
+// Close 关闭所有订阅通道，使进度推送连接在服务退出时结束。
+func (b *EventBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for comicID, subscribers := range b.subscribers {
+		for ch := range subscribers {
+			close(ch)
+		}
+		delete(b.subscribers, comicID)
+	}
+}
+
+// Publish 向漫画的所有订阅者推送事件。
+func (b *EventBroker) Publish(comicID uint, event ProgressEvent) {
+	event.ComicID = formatID(comicID)
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subscribers[comicID] {
+		select {
+		case ch <- event:
+		default:
+			logger.Warn("[Events] Subscriber of comic ID=%d is too slow, dropping %s event", comicID, event.Type)
+		}
+	}
+}
+
+// loadJobProgress 根据任务表统计漫画的生成进度。
+func loadJobProgress(jobRepo *repositories.JobRepository, comicID uint) *JobProgress {
+	counts, err := jobRepo.CountByStatus(comicID)
+	if err != nil {
+		logger.Warn("[Events] Failed to count jobs for comic ID=%d: %v", comicID, err)
+		return nil
+	}
+
+	progress := &JobProgress{
+		Completed: counts[models.JobStatusCompleted],
+		Failed:    counts[models.JobStatusFailed],
+		Running:   counts[models.JobStatusRunning],
+	}
+	for _, n := range counts {
+		progress.Total += n
+	}
+	return progress
+}
+
+func formatID(id uint) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// SubscribeEvents 订阅漫画的生成进度，返回包含当前状态的快照事件、事件通道与取消订阅函数。
+func (s *ComicService) SubscribeEvents(comicID uint) (*ProgressEvent, <-chan ProgressEvent, func(), error) {
+	comic, err := s.comicRepo.FindByID(comicID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	events, unsubscribe := s.events.Subscribe(comicID)
+	snapshot := &ProgressEvent{
+		Type:            EventSnapshot,
+		ComicID:         formatID(comicID),
+		Status:          comic.Status,
+		GenerationState: comic.GenerationState,
+		Progress:        loadJobProgress(s.jobRepo, comicID),
+		Time:            time.Now(),
+	}
+	return snapshot, events, unsubscribe, nil
+}
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cohesion-dev/GNX/backend_new/config"
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"github.com/cohesion-dev/GNX/backend_new/internal/repositories"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// 任务 context 的取消原因：errJobStopped 表示漫画被暂停或取消，errJobLeaseLost 表示租约已被改写或接管。
+// 两种情况下任务状态都已不归本进程管理，执行结束后不再更新任务表。
+var (
+	errJobStopped   = errors.New("comic generation stopped")
+	errJobLeaseLost = errors.New("job lease lost")
+)
+
+// jobStopped 判断任务是否因漫画被暂停或取消而中断，此时处理函数不应把业务状态标记为失败或推送失败事件，
+// 恢复生成后任务会重新执行。
+func jobStopped(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errJobStopped)
+}
+
+const (
+	jobRetryBaseDelay = 10 * time.Second
+	jobRetryMaxDelay  = 10 * time.Minute
+)
+
+// JobHandler 执行单个任务，返回错误时任务按退避策略重试，直到达到最大执行次数。
+type JobHandler func(ctx context.Context, job *models.ComicJob) error
+
+// JobRunner 从任务表中领取任务并分发给对应阶段的处理函数。
+type JobRunner struct {
+	jobRepo  *repositories.JobRepository
+	events   *EventBroker
+	cfg      *config.JobConfig
+	handlers map[string]JobHandler
+	owner    string
+	wg       sync.WaitGroup
+
+	// inflight 按漫画记录执行中任务的取消函数，用于暂停或取消时中断 AI 调用
+	mu       sync.Mutex
+	inflight map[uint]map[uint]context.CancelCauseFunc
+	// running 按阶段统计本进程执行中的任务数，claimMu 保证领取与计数之间不会超出阶段上限
+	running map[string]int
+	claimMu sync.Mutex
+}
+
+func NewJobRunner(jobRepo *repositories.JobRepository, events *EventBroker, cfg *config.JobConfig) *JobRunner {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "localhost"
+	}
+	return &JobRunner{
+		jobRepo:  jobRepo,
+		events:   events,
+		cfg:      cfg,
+		handlers: make(map[string]JobHandler),
+		inflight: make(map[uint]map[uint]context.CancelCauseFunc),
+		running:  make(map[string]int),
+		owner:    fmt.Sprintf("%s:%s", host, uuid.New().String()),
+	}
+}
+
+// Register 注册某个阶段的处理函数，必须在 Start 之前调用。未注册处理函数的阶段不会被领取。
+func (r *JobRunner) Register(stage string, handler JobHandler) {
+	r.handlers[stage] = handler
+}
+
+// Start 启动 Workers 个工作协程，ctx 结束后停止领取新任务，并交还被中断的任务。
+// 异常退出的进程遗留的任务在租约过期后由 Claim 重新领取。
+func (r *JobRunner) Start(ctx context.Context) {
+	workers := max(1, r.cfg.Workers)
+	logger.Info("[Job Runner] Starting %d workers (owner=%s, stage limits=%v)", workers, r.owner, r.cfg.StageWorkers)
+	for i := 0; i < workers; i++ {
+		r.wg.Add(1)
+		go r.work(ctx)
+	}
+}
+
+// Wait 等待所有工作协程退出，执行中的任务在此期间处理完中断并交还任务表。
+func (r *JobRunner) Wait() {
+	r.wg.Wait()
+}
+
+// availableStages 返回已注册且执行中任务数未达到 StageWorkers 上限的阶段，调用方需持有 mu。
+// 设有上限的阶段合计最多占用 Workers-1 个工作协程，至少留一个给未设上限的阶段（如分镜），
+// 各阶段上限之和不小于 Workers 时也不会把分镜饿死。
+func (r *JobRunner) availableStages() []string {
+	cappedRunning, hasUncapped := 0, false
+	for stage := range r.handlers {
+		if _, ok := r.cfg.StageWorkers[stage]; ok {
+			cappedRunning += r.running[stage]
+		} else {
+			hasUncapped = true
+		}
+	}
+	workers := max(1, r.cfg.Workers)
+	reserved := hasUncapped && workers > 1 && cappedRunning >= workers-1
+
+	stages := make([]string, 0, len(r.handlers))
+	for stage := range r.handlers {
+		if limit, ok := r.cfg.StageWorkers[stage]; ok && (reserved || r.running[stage] >= limit) {
+			continue
+		}
+		stages = append(stages, stage)
+	}
+	return stages
+}
+
+// claim 在阶段上限内领取一个任务并计入执行中任务数，所有阶段都已达到上限时返回 nil。
+func (r *JobRunner) claim() (*models.ComicJob, error) {
+	r.claimMu.Lock()
+	defer r.claimMu.Unlock()
+
+	r.mu.Lock()
+	stages := r.availableStages()
+	r.mu.Unlock()
+	if len(stages) == 0 {
+		return nil, nil
+	}
+
+	job, err := r.jobRepo.Claim(stages, r.owner, r.cfg.Lease)
+	if err != nil || job == nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.running[job.Stage]++
+	r.mu.Unlock()
+	return job, nil
+}
+
+func (r *JobRunner) release(job *models.ComicJob) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.running[job.Stage]--
+}
+
+func (r *JobRunner) work(ctx context.Context) {
+	defer r.wg.Done()
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, err := r.claim()
+		if err != nil {
+			logger.Error("[Job Runner] Failed to claim job: %v", err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(r.cfg.PollInterval):
+			}
+			continue
+		}
+
+		r.run(ctx, job)
+		r.release(job)
+	}
+}
+
+func (r *JobRunner) run(ctx context.Context, job *models.ComicJob) {
+	label := fmt.Sprintf("job ID=%d stage=%s comic=%d attempt=%d/%d", job.ID, job.Stage, job.ComicID, job.Attempts, job.MaxAttempts)
+
+	// 租约过期后被重新领取的任务同样计入执行次数，超过上限时直接判定失败
+	if job.Attempts > job.MaxAttempts {
+		logger.Error("[Job Runner] %s: exceeded max attempts", label)
+		lastError := fmt.Sprintf("exceeded max attempts; last error: %s", job.LastError)
+		if err := r.jobRepo.Fail(job.ID, r.owner, lastError); err != nil {
+			logger.Error("[Job Runner] %s: failed to mark job failed: %v", label, err)
+		}
+		r.publish(job, EventJobFailed, lastError)
+		return
+	}
+
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	r.track(job, cancel)
+	defer r.untrack(job)
+
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		r.heartbeat(jobCtx, cancel, job, label)
+	}()
+
+	logger.Info("[Job Runner] %s: started", label)
+	err := r.handlers[job.Stage](jobCtx, job)
+	cause := context.Cause(jobCtx)
+	cancel(nil)
+	<-heartbeatDone
+
+	switch jobOutcomeOf(job, err, cause, ctx.Err() != nil) {
+	case jobOutcomeUntouched:
+		logger.Warn("[Job Runner] %s: %v, leaving job state untouched", label, cause)
+	case jobOutcomeCompleted:
+		if err := r.jobRepo.Complete(job.ID, r.owner); err != nil {
+			logger.Error("[Job Runner] %s: failed to mark job completed: %v", label, err)
+			return
+		}
+		logger.Info("[Job Runner] %s: completed", label)
+		r.publish(job, EventJobCompleted, "")
+	case jobOutcomeReleased:
+		// 进程退出导致的中断不计入重试，立即交还给其他进程或下次启动时领取
+		logger.Warn("[Job Runner] %s: interrupted by shutdown, releasing", label)
+		if err := r.jobRepo.Release(job.ID, r.owner); err != nil {
+			logger.Error("[Job Runner] %s: failed to release job: %v", label, err)
+		}
+	case jobOutcomeFailed:
+		logger.Error("[Job Runner] %s: failed permanently: %v", label, err)
+		if err := r.jobRepo.Fail(job.ID, r.owner, err.Error()); err != nil {
+			logger.Error("[Job Runner] %s: failed to mark job failed: %v", label, err)
+		}
+		r.publish(job, EventJobFailed, err.Error())
+	case jobOutcomeRetry:
+		delay := retryDelay(job.Attempts)
+		logger.Warn("[Job Runner] %s: failed (%v), retrying in %s", label, err, delay)
+		if err := r.jobRepo.Retry(job.ID, r.owner, err.Error(), time.Now().Add(delay)); err != nil {
+			logger.Error("[Job Runner] %s: failed to schedule retry: %v", label, err)
+		}
+		r.publish(job, EventJobRetrying, err.Error())
+	}
+}
+
+// jobOutcome 任务执行结束后对任务表的处理方式。
+type jobOutcome int
+
+const (
+	// jobOutcomeUntouched 漫画被暂停、取消或租约丢失，任务状态已由他处改写
+	jobOutcomeUntouched jobOutcome = iota
+	jobOutcomeCompleted
+	// jobOutcomeReleased 进程退出导致中断，交还任务且不计入执行次数
+	jobOutcomeReleased
+	jobOutcomeFailed
+	jobOutcomeRetry
+)
+
+// jobOutcomeOf 根据处理函数的返回值、任务 context 的取消原因以及进程是否正在退出，决定任务的去向。
+func jobOutcomeOf(job *models.ComicJob, err, cause error, shuttingDown bool) jobOutcome {
+	switch {
+	case errors.Is(cause, errJobStopped), errors.Is(cause, errJobLeaseLost):
+		return jobOutcomeUntouched
+	case err == nil:
+		return jobOutcomeCompleted
+	case shuttingDown:
+		return jobOutcomeReleased
+	case job.Attempts >= job.MaxAttempts:
+		return jobOutcomeFailed
+	default:
+		return jobOutcomeRetry
+	}
+}
+
+func (r *JobRunner) track(job *models.ComicJob, cancel context.CancelCauseFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.inflight[job.ComicID] == nil {
+		r.inflight[job.ComicID] = make(map[uint]context.CancelCauseFunc)
+	}
+	r.inflight[job.ComicID][job.ID] = cancel
+}
+
+func (r *JobRunner) untrack(job *models.ComicJob) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.inflight[job.ComicID], job.ID)
+	if len(r.inflight[job.ComicID]) == 0 {
+		delete(r.inflight, job.ComicID)
+	}
+}
+
+// StopComic 中断本进程中该漫画所有执行中的任务，返回被中断的任务数。
+// 调用方需先在任务表中改写这些任务的状态，被中断的任务不会再被标记为完成、重试或失败。
+func (r *JobRunner) StopComic(comicID uint) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, cancel := range r.inflight[comicID] {
+		cancel(errJobStopped)
+	}
+	return len(r.inflight[comicID])
+}
+
+// publish 推送任务状态变化，并附带漫画整体的任务进度。
+func (r *JobRunner) publish(job *models.ComicJob, eventType, errMessage string) {
+	event := ProgressEvent{
+		Type:     eventType,
+		JobID:    formatID(job.ID),
+		Stage:    job.Stage,
+		Error:    errMessage,
+		Progress: loadJobProgress(r.jobRepo, job.ComicID),
+	}
+	if job.SectionID != nil {
+		event.SectionID = formatID(*job.SectionID)
+	}
+	if job.PageID != nil {
+		event.PageID = formatID(*job.PageID)
+	}
+	r.events.Publish(job.ComicID, event)
+}
+
+// heartbeat 定期续期租约，租约被其他进程接管时取消任务的 context。
+func (r *JobRunner) heartbeat(ctx context.Context, cancel context.CancelCauseFunc, job *models.ComicJob, label string) {
+	ticker := time.NewTicker(max(time.Second, r.cfg.Lease/3))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			held, err := r.jobRepo.ExtendLease(job.ID, r.owner, r.cfg.Lease)
+			if err != nil {
+				logger.Warn("[Job Runner] %s: failed to extend lease: %v", label, err)
+				continue
+			}
+			if !held {
+				logger.Error("[Job Runner] %s: lease lost, cancelling", label)
+				cancel(errJobLeaseLost)
+				return
+			}
+		}
+	}
+}
+
+// retryDelay 按执行次数的平方退避，最长 jobRetryMaxDelay。
+func retryDelay(attempts int) time.Duration {
+	return min(jobRetryMaxDelay, jobRetryBaseDelay*time.Duration(attempts*attempts))
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cohesion-dev/GNX/backend_new/config"
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+)
+
+func TestJobOutcomeOf(t *testing.T) {
+	failure := errors.New("model timeout")
+	tests := []struct {
+		name         string
+		attempts     int
+		err          error
+		cause        error
+		shuttingDown bool
+		want         jobOutcome
+	}{
+		{name: "success", attempts: 1, want: jobOutcomeCompleted},
+		{name: "first failure retries", attempts: 1, err: failure, want: jobOutcomeRetry},
+		{name: "last attempt fails", attempts: 3, err: failure, want: jobOutcomeFailed},
+		{name: "paused comic", attempts: 1, err: context.Canceled, cause: errJobStopped, want: jobOutcomeUntouched},
+		{name: "lease lost", attempts: 3, err: failure, cause: fmt.Errorf("heartbeat: %w", errJobLeaseLost), want: jobOutcomeUntouched},
+		{name: "stopped wins over shutdown", attempts: 1, err: context.Canceled, cause: errJobStopped, shuttingDown: true, want: jobOutcomeUntouched},
+		{name: "shutdown releases", attempts: 3, err: context.Canceled, cause: context.Canceled, shuttingDown: true, want: jobOutcomeReleased},
+		{name: "finished before shutdown", attempts: 1, cause: context.Canceled, shuttingDown: true, want: jobOutcomeCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &models.ComicJob{Attempts: tt.attempts, MaxAttempts: 3}
+			if got := jobOutcomeOf(job, tt.err, tt.cause, tt.shuttingDown); got != tt.want {
+				t.Fatalf("jobOutcomeOf = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 40 * time.Second},
+		{3, 90 * time.Second},
+		{7, 490 * time.Second},
+		{8, 10 * time.Minute},
+		{100, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestAvailableStagesRespectsStageLimits(t *testing.T) {
+	noop := func(context.Context, *models.ComicJob) error { return nil }
+	r := NewJobRunner(nil, nil, &config.JobConfig{
+		Workers:      4,
+		StageWorkers: map[string]int{models.JobStagePageImage: 2, models.JobStageTTS: 0},
+	})
+	r.Register(models.JobStageStoryboard, noop)
+	r.Register(models.JobStagePageImage, noop)
+	r.Register(models.JobStageTTS, noop)
+
+	stages := r.availableStages()
+	slices.Sort(stages)
+	if want := []string{models.JobStagePageImage, models.JobStageStoryboard}; !slices.Equal(stages, want) {
+		t.Fatalf("stages = %v, want %v", stages, want)
+	}
+
+	r.running[models.JobStagePageImage] = 2
+	r.running[models.JobStageStoryboard] = 10
+	if stages := r.availableStages(); !slices.Equal(stages, []string{models.JobStageStoryboard}) {
+		t.Fatalf("stages with page_image at its limit = %v, want only storyboard", stages)
+	}
+
+	r.release(&models.ComicJob{Stage: models.JobStagePageImage})
+	stages = r.availableStages()
+	slices.Sort(stages)
+	if want := []string{models.JobStagePageImage, models.JobStageStoryboard}; !slices.Equal(stages, want) {
+		t.Fatalf("stages after release = %v, want %v", stages, want)
+	}
+
+	r = NewJobRunner(nil, nil, &config.JobConfig{
+		Workers:      4,
+		StageWorkers: map[string]int{models.JobStagePageImage: 2, models.JobStageTTS: 2},
+	})
+	r.Register(models.JobStageStoryboard, noop)
+	r.Register(models.JobStagePageImage, noop)
+	r.Register(models.JobStageTTS, noop)
+
+	// 各阶段均未达到上限，但两个受限阶段合计已占用 3 个工作协程，最后一个留给分镜
+	r.running[models.JobStagePageImage] = 2
+	r.running[models.JobStageTTS] = 1
+	if stages := r.availableStages(); !slices.Equal(stages, []string{models.JobStageStoryboard}) {
+		t.Fatalf("stages with capped stages holding Workers-1 = %v, want only storyboard", stages)
+	}
+
+	r.release(&models.ComicJob{Stage: models.JobStagePageImage})
+	stages = r.availableStages()
+	slices.Sort(stages)
+	if want := []string{models.JobStagePageImage, models.JobStageStoryboard, models.JobStageTTS}; !slices.Equal(stages, want) {
+		t.Fatalf("stages after release = %v, want %v", stages, want)
+	}
+
+	// 只有一个工作协程时不做预留，否则受限阶段永远无法执行
+	single := NewJobRunner(nil, nil, &config.JobConfig{Workers: 1, StageWorkers: map[string]int{models.JobStageTTS: 1}})
+	single.Register(models.JobStageStoryboard, noop)
+	single.Register(models.JobStageTTS, noop)
+	stages = single.availableStages()
+	slices.Sort(stages)
+	if want := []string{models.JobStageStoryboard, models.JobStageTTS}; !slices.Equal(stages, want) {
+		t.Fatalf("single worker stages = %v, want %v", stages, want)
+	}
+}
+
+func TestJobStoppedByStopComic(t *testing.T) {
+	r := NewJobRunner(nil, nil, &config.JobConfig{Workers: 1})
+	job := &models.ComicJob{ID: 1, ComicID: 7}
+	ctx, cancel := context.WithCancelCause(context.Background())
+	r.track(job, cancel)
+
+	if jobStopped(ctx) {
+		t.Fatal("running job reported as stopped")
+	}
+	if n := r.StopComic(job.ComicID); n != 1 {
+		t.Fatalf("StopComic interrupted %d jobs, want 1", n)
+	}
+	if !jobStopped(ctx) {
+		t.Fatal("job interrupted by StopComic not reported as stopped")
+	}
+
+	section := &models.ComicSection{ID: 3, Index: 2}
+	if event, ok := storyboardResultEvent(ctx, section, context.Cause(ctx)); ok {
+		t.Fatalf("stopped storyboard published %q, want no event", event.Type)
+	}
+
+	// 租约丢失或进程退出并不是暂停，失败仍照常推送
+	leaseCtx, leaseCancel := context.WithCancelCause(context.Background())
+	leaseCancel(errJobLeaseLost)
+	if jobStopped(leaseCtx) {
+		t.Fatal("lease loss reported as stopped")
+	}
+	event, ok := storyboardResultEvent(leaseCtx, section, errors.New("model timeout"))
+	if !ok || event.Type != EventStoryboardFailed || event.Error != "model timeout" {
+		t.Fatalf("failed storyboard event = %+v (%t), want %s", event, ok, EventStoryboardFailed)
+	}
+	if event, ok := storyboardResultEvent(context.Background(), section, nil); !ok || event.Type != EventStoryboardReady {
+		t.Fatalf("completed storyboard event = %+v (%t), want %s", event, ok, EventStoryboardReady)
+	}
+}
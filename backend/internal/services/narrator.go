@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/logger"
+)
+
+// ErrInvalidVoice 音色不在可用音色列表中。
+var ErrInvalidVoice = errors.New("invalid voice")
+
+// SetNarratorVoice 设置漫画的旁白音色，空字符串表示恢复为默认音色。已预合成的章节随后重新合成旁白。
+func (s *ComicService) SetNarratorVoice(ctx context.Context, comicID uint, voiceType string) (*models.Comic, error) {
+	comic, err := s.comicRepo.FindByID(comicID)
+	if err != nil {
+		return nil, err
+	}
+
+	voiceName := ""
+	voiceType = strings.TrimSpace(voiceType)
+	if voiceType != "" {
+		voice, err := s.findVoice(ctx, voiceType)
+		if err != nil {
+			return nil, err
+		}
+		if voice == nil {
+			return nil, fmt.Errorf("%w: unknown voice_type %s", ErrInvalidVoice, voiceType)
+		}
+		voiceName = voice.VoiceName
+	}
+
+	if voiceType != comic.NarratorVoiceType {
+		if err := s.comicRepo.UpdateNarratorVoice(comicID, voiceName, voiceType); err != nil {
+			return nil, fmt.Errorf("failed to update narrator voice: %w", err)
+		}
+		comic.NarratorVoiceName, comic.NarratorVoiceType = voiceName, voiceType
+		s.refreshComicAudio(comicID)
+	}
+	logger.Info("[Narrator] Comic ID=%d narrator voice set to %q", comicID, voiceType)
+	return comic, nil
+}
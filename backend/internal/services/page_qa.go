@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cohesion-dev/GNX/ai/gnxaigc"
+	"github.com/cohesion-dev/GNX/backend_new/config"
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/logger"
+)
+
+// renderPageImage 按配置的生成模式渲染整页图片，逐格模式失败时回退到整页生成，此时不返回分格区域。
+func (s *ComicService) renderPageImage(
+	ctx context.Context,
+	comic *models.Comic,
+	page gnxaigc.StoryboardPage,
+	features []gnxaigc.CharacterFeature,
+	characterAssets map[string]*CharacterAsset,
+	label string,
+) ([]byte, []models.PanelRect, error) {
+	if s.cfg.RenderMode == config.RenderModePanel && hasPanelVisualPrompts(page) {
+		imageData, rects, err := s.renderPageByPanels(ctx, comic, page, features, characterAssets, label)
+		if err == nil {
+			return imageData, rects, nil
+		}
+		logger.Warn("[Section Image Processing] %s: Panel rendering failed (%v), falling back to whole-page rendering", label, err)
+	}
+
+	fullPrompt := gnxaigc.ComposePageImagePrompt(comic.UserPrompt, page)
+	referenceKeys := s.collectPageCharacterKeys(page, features)
+	imageData, err := s.generateImageWithReferences(ctx, fullPrompt, collectReferenceImages(referenceKeys, characterAssets, page.Panels), label)
+	return imageData, nil, err
+}
+
+// hasPanelVisualPrompts 判断页面的每个分格都有画面描述，逐格生成依赖分格的 VisualPrompt。
+func hasPanelVisualPrompts(page gnxaigc.StoryboardPage) bool {
+	if len(page.Panels) == 0 {
+		return false
+	}
+	for _, panel := range page.Panels {
+		if strings.TrimSpace(panel.VisualPrompt) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// renderPageImageWithQA 渲染页面图片，开启质检时由视觉模型检查结果，不合格则重新生成，
+// 最多重试 QAMaxRetries 次；全部不合格时采用得分最高的一次。质检结果与分格区域随最终图片一起保存。
+func (s *ComicService) renderPageImageWithQA(
+	ctx context.Context,
+	comic *models.Comic,
+	pageID uint,
+	page gnxaigc.StoryboardPage,
+	features []gnxaigc.CharacterFeature,
+	characterAssets map[string]*CharacterAsset,
+	label string,
+) ([]byte, []models.PanelRect, error) {
+	imageData, rects, err := s.renderPageImage(ctx, comic, page, features, characterAssets, label)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var report *models.PageQAReport
+	if s.cfg.QAEnabled {
+		characters := collectReviewCharacters(s.collectPageCharacterKeys(page, features), characterAssets)
+
+		bestData, bestRects := imageData, rects
+		for attempt := 1; ; attempt++ {
+			review, err := s.aigc.ReviewPageImage(ctx, gnxaigc.ReviewPageImageInput{
+				PageImage:      imageData,
+				ExpectedPanels: len(page.Panels),
+				LayoutHint:     page.LayoutHint,
+				Characters:     characters,
+			})
+			if err != nil {
+				logger.Warn("[Page QA] %s: Review failed on attempt %d (%v), keeping current best image", label, attempt, err)
+				break
+			}
+
+			current := toPageQAReport(review, len(page.Panels), s.cfg.QAMinCharacterScore)
+			logger.Info("[Page QA] %s: Attempt %d score=%.2f passed=%t issues=%v", label, attempt, current.Score, current.Passed, current.Issues)
+			if report == nil || current.Passed || (!report.Passed && current.Score > report.Score) {
+				report, bestData, bestRects = current, imageData, rects
+			}
+			report.Attempts = attempt
+
+			if current.Passed || attempt > s.cfg.QAMaxRetries {
+				break
+			}
+
+			logger.Warn("[Page QA] %s: Page failed QA, regenerating (%d/%d)", label, attempt, s.cfg.QAMaxRetries)
+			imageData, rects, err = s.renderPageImage(ctx, comic, page, features, characterAssets, label)
+			if err != nil {
+				logger.Error("[Page QA] %s: Regeneration failed: %v", label, err)
+				break
+			}
+		}
+		imageData, rects = bestData, bestRects
+	}
+
+	if rects != nil {
+		if err := s.pageRepo.UpdatePanelRects(pageID, rects); err != nil {
+			logger.Error("[Section Image Processing] %s: Failed to save panel rects: %v", label, err)
+		}
+	}
+	if report != nil {
+		if err := s.pageRepo.UpdateQAReport(pageID, report); err != nil {
+			logger.Error("[Page QA] %s: Failed to save QA report: %v", label, err)
+		}
+	}
+
+	return imageData, rects, nil
+}
+
+func collectReviewCharacters(keys []string, characterAssets map[string]*CharacterAsset) []gnxaigc.ReviewCharacter {
+	var characters []gnxaigc.ReviewCharacter
+	for _, key := range keys {
+		asset := characterAssets[key]
+		if asset == nil || len(asset.ImageData) == 0 {
+			continue
+		}
+		characters = append(characters, gnxaigc.ReviewCharacter{Name: key, ImageData: asset.ImageData})
+	}
+	return characters
+}
+
+// toPageQAReport 将视觉模型的检查结果换算为各项得分，总分为三项得分的平均值。
+func toPageQAReport(review *gnxaigc.PageReview, expectedPanels int, minCharacterScore float64) *models.PageQAReport {
+	report := &models.PageQAReport{
+		Passed:         review.Passed(expectedPanels, minCharacterScore),
+		TextScore:      1,
+		PanelScore:     1,
+		CharacterScore: review.CharacterScore(),
+		ExpectedPanels: expectedPanels,
+		DetectedPanels: review.DetectedPanels,
+		Issues:         review.Issues,
+	}
+	if review.HasText {
+		report.TextScore = 0
+	}
+	// 期望分格数未知时不比较分格数，与 Passed 的判断保持一致
+	if expectedPanels > 0 && review.DetectedPanels != expectedPanels {
+		report.PanelScore = 0
+	}
+	report.Score = (report.TextScore + report.PanelScore + report.CharacterScore) / 3
+
+	for _, c := range review.Characters {
+		report.Characters = append(report.Characters, models.PageQACharacter{Name: c.Name, Score: c.Score, Comment: c.Comment})
+	}
+	return report
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/cohesion-dev/GNX/ai/gnxaigc"
+)
+
+func TestToPageQAReportPanelScore(t *testing.T) {
+	tests := []struct {
+		name           string
+		expectedPanels int
+		detectedPanels int
+		want           float64
+	}{
+		{name: "matching panels", expectedPanels: 3, detectedPanels: 3, want: 1},
+		{name: "missing panel", expectedPanels: 3, detectedPanels: 2, want: 0},
+		{name: "unknown expected panels", expectedPanels: 0, detectedPanels: 4, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review := &gnxaigc.PageReview{DetectedPanels: tt.detectedPanels}
+			report := toPageQAReport(review, tt.expectedPanels, 0)
+			if report.PanelScore != tt.want {
+				t.Fatalf("PanelScore = %v, want %v", report.PanelScore, tt.want)
+			}
+			if report.Passed != (tt.want == 1) {
+				t.Fatalf("Passed = %t, want it to agree with PanelScore %v", report.Passed, report.PanelScore)
+			}
+		})
+	}
+}
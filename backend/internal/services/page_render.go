@@ -0,0 +1,303 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cohesion-dev/GNX/ai/gnxaigc"
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/imageutil"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/logger"
+)
+
+// acquireImageSlot 占用一个图像生成名额，进程内同时调用图像模型的数量不超过 ImageConcurrency。
+func (s *ComicService) acquireImageSlot(ctx context.Context) (func(), error) {
+	select {
+	case s.imageSlots <- struct{}{}:
+		return func() { <-s.imageSlots }, nil
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+}
+
+// generateImageByText 在图像生成名额内调用文生图。
+func (s *ComicService) generateImageByText(ctx context.Context, prompt string) ([]byte, error) {
+	release, err := s.acquireImageSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.aigc.GenerateImageByText(ctx, prompt)
+}
+
+// generateImageByImage 在图像生成名额内调用图生图。
+func (s *ComicService) generateImageByImage(ctx context.Context, reference []byte, prompt string) ([]byte, error) {
+	release, err := s.acquireImageSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.aigc.GenerateImageByImage(ctx, reference, prompt)
+}
+
+// generateImageWithReferences 只有一张参考图时直接图生图，多张时拼成带角色名标注的设定图再做图生图，图生图失败时回退到文生图。
+func (s *ComicService) generateImageWithReferences(ctx context.Context, prompt string, references []imageutil.ReferenceTile, label string) ([]byte, error) {
+	if len(references) == 0 {
+		return s.generateImageByText(ctx, prompt)
+	}
+
+	reference, referencePrompt := references[0].Image, prompt
+	if len(references) == 1 {
+		logger.Info("[Section Image Processing] %s: Using reference image of %s (%d bytes)", label, references[0].Label, len(reference))
+	} else {
+		sheet, placed, err := imageutil.ComposeReferenceSheet(references, imageutil.ReferenceSheetOptions{})
+		if err != nil {
+			logger.Warn("[Section Image Processing] %s: Failed to compose reference sheet from %d images (%v), using text-to-image", label, len(references), err)
+			return s.generateImageByText(ctx, prompt)
+		}
+		if placed < len(references) {
+			logger.Warn("[Section Image Processing] %s: Reference sheet only fits %d of %d images", label, placed, len(references))
+		}
+
+		names := make([]string, 0, placed)
+		for _, ref := range references[:placed] {
+			names = append(names, ref.Label)
+		}
+		reference = sheet
+		referencePrompt = strings.TrimSpace(prompt + " " + gnxaigc.ReferenceSheetNote(names))
+		logger.Info("[Section Image Processing] %s: Using reference sheet of %d images (%d bytes)", label, placed, len(sheet))
+	}
+
+	imageData, err := s.generateImageByImage(ctx, reference, referencePrompt)
+	if err != nil {
+		logger.Warn("[Section Image Processing] %s: img2img failed (%v), falling back to text-to-image", label, err)
+		return s.generateImageByText(ctx, prompt)
+	}
+	return imageData, nil
+}
+
+// maxPanelRenderAttempts 逐格生成时每个分格最多尝试的次数，用尽后整页回退到整页生成。
+const maxPanelRenderAttempts = 3
+
+// renderPageByPanels 逐格生成分格图片，再按页面的 LayoutHint 在本地拼版，返回整页图片与分格区域。
+// 部分分格失败时只重试失败的分格。
+func (s *ComicService) renderPageByPanels(
+	ctx context.Context,
+	comic *models.Comic,
+	page gnxaigc.StoryboardPage,
+	features []gnxaigc.CharacterFeature,
+	characterAssets map[string]*CharacterAsset,
+	label string,
+) ([]byte, []models.PanelRect, error) {
+	if len(page.Panels) == 0 {
+		return nil, nil, errors.New("storyboard page has no panels")
+	}
+
+	layout := imageutil.ParseLayout(page.LayoutHint, len(page.Panels))
+	slots := layout.Rects(imageutil.DefaultPageWidth, imageutil.DefaultPageHeight, imageutil.DefaultGutter)
+	logger.Info("[Section Image Processing] %s: Rendering %d panels with layout %v (hint=%q)", label, len(page.Panels), layout.Rows, page.LayoutHint)
+
+	panelImages := make([][]byte, len(page.Panels))
+	errs := make([]error, len(page.Panels))
+
+	// 每轮只重新生成尚未成功的分格，已完成的分格图片跨轮保留
+	for attempt := 1; ; attempt++ {
+		var wg sync.WaitGroup
+		for panelIndex, panel := range page.Panels {
+			if panelImages[panelIndex] != nil {
+				continue
+			}
+			wg.Add(1)
+			go func(panelIndex int, panel gnxaigc.StoryboardPanel) {
+				defer wg.Done()
+
+				panelLabel := fmt.Sprintf("%s panel %d/%d", label, panelIndex+1, len(page.Panels))
+				prompt := gnxaigc.ComposePanelImagePrompt(comic.UserPrompt, page, panelIndex, describePanelAspect(slots[panelIndex]))
+				referenceKeys := s.collectPanelCharacterKeys(panel, features)
+
+				data, err := s.generateImageWithReferences(ctx, prompt, collectReferenceImages(referenceKeys, characterAssets, []gnxaigc.StoryboardPanel{panel}), panelLabel)
+				if err != nil {
+					errs[panelIndex] = fmt.Errorf("panel %d: %w", panelIndex+1, err)
+					return
+				}
+				panelImages[panelIndex], errs[panelIndex] = data, nil
+			}(panelIndex, panel)
+		}
+		wg.Wait()
+
+		err := errors.Join(errs...)
+		if err == nil {
+			break
+		}
+		if attempt >= maxPanelRenderAttempts || ctx.Err() != nil {
+			return nil, nil, err
+		}
+		logger.Warn("[Section Image Processing] %s: Retrying failed panels (%d/%d): %v", label, attempt, maxPanelRenderAttempts-1, err)
+	}
+
+	pageImage, rects, err := imageutil.ComposePage(panelImages, layout, imageutil.PageComposeOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pageImage, toPanelRects(rects), nil
+}
+
+func (s *ComicService) collectPanelCharacterKeys(panel gnxaigc.StoryboardPanel, features []gnxaigc.CharacterFeature) []string {
+	return s.collectPageCharacterKeys(gnxaigc.StoryboardPage{Panels: []gnxaigc.StoryboardPanel{panel}}, features)
+}
+
+// collectReferenceImages 按角色在分格中的出镜角度与表情挑选参考图，没有设定表图片时使用角色原画。
+func collectReferenceImages(keys []string, characterAssets map[string]*CharacterAsset, panels []gnxaigc.StoryboardPanel) []imageutil.ReferenceTile {
+	emotions := characterEmotions(panels)
+	var references []imageutil.ReferenceTile
+	for _, key := range keys {
+		asset := characterAssets[key]
+		if asset == nil {
+			continue
+		}
+		data := asset.ReferenceImage(emotions[key], characterView(panels, key))
+		if len(data) == 0 {
+			continue
+		}
+		references = append(references, imageutil.ReferenceTile{Label: key, Image: data})
+	}
+	return references
+}
+
+// describePanelAspect 将分格区域的宽高比转换为提示词中的画幅描述。
+func describePanelAspect(rect image.Rectangle) string {
+	if rect.Dx() == 0 || rect.Dy() == 0 {
+		return ""
+	}
+	ratio := float64(rect.Dx()) / float64(rect.Dy())
+	switch {
+	case ratio >= 1.3:
+		return "wide landscape"
+	case ratio <= 0.77:
+		return "tall portrait"
+	default:
+		return "square"
+	}
+}
+
+func toPanelRects(rects []image.Rectangle) []models.PanelRect {
+	out := make([]models.PanelRect, 0, len(rects))
+	for _, r := range rects {
+		out = append(out, models.PanelRect{X: r.Min.X, Y: r.Min.Y, Width: r.Dx(), Height: r.Dy()})
+	}
+	return out
+}
+
+// storePageImage 以新的存储 key 上传页面图片，记录到页面并推送进度事件，开启嵌字时另存嵌字图层。
+// 旧图片不会被覆盖，修订记录可以继续引用。
+func (s *ComicService) storePageImage(
+	comicID uint,
+	section *models.ComicSection,
+	pageID uint,
+	imageData []byte,
+	page gnxaigc.StoryboardPage,
+	rects []models.PanelRect,
+	label string,
+) error {
+	imageID := fmt.Sprintf("page_%d_%d", pageID, time.Now().UnixNano())
+	if err := s.storage.UploadBytes(imageData, imageID); err != nil {
+		logger.Error("[Section Image Processing] %s: Failed to upload image: %v", label, err)
+		s.failPageImage(comicID, section, pageID, fmt.Errorf("failed to upload image: %w", err), label)
+		return err
+	}
+	if err := s.pageRepo.MarkImageCompleted(pageID, imageID, s.aigc.ImageModel); err != nil {
+		logger.Error("[Section Image Processing] %s: Failed to save image ID: %v", label, err)
+		return err
+	}
+	logger.Info("[Section Image Processing] %s: Image uploaded successfully (imageID=%s)", label, imageID)
+	s.events.Publish(comicID, ProgressEvent{Type: EventPageImageUploaded, SectionID: formatID(section.ID), SectionIndex: section.Index, PageID: formatID(pageID), ImageID: imageID})
+	if s.cfg.LetteringEnabled {
+		s.letterPage(pageID, imageID, imageData, page, rects, label)
+	}
+	return nil
+}
+
+// failPageImage 记录页面图片生成失败并推送进度事件。
+func (s *ComicService) failPageImage(comicID uint, section *models.ComicSection, pageID uint, err error, label string) {
+	if markErr := s.pageRepo.MarkImageFailed(pageID, err.Error()); markErr != nil {
+		logger.Error("[Section Image Processing] %s: Failed to save image error: %v", label, markErr)
+	}
+	s.events.Publish(comicID, ProgressEvent{Type: EventPageImageFailed, SectionID: formatID(section.ID), SectionIndex: section.Index, PageID: formatID(pageID), Error: err.Error()})
+}
+
+// stopPageImage 漫画被暂停或取消导致生成中断时恢复页面图片状态，恢复生成后重新渲染，不推送失败事件。
+func (s *ComicService) stopPageImage(pageID uint, label string) {
+	logger.Info("[Section Image Processing] %s: Generation stopped, leaving image pending", label)
+	if err := s.pageRepo.ResetImageStatus(pageID); err != nil {
+		logger.Error("[Section Image Processing] %s: Failed to reset image status: %v", label, err)
+	}
+}
+
+// letterPage 在原始画面之上绘制对白气泡与旁白框，另存为独立图层，原始画面保持不变。
+// 整页生成模式没有精确的分格区域，此时按 LayoutHint 在实际图片尺寸上估算。
+func (s *ComicService) letterPage(pageID uint, imageID string, imageData []byte, page gnxaigc.StoryboardPage, rects []models.PanelRect, label string) {
+	panelRects := make([]image.Rectangle, 0, len(rects))
+	for _, r := range rects {
+		panelRects = append(panelRects, image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height))
+	}
+	if len(panelRects) != len(page.Panels) {
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(imageData))
+		if err != nil {
+			logger.Error("[Section Image Processing] %s: Failed to read image size for lettering: %v", label, err)
+			return
+		}
+		gutter := imageutil.DefaultGutter * cfg.Width / imageutil.DefaultPageWidth
+		panelRects = imageutil.ParseLayout(page.LayoutHint, len(page.Panels)).Rects(cfg.Width, cfg.Height, gutter)
+	}
+
+	panels := make([]imageutil.PanelLettering, 0, len(page.Panels))
+	for panelIndex, panel := range page.Panels {
+		if panelIndex >= len(panelRects) {
+			break
+		}
+		var items []imageutil.LetteringItem
+		for _, segment := range panel.SourceTextSegments {
+			kind := imageutil.BalloonSpeech
+			if segment.IsNarration || len(segment.CharacterNames) == 0 {
+				kind = imageutil.BalloonCaption
+			}
+			items = append(items, imageutil.LetteringItem{Kind: kind, Text: trimDialogueQuotes(segment.Text)})
+		}
+		panels = append(panels, imageutil.PanelLettering{Rect: panelRects[panelIndex], Items: items})
+	}
+
+	lettered, err := imageutil.LetterPage(imageData, panels, imageutil.LetteringOptions{})
+	if err != nil {
+		logger.Error("[Section Image Processing] %s: Failed to letter page: %v", label, err)
+		return
+	}
+
+	letteredImageID := imageID + "_lettered"
+	if err := s.storage.UploadBytes(lettered, letteredImageID); err != nil {
+		logger.Error("[Section Image Processing] %s: Failed to upload lettered page: %v", label, err)
+		return
+	}
+	if err := s.pageRepo.UpdateLetteredImageID(pageID, letteredImageID); err != nil {
+		logger.Error("[Section Image Processing] %s: Failed to save lettered image ID: %v", label, err)
+		return
+	}
+	logger.Info("[Section Image Processing] %s: Lettered page uploaded (imageID=%s)", label, letteredImageID)
+}
+
+// trimDialogueQuotes 去掉对白两侧的引号，气泡本身已经表明是角色台词。
+func trimDialogueQuotes(text string) string {
+	text = strings.TrimSpace(text)
+	for _, pair := range [][2]string{{"“", "”"}, {"「", "」"}, {"『", "』"}, {"\"", "\""}} {
+		if strings.HasPrefix(text, pair[0]) && strings.HasSuffix(text, pair[1]) && len(text) > len(pair[0])+len(pair[1]) {
+			return strings.TrimSpace(text[len(pair[0]) : len(text)-len(pair[1])])
+		}
+	}
+	return text
+}
@@ -0,0 +1,484 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/logger"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSectionBusy 章节分镜或页面图片仍在生成中，不能重新生成或回退分镜。
+	ErrSectionBusy = errors.New("section storyboard is still being generated")
+	// ErrPageBusy 页面图片已在排队或生成中，不能再次重新生成。
+	ErrPageBusy = errors.New("page image is already being generated")
+	// ErrRoleBusy 角色原画已在排队或生成中，不能再次重新生成或回退。
+	ErrRoleBusy = errors.New("role portrait is already being generated")
+	// ErrNotFound 目标不存在或不属于该漫画。
+	ErrNotFound = errors.New("not found")
+)
+
+// RegenerateOptions 重新生成时的提示词调整。Prompt 整体替换原有提示词，Instruction 追加在提示词之后。
+type RegenerateOptions struct {
+	Prompt      string
+	Instruction string
+}
+
+// apply 基于 base 计算新的提示词。
+func (o RegenerateOptions) apply(base string) string {
+	prompt := strings.TrimSpace(base)
+	if override := strings.TrimSpace(o.Prompt); override != "" {
+		prompt = override
+	}
+	if instruction := strings.TrimSpace(o.Instruction); instruction != "" {
+		prompt = strings.TrimSpace(prompt + " " + instruction)
+	}
+	return prompt
+}
+
+func defaultConceptArtPrompt(role *models.ComicRole) string {
+	return fmt.Sprintf("Character concept art for %s: %s", role.Name, role.Brief)
+}
+
+// RegeneratePageImage 保存页面当前的图片作为修订记录，更新页面提示词后排队重新生成该页图片。
+func (s *ComicService) RegeneratePageImage(comicID, pageID uint, opts RegenerateOptions) (*models.ComicJob, *models.AssetRevision, error) {
+	page, section, err := s.findComicPage(comicID, pageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.ensureNotCancelled(comicID); err != nil {
+		return nil, nil, err
+	}
+	if err := s.ensurePageIdle(comicID, section.ID, page.ID); err != nil {
+		return nil, nil, err
+	}
+
+	revision, err := s.snapshotPageImage(comicID, page)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to save current page image: %w", err)
+	}
+
+	prompt := opts.apply(page.ImagePrompt)
+	if prompt != page.ImagePrompt {
+		if err := s.pageRepo.UpdateImagePrompt(page.ID, prompt); err != nil {
+			return nil, nil, fmt.Errorf("failed to update page prompt: %w", err)
+		}
+	}
+
+	job, err := s.enqueueJob(&models.ComicJob{ComicID: comicID, SectionID: &section.ID, PageID: &page.ID, Stage: models.JobStagePageImage})
+	if err != nil {
+		return nil, nil, err
+	}
+	logger.Info("[Regenerate] Page ID=%d queued for regeneration (job ID=%d, revision ID=%d)", page.ID, job.ID, revision.ID)
+	return job, revision, nil
+}
+
+// RegenerateRolePortrait 记录角色当前的原画作为修订记录，更新原画提示词后排队重新生成。
+func (s *ComicService) RegenerateRolePortrait(comicID, roleID uint, opts RegenerateOptions) (*models.ComicJob, *models.AssetRevision, error) {
+	role, err := s.findComicRole(comicID, roleID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.ensureNotCancelled(comicID); err != nil {
+		return nil, nil, err
+	}
+	if err := s.ensureRoleIdle(comicID, role.ID); err != nil {
+		return nil, nil, err
+	}
+
+	revision := &models.AssetRevision{
+		ComicID:    comicID,
+		TargetType: models.RevisionTargetRolePortrait,
+		TargetID:   role.ID,
+		Prompt:     role.ConceptArtPrompt,
+		ImageID:    role.ImageID,
+	}
+	if err := s.revisionRepo.Create(revision); err != nil {
+		return nil, nil, fmt.Errorf("failed to save current role portrait: %w", err)
+	}
+
+	base := role.ConceptArtPrompt
+	if base == "" {
+		base = defaultConceptArtPrompt(role)
+	}
+	role.ConceptArtPrompt = opts.apply(base)
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, nil, fmt.Errorf("failed to update role prompt: %w", err)
+	}
+
+	job, err := s.enqueueJob(&models.ComicJob{ComicID: comicID, RoleID: &role.ID, Stage: models.JobStageRolePortrait})
+	if err != nil {
+		return nil, nil, err
+	}
+	logger.Info("[Regenerate] Role ID=%d queued for portrait regeneration (job ID=%d, revision ID=%d)", role.ID, job.ID, revision.ID)
+	return job, revision, nil
+}
+
+// RegenerateSectionStoryboard 将章节当前的页面归档为修订记录，按新的额外要求排队重新生成分镜，完成后自动渲染页面图片。
+func (s *ComicService) RegenerateSectionStoryboard(comicID, sectionID uint, instruction string) (*models.ComicJob, *models.AssetRevision, error) {
+	section, err := s.findComicSection(comicID, sectionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.ensureSectionIdle(comicID, section); err != nil {
+		return nil, nil, err
+	}
+	if err := s.ensureNotCancelled(comicID); err != nil {
+		return nil, nil, err
+	}
+
+	revision := &models.AssetRevision{
+		ComicID:    comicID,
+		TargetType: models.RevisionTargetSectionStoryboard,
+		TargetID:   section.ID,
+		Prompt:     section.StoryboardInstruction,
+	}
+	if err := s.revisionRepo.ArchiveStoryboard(revision); err != nil {
+		return nil, nil, fmt.Errorf("failed to archive current storyboard: %w", err)
+	}
+
+	if err := s.sectionRepo.UpdateStoryboardInstruction(section.ID, strings.TrimSpace(instruction)); err != nil {
+		return nil, nil, fmt.Errorf("failed to update storyboard instruction: %w", err)
+	}
+	s.updateSectionStatus(section.ID, "pending")
+
+	job, err := s.enqueueJob(&models.ComicJob{ComicID: comicID, SectionID: &section.ID, Stage: models.JobStageStoryboard})
+	if err != nil {
+		s.updateSectionStatus(section.ID, "failed")
+		return nil, nil, err
+	}
+	logger.Info("[Regenerate] Section ID=%d queued for storyboard regeneration (job ID=%d, revision ID=%d)", section.ID, job.ID, revision.ID)
+	return job, revision, nil
+}
+
+// ListRevisions 列出漫画的修订记录，可按目标类型与目标 ID 过滤。
+func (s *ComicService) ListRevisions(comicID uint, targetType string, targetID uint) ([]models.AssetRevision, error) {
+	if _, err := s.comicRepo.FindByID(comicID); err != nil {
+		return nil, err
+	}
+	return s.revisionRepo.FindByComicID(comicID, targetType, targetID)
+}
+
+// RevertRevision 回退到修订记录保存的版本。当前版本会先保存为新的修订记录，因此回退本身也可以再次回退；
+// 被回退的修订记录随后删除。返回保存当前版本的修订记录。
+func (s *ComicService) RevertRevision(comicID, revisionID uint) (*models.AssetRevision, error) {
+	target, err := s.revisionRepo.FindByID(revisionID)
+	if err != nil {
+		return nil, err
+	}
+	if target.ComicID != comicID {
+		return nil, ErrNotFound
+	}
+
+	var current *models.AssetRevision
+	switch target.TargetType {
+	case models.RevisionTargetPageImage:
+		current, err = s.revertPageImage(comicID, target)
+	case models.RevisionTargetRolePortrait:
+		current, err = s.revertRolePortrait(comicID, target)
+	case models.RevisionTargetSectionStoryboard:
+		current, err = s.revertSectionStoryboard(comicID, target)
+	default:
+		err = fmt.Errorf("unknown revision target type %q", target.TargetType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("[Regenerate] Reverted %s ID=%d to revision ID=%d (current version saved as revision ID=%d)",
+		target.TargetType, target.TargetID, target.ID, current.ID)
+	return current, nil
+}
+
+func (s *ComicService) revertPageImage(comicID uint, target *models.AssetRevision) (*models.AssetRevision, error) {
+	page, section, err := s.findComicPage(comicID, target.TargetID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.snapshotPageImage(comicID, page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save current page image: %w", err)
+	}
+
+	if err := s.pageRepo.RestoreImage(page.ID, target); err != nil {
+		return nil, err
+	}
+	if err := s.revisionRepo.Delete(target.ID); err != nil {
+		return nil, err
+	}
+
+	s.events.Publish(comicID, ProgressEvent{Type: EventPageImageUploaded, SectionID: formatID(section.ID), SectionIndex: section.Index, PageID: formatID(page.ID), ImageID: target.ImageID})
+	return current, nil
+}
+
+func (s *ComicService) revertRolePortrait(comicID uint, target *models.AssetRevision) (*models.AssetRevision, error) {
+	role, err := s.findComicRole(comicID, target.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureRoleIdle(comicID, role.ID); err != nil {
+		return nil, err
+	}
+
+	current := &models.AssetRevision{
+		ComicID:    comicID,
+		TargetType: models.RevisionTargetRolePortrait,
+		TargetID:   role.ID,
+		Prompt:     role.ConceptArtPrompt,
+		ImageID:    role.ImageID,
+	}
+	if err := s.revisionRepo.Create(current); err != nil {
+		return nil, err
+	}
+
+	role.ImageID = target.ImageID
+	role.ConceptArtPrompt = target.Prompt
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, err
+	}
+	if err := s.revisionRepo.Delete(target.ID); err != nil {
+		return nil, err
+	}
+
+	s.events.Publish(comicID, ProgressEvent{Type: EventRolePortraitReady, RoleID: formatID(role.ID), ImageID: role.ImageID, Message: role.Name})
+	return current, nil
+}
+
+func (s *ComicService) revertSectionStoryboard(comicID uint, target *models.AssetRevision) (*models.AssetRevision, error) {
+	section, err := s.findComicSection(comicID, target.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureSectionIdle(comicID, section); err != nil {
+		return nil, err
+	}
+
+	current := &models.AssetRevision{
+		ComicID:    comicID,
+		TargetType: models.RevisionTargetSectionStoryboard,
+		TargetID:   section.ID,
+		Prompt:     section.StoryboardInstruction,
+	}
+	if err := s.revisionRepo.RevertStoryboard(target, current); err != nil {
+		return nil, err
+	}
+	if err := s.sectionRepo.UpdateStoryboardInstruction(section.ID, target.Prompt); err != nil {
+		return nil, err
+	}
+	s.updateSectionStatus(section.ID, "completed")
+	s.enqueueSectionAudio(comicID, section)
+
+	s.events.Publish(comicID, ProgressEvent{Type: EventStoryboardReady, SectionID: formatID(section.ID), SectionIndex: section.Index})
+	return current, nil
+}
+
+// snapshotPageImage 把页面当前的图片、嵌字图层与提示词写入修订记录。每次生成都使用新的存储 key，旧图片无需复制。
+func (s *ComicService) snapshotPageImage(comicID uint, page *models.ComicPage) (*models.AssetRevision, error) {
+	revision := &models.AssetRevision{
+		ComicID:         comicID,
+		TargetType:      models.RevisionTargetPageImage,
+		TargetID:        page.ID,
+		Prompt:          page.ImagePrompt,
+		ImageID:         page.ImageID,
+		LetteredImageID: page.LetteredImageID,
+		PanelRects:      page.PanelRects,
+	}
+	if err := s.revisionRepo.Create(revision); err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+func (s *ComicService) ensureNotCancelled(comicID uint) error {
+	comic, err := s.comicRepo.FindByID(comicID)
+	if err != nil {
+		return err
+	}
+	if comic.GenerationState == models.GenerationStateCancelled {
+		return ErrGenerationCancelled
+	}
+	return nil
+}
+
+func (s *ComicService) findComicPage(comicID, pageID uint) (*models.ComicPage, *models.ComicSection, error) {
+	page, err := s.pageRepo.FindByID(pageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if page.RevisionID != nil {
+		return nil, nil, ErrNotFound
+	}
+	section, err := s.findComicSection(comicID, page.SectionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return page, section, nil
+}
+
+// ensurePageIdle 页面自身或所在章节有未结束的图片任务时返回 ErrPageBusy，避免重复排队并覆盖修订记录。
+func (s *ComicService) ensurePageIdle(comicID, sectionID, pageID uint) error {
+	for _, target := range []*models.ComicJob{
+		{ComicID: comicID, SectionID: &sectionID, PageID: &pageID, Stage: models.JobStagePageImage},
+		{ComicID: comicID, SectionID: &sectionID, Stage: models.JobStagePageImage},
+	} {
+		active, err := s.jobRepo.FindActive(target)
+		if err != nil {
+			return fmt.Errorf("failed to check page image jobs: %w", err)
+		}
+		if active != nil {
+			return fmt.Errorf("%w (job ID=%d)", ErrPageBusy, active.ID)
+		}
+	}
+	return nil
+}
+
+// ensureSectionIdle 章节分镜仍在生成，或章节的分镜、页面图片任务尚未结束时返回 ErrSectionBusy。
+// 旧任务会继续写入即将归档的页面，新分镜的图片任务也会因与旧任务重复而不再排队。
+func (s *ComicService) ensureSectionIdle(comicID uint, section *models.ComicSection) error {
+	if section.Status == "pending" {
+		return ErrSectionBusy
+	}
+	for _, stage := range []string{models.JobStageStoryboard, models.JobStagePageImage} {
+		active, err := s.jobRepo.FindActive(&models.ComicJob{ComicID: comicID, SectionID: &section.ID, Stage: stage})
+		if err != nil {
+			return fmt.Errorf("failed to check section jobs: %w", err)
+		}
+		if active != nil {
+			return fmt.Errorf("%w (job ID=%d)", ErrSectionBusy, active.ID)
+		}
+	}
+	return nil
+}
+
+// ensureRoleIdle 角色有未结束的原画任务时返回 ErrRoleBusy，避免重复记录同一张原画并覆盖排队中的提示词。
+func (s *ComicService) ensureRoleIdle(comicID, roleID uint) error {
+	active, err := s.jobRepo.FindActive(&models.ComicJob{ComicID: comicID, RoleID: &roleID, Stage: models.JobStageRolePortrait})
+	if err != nil {
+		return fmt.Errorf("failed to check role portrait jobs: %w", err)
+	}
+	if active != nil {
+		return fmt.Errorf("%w (job ID=%d)", ErrRoleBusy, active.ID)
+	}
+	return nil
+}
+
+func (s *ComicService) findComicSection(comicID, sectionID uint) (*models.ComicSection, error) {
+	section, err := s.sectionRepo.FindByID(sectionID)
+	if err != nil {
+		return nil, err
+	}
+	if section.ComicID != comicID {
+		return nil, ErrNotFound
+	}
+	return section, nil
+}
+
+func (s *ComicService) findComicRole(comicID, roleID uint) (*models.ComicRole, error) {
+	role, err := s.roleRepo.FindByID(roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role.ComicID != comicID {
+		return nil, ErrNotFound
+	}
+	return role, nil
+}
+
+// regeneratePageImage 根据页面记录重新渲染单页图片。
+func (s *ComicService) regeneratePageImage(ctx context.Context, comic *models.Comic, pageID uint) error {
+	page, section, err := s.findComicPage(comic.ID, pageID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) || errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn("[Regenerate] Page ID=%d no longer exists or was archived by a storyboard revision, skipping", pageID)
+			return nil
+		}
+		return err
+	}
+
+	roles, err := s.roleRepo.FindByComicID(comic.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get roles: %w", err)
+	}
+	characterAssets, err := s.LoadCharacterAssets(ctx, comic.ID)
+	if err != nil {
+		logger.Warn("[Regenerate] Failed to load character assets: %v", err)
+		characterAssets = make(map[string]*CharacterAsset)
+	}
+
+	storyboardPage := storyboardPageFromRecords(page, roles)
+	features := characterFeaturesFromRoles(roles)
+	label := fmt.Sprintf("Page ID=%d", page.ID)
+
+	if err := s.pageRepo.MarkImageGenerating(page.ID); err != nil {
+		logger.Warn("[Regenerate] %s: Failed to mark image generating: %v", label, err)
+	}
+
+	imageData, rects, err := s.renderPageImageWithQA(ctx, comic, page.ID, storyboardPage, features, characterAssets, label)
+	if err != nil && jobStopped(ctx) {
+		s.stopPageImage(page.ID, label)
+		return context.Cause(ctx)
+	}
+	if err != nil {
+		s.failPageImage(comic.ID, section, page.ID, err, label)
+		return fmt.Errorf("failed to regenerate page %d: %w", page.ID, err)
+	}
+	// 生成期间章节分镜可能已重新生成，归档的页面不再写入新图片
+	if current, err := s.pageRepo.FindByID(page.ID); err == nil && current.RevisionID != nil {
+		logger.Warn("[Regenerate] %s: Page was archived while rendering, discarding the new image", label)
+		return nil
+	}
+	// 旧图片的分格区域已不再适用，嵌字图层由 storePageImage 重置
+	if rects == nil {
+		if err := s.pageRepo.UpdatePanelRects(page.ID, nil); err != nil {
+			logger.Error("[Regenerate] %s: Failed to clear panel rects: %v", label, err)
+		}
+	}
+	return s.storePageImage(comic.ID, section, page.ID, imageData, storyboardPage, rects, label)
+}
+
+// regenerateRolePortrait 按角色保存的原画提示词重新生成原画，开启角色设定表时一并刷新设定表。
+// 新原画使用新的存储 key，旧原画保留给修订记录。
+func (s *ComicService) regenerateRolePortrait(ctx context.Context, comic *models.Comic, roleID uint) error {
+	role, err := s.findComicRole(comic.ID, roleID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) || errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn("[Regenerate] Role ID=%d no longer exists, skipping", roleID)
+			return nil
+		}
+		return err
+	}
+
+	prompt := role.ConceptArtPrompt
+	if prompt == "" {
+		prompt = defaultConceptArtPrompt(role)
+	}
+	style := strings.TrimSpace(comic.UserPrompt)
+	fullPrompt := strings.TrimSpace(style + " " + prompt)
+
+	logger.Info("[Regenerate] Character %s: Generating concept art", role.Name)
+	imageData, err := s.generateImageByText(ctx, fullPrompt)
+	if err != nil {
+		return fmt.Errorf("failed to generate concept art for %s: %w", role.Name, err)
+	}
+
+	imageID := fmt.Sprintf("character_%d_%d", role.ID, time.Now().UnixNano())
+	if err := s.storage.UploadBytes(imageData, imageID); err != nil {
+		return fmt.Errorf("failed to upload concept art for %s: %w", role.Name, err)
+	}
+	role.ImageID = imageID
+	if err := s.roleRepo.Update(role); err != nil {
+		return fmt.Errorf("failed to update role %s: %w", role.Name, err)
+	}
+	logger.Info("[Regenerate] Character %s: Concept art uploaded (imageID=%s)", role.Name, imageID)
+	s.events.Publish(comic.ID, ProgressEvent{Type: EventRolePortraitReady, RoleID: formatID(role.ID), ImageID: imageID, Message: role.Name})
+
+	if s.cfg.CharacterSheetsEnabled {
+		s.syncCharacterSheet(ctx, role, &CharacterAsset{ImageData: imageData, Prompt: fullPrompt}, style, true)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+	"time"
+
+	"github.com/cohesion-dev/GNX/ai/gnxaigc"
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/logger"
+)
+
+var (
+	// ErrRoleNameTaken 漫画中已有同名角色。角色按名称匹配分镜中的出场人物，名称必须唯一。
+	ErrRoleNameTaken = errors.New("role name already exists")
+	// ErrInvalidRole 角色信息不完整或音色不在可用列表中。
+	ErrInvalidRole = errors.New("invalid role")
+	// ErrInvalidImage 上传的原画不是 PNG 或 JPEG 图片。
+	ErrInvalidImage = errors.New("invalid image")
+)
+
+// RoleInput 新建或修改角色时提交的字段，nil 表示保持不变。
+type RoleInput struct {
+	Name   *string
+	Brief  *string
+	Gender *string
+	Age    *string
+	// VoiceType 音色类型，音色名称按可用音色列表自动填写，空字符串表示清除音色
+	VoiceType *string
+	// ConceptArtPrompt 原画提示词，重新生成原画与设定表时使用
+	ConceptArtPrompt *string
+}
+
+// ListRoles 列出漫画的全部角色。
+func (s *ComicService) ListRoles(comicID uint) ([]models.ComicRole, error) {
+	if _, err := s.comicRepo.FindByID(comicID); err != nil {
+		return nil, err
+	}
+	return s.roleRepo.FindByComicID(comicID)
+}
+
+// GetRole 返回角色及其设定表图片。
+func (s *ComicService) GetRole(comicID, roleID uint) (*models.ComicRole, []models.ComicRoleAsset, error) {
+	role, err := s.findComicRole(comicID, roleID)
+	if err != nil {
+		return nil, nil, err
+	}
+	assets, err := s.roleAssetRepo.FindByRoleID(role.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get role assets: %w", err)
+	}
+	return role, assets, nil
+}
+
+// CreateRole 手动添加角色。原画在角色首次出场渲染时生成，也可以随后上传或重新生成。
+func (s *ComicService) CreateRole(ctx context.Context, comicID uint, input RoleInput) (*models.ComicRole, error) {
+	if _, err := s.comicRepo.FindByID(comicID); err != nil {
+		return nil, err
+	}
+	if input.Name == nil || strings.TrimSpace(*input.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidRole)
+	}
+
+	role := &models.ComicRole{ComicID: comicID}
+	if err := s.applyRoleInput(ctx, role, input); err != nil {
+		return nil, err
+	}
+	if err := s.roleRepo.Create(role); err != nil {
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+	logger.Info("[Roles] Role %s created for comic ID=%d (ID=%d)", role.Name, comicID, role.ID)
+	return role, nil
+}
+
+// UpdateRole 修改角色信息。后续生成的分镜与页面按修改后的角色画像、音色与原画保持一致；
+// 改名时同步更新已有对白记录中的出场角色名，保证重新渲染旧页面时仍能匹配到角色；
+// 更换或清除音色时同步更新该角色已有对白的音色（清除后对白改用旁白音色），并重新预合成受影响章节的语音。
+func (s *ComicService) UpdateRole(ctx context.Context, comicID, roleID uint, input RoleInput) (*models.ComicRole, error) {
+	role, err := s.findComicRole(comicID, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldName, oldVoiceType := role.Name, role.VoiceType
+	if err := s.applyRoleInput(ctx, role, input); err != nil {
+		return nil, err
+	}
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+	if role.Name != oldName {
+		if err := s.pageRepo.RenameCharacter(comicID, oldName, role.Name); err != nil {
+			logger.Error("[Roles] Failed to rename %s to %s in page details: %v", oldName, role.Name, err)
+		}
+	}
+	if role.VoiceType != oldVoiceType {
+		if err := s.pageRepo.UpdateRoleVoice(role.ID, role.VoiceName, role.VoiceType); err != nil {
+			logger.Error("[Roles] Failed to apply voice %q to dialogue of %s: %v", role.VoiceType, role.Name, err)
+		} else {
+			s.refreshComicAudio(comicID)
+		}
+	}
+	logger.Info("[Roles] Role ID=%d updated: %s", role.ID, role.Name)
+	return role, nil
+}
+
+// DeleteRole 删除角色及其设定表图片，已有对白保留内容但不再关联该角色。
+func (s *ComicService) DeleteRole(comicID, roleID uint) error {
+	role, err := s.findComicRole(comicID, roleID)
+	if err != nil {
+		return err
+	}
+	if err := s.roleRepo.Delete(role.ID); err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	logger.Info("[Roles] Role %s (ID=%d) deleted from comic ID=%d", role.Name, role.ID, comicID)
+	return nil
+}
+
+// UploadRolePortrait 以上传的图片替换角色原画。当前原画保存为修订记录，可以回退；
+// 已有的设定表图片随之清除，下次渲染时以新原画为参考重新生成。
+func (s *ComicService) UploadRolePortrait(comicID, roleID uint, imageData []byte) (*models.ComicRole, *models.AssetRevision, error) {
+	role, err := s.findComicRole(comicID, roleID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, format, err := image.DecodeConfig(bytes.NewReader(imageData)); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	} else if format != "png" && format != "jpeg" {
+		return nil, nil, fmt.Errorf("%w: unsupported format %s", ErrInvalidImage, format)
+	}
+
+	revision := &models.AssetRevision{
+		ComicID:    comicID,
+		TargetType: models.RevisionTargetRolePortrait,
+		TargetID:   role.ID,
+		Prompt:     role.ConceptArtPrompt,
+		ImageID:    role.ImageID,
+	}
+	if err := s.revisionRepo.Create(revision); err != nil {
+		return nil, nil, fmt.Errorf("failed to save current role portrait: %w", err)
+	}
+
+	imageID := fmt.Sprintf("character_%d_%d", role.ID, time.Now().UnixNano())
+	if err := s.storage.UploadBytes(imageData, imageID); err != nil {
+		return nil, nil, fmt.Errorf("failed to upload portrait: %w", err)
+	}
+	role.ImageID = imageID
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, nil, fmt.Errorf("failed to update role: %w", err)
+	}
+	if err := s.roleAssetRepo.DeleteByRoleID(role.ID); err != nil {
+		logger.Warn("[Roles] Failed to clear character sheet of %s: %v", role.Name, err)
+	}
+
+	logger.Info("[Roles] Custom portrait uploaded for %s (imageID=%s, revision ID=%d)", role.Name, imageID, revision.ID)
+	s.events.Publish(comicID, ProgressEvent{Type: EventRolePortraitReady, RoleID: formatID(role.ID), ImageID: imageID, Message: role.Name})
+	return role, revision, nil
+}
+
+// applyRoleInput 校验并写入角色字段：名称在漫画内唯一，音色必须在可用音色列表中。
+func (s *ComicService) applyRoleInput(ctx context.Context, role *models.ComicRole, input RoleInput) error {
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			return fmt.Errorf("%w: name is required", ErrInvalidRole)
+		}
+		if name != role.Name {
+			roles, err := s.roleRepo.FindByComicID(role.ComicID)
+			if err != nil {
+				return fmt.Errorf("failed to get roles: %w", err)
+			}
+			for _, other := range roles {
+				if other.ID != role.ID && other.Name == name {
+					return ErrRoleNameTaken
+				}
+			}
+		}
+		role.Name = name
+	}
+	if input.Brief != nil {
+		role.Brief = strings.TrimSpace(*input.Brief)
+	}
+	if input.Gender != nil {
+		role.Gender = strings.TrimSpace(*input.Gender)
+	}
+	if input.Age != nil {
+		role.Age = strings.TrimSpace(*input.Age)
+	}
+	if input.ConceptArtPrompt != nil {
+		role.ConceptArtPrompt = strings.TrimSpace(*input.ConceptArtPrompt)
+	}
+	if input.VoiceType != nil {
+		voiceType := strings.TrimSpace(*input.VoiceType)
+		if voiceType == "" {
+			role.VoiceName, role.VoiceType = "", ""
+			return nil
+		}
+		voice, err := s.findVoice(ctx, voiceType)
+		if err != nil {
+			return err
+		}
+		if voice == nil {
+			return fmt.Errorf("%w: unknown voice_type %s", ErrInvalidRole, voiceType)
+		}
+		role.VoiceName, role.VoiceType = voice.VoiceName, voice.VoiceType
+	}
+	return nil
+}
+
+// findVoice 在可用音色列表中查找音色，不存在时返回 nil。
+func (s *ComicService) findVoice(ctx context.Context, voiceType string) (*gnxaigc.VoiceItem, error) {
+	voices, err := s.aigc.GetVoiceList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voice list: %w", err)
+	}
+	for i := range voices {
+		if voices[i].VoiceType == voiceType {
+			return &voices[i], nil
+		}
+	}
+	return nil, nil
+}
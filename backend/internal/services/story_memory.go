@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cohesion-dev/GNX/ai/gnxaigc"
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// loadStoryMemory 读取生成第 sectionIndex 章分镜时应注入的剧情记忆，即读完上一章后的快照，尚未积累时返回 nil。
+// 重新生成早先章节的分镜时同样注入该章之前的快照，不会提前透露后续剧情。
+func (s *ComicService) loadStoryMemory(comicID uint, sectionIndex int) *gnxaigc.StoryMemory {
+	if sectionIndex <= 1 {
+		return nil
+	}
+
+	previous, err := s.sectionRepo.FindByComicIDAndIndex(comicID, sectionIndex-1)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Warn("[Story Memory] Failed to load section %d of comic ID=%d: %v", sectionIndex-1, comicID, err)
+		return nil
+	}
+	if err == nil {
+		memory := &gnxaigc.StoryMemory{Synopsis: previous.StorySynopsis, WorldState: previous.StoryWorldState}
+		if !memory.IsEmpty() {
+			return memory
+		}
+	}
+
+	// 章节快照出现之前的数据只有漫画上的滚动记忆，恰好停在上一章时仍可使用
+	comic, err := s.comicRepo.FindByID(comicID)
+	if err != nil {
+		logger.Warn("[Story Memory] Failed to load story memory for comic ID=%d: %v", comicID, err)
+		return nil
+	}
+	if comic.StoryMemoryIndex != sectionIndex-1 {
+		logger.Info("[Story Memory] No story memory snapshot before section %d of comic ID=%d, not injecting", sectionIndex, comicID)
+		return nil
+	}
+	memory := &gnxaigc.StoryMemory{
+		Synopsis:   comic.StorySynopsis,
+		WorldState: comic.StoryWorldState,
+	}
+	if memory.IsEmpty() {
+		return nil
+	}
+	return memory
+}
+
+// updateStoryMemory 在章节分镜完成后让语言模型滚动更新剧情记忆，保存为该章的快照，失败时保留原有记忆。
+// 章节已有快照时（重新生成分镜）直接复用；漫画上的滚动记忆只向后推进。
+func (s *ComicService) updateStoryMemory(ctx context.Context, comic *models.Comic, section *models.ComicSection, previous *gnxaigc.StoryMemory) {
+	memory := &gnxaigc.StoryMemory{Synopsis: section.StorySynopsis, WorldState: section.StoryWorldState}
+	if !memory.IsEmpty() {
+		logger.Info("[Story Memory] Section ID=%d (index=%d) already has a story memory snapshot, reusing it", section.ID, section.Index)
+	} else {
+		logger.Info("[Story Memory] Updating story memory after section ID=%d (index=%d)", section.ID, section.Index)
+		updated, err := s.aigc.UpdateStoryMemory(ctx, gnxaigc.UpdateStoryMemoryInput{
+			NovelTitle:   comic.Title,
+			ChapterTitle: section.Title,
+			Content:      section.Content,
+			Previous:     previous,
+		})
+		if err != nil {
+			logger.Error("[Story Memory] Failed to update story memory for section ID=%d: %v", section.ID, err)
+			return
+		}
+		memory = updated
+		if err := s.sectionRepo.UpdateStoryMemory(section.ID, memory.Synopsis, memory.WorldState); err != nil {
+			logger.Error("[Story Memory] Failed to save story memory snapshot for section ID=%d: %v", section.ID, err)
+			return
+		}
+		logger.Info("[Story Memory] Story memory snapshot saved for section ID=%d: synopsis=%d runes, world_state=%d runes",
+			section.ID, len([]rune(memory.Synopsis)), len([]rune(memory.WorldState)))
+	}
+
+	current, err := s.comicRepo.FindByID(comic.ID)
+	if err != nil {
+		logger.Error("[Story Memory] Failed to load comic %d: %v", comic.ID, err)
+		return
+	}
+	if section.Index <= current.StoryMemoryIndex {
+		return
+	}
+	if err := s.comicRepo.UpdateStoryMemory(comic.ID, memory.Synopsis, memory.WorldState, section.Index); err != nil {
+		logger.Error("[Story Memory] Failed to save story memory for comic ID=%d: %v", comic.ID, err)
+	}
+}
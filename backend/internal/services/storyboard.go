@@ -0,0 +1,215 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cohesion-dev/GNX/ai/gnxaigc"
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/logger"
+)
+
+// saveStoryboardPage 将分镜页面完整写入页面、分格与对白记录，后续渲染只读取这些记录。
+func (s *ComicService) saveStoryboardPage(sectionID uint, index int, storyboardPage gnxaigc.StoryboardPage, roles []models.ComicRole) error {
+	page := &models.ComicPage{
+		SectionID:   sectionID,
+		Index:       index,
+		ImagePrompt: storyboardPage.ImagePrompt,
+		LayoutHint:  strings.TrimSpace(storyboardPage.LayoutHint),
+		PageSummary: strings.TrimSpace(storyboardPage.PageSummary),
+	}
+	if err := s.pageRepo.Create(page); err != nil {
+		return fmt.Errorf("failed to create page: %w", err)
+	}
+	logger.Info("[Section Processing] Created page %d (ID=%d) with %d panels", index, page.ID, len(storyboardPage.Panels))
+
+	roleIDs := make(map[string]uint, len(roles))
+	for _, role := range roles {
+		roleIDs[role.Name] = role.ID
+	}
+
+	for panelIndex, storyboardPanel := range storyboardPage.Panels {
+		panel := &models.ComicPanel{
+			PageID:       page.ID,
+			Index:        panelIndex,
+			PanelSummary: strings.TrimSpace(storyboardPanel.PanelSummary),
+			VisualPrompt: strings.TrimSpace(storyboardPanel.VisualPrompt),
+		}
+		if err := s.pageRepo.CreatePanel(panel); err != nil {
+			return fmt.Errorf("failed to create panel %d: %w", panelIndex+1, err)
+		}
+
+		for segmentIndex, segment := range storyboardPanel.SourceTextSegments {
+			var roleID *uint
+			if len(segment.CharacterNames) > 0 {
+				if id, ok := roleIDs[segment.CharacterNames[0]]; ok {
+					roleID = &id
+				}
+			}
+
+			speedRatio := segment.SpeedRatio
+			if speedRatio <= 0 {
+				speedRatio = 1.0
+			}
+
+			detail := &models.ComicPageDetail{
+				PageID:         page.ID,
+				PanelID:        &panel.ID,
+				Index:          (panelIndex * 100) + segmentIndex,
+				Content:        segment.Text,
+				RoleID:         roleID,
+				VoiceName:      segment.VoiceName,
+				VoiceType:      segment.VoiceType,
+				SpeedRatio:     speedRatio,
+				IsNarration:    segment.IsNarration,
+				CharacterNames: segment.CharacterNames,
+				Emotion:        gnxaigc.NormalizeEmotion(segment.Emotion),
+			}
+			if err := s.pageRepo.CreateDetail(detail); err != nil {
+				logger.Error("[Section Processing] Failed to create page detail: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// saveConceptArtPrompts 为尚未记录原画提示词的角色保存分镜给出的提示词，作为后续生成原画的依据。
+func (s *ComicService) saveConceptArtPrompts(roles []models.ComicRole, features []gnxaigc.CharacterFeature) {
+	prompts := make(map[string]string, len(features))
+	for _, feature := range features {
+		if prompt := strings.TrimSpace(feature.ConceptArtPrompt); prompt != "" {
+			prompts[strings.TrimSpace(feature.Basic.Name)] = prompt
+		}
+	}
+
+	for _, role := range roles {
+		prompt, ok := prompts[role.Name]
+		if !ok || role.ConceptArtPrompt != "" {
+			continue
+		}
+		if err := s.roleRepo.UpdateConceptArtPrompt(role.ID, prompt); err != nil {
+			logger.Error("[Section Processing] Failed to save concept art prompt for %s: %v", role.Name, err)
+		}
+	}
+}
+
+// storyboardPageFromRecords 由页面、分格与对白记录还原分镜页面。
+// 没有分格记录的旧数据按对白 Index/100 归入分格，此时分格没有画面描述。
+func storyboardPageFromRecords(page *models.ComicPage, roles []models.ComicRole) gnxaigc.StoryboardPage {
+	roleNames := make(map[uint]string, len(roles))
+	for _, role := range roles {
+		roleNames[role.ID] = role.Name
+	}
+
+	panels := slices.Clone(page.Panels)
+	slices.SortFunc(panels, func(a, b models.ComicPanel) int {
+		return a.Index - b.Index
+	})
+	details := slices.Clone(page.Details)
+	slices.SortFunc(details, func(a, b models.ComicPageDetail) int {
+		return a.Index - b.Index
+	})
+
+	storyboardPage := gnxaigc.StoryboardPage{
+		ImagePrompt: page.ImagePrompt,
+		LayoutHint:  page.LayoutHint,
+		PageSummary: page.PageSummary,
+	}
+	panelIndexes := make(map[uint]int, len(panels))
+	for _, panel := range panels {
+		panelIndexes[panel.ID] = len(storyboardPage.Panels)
+		storyboardPage.Panels = append(storyboardPage.Panels, gnxaigc.StoryboardPanel{
+			PanelSummary: panel.PanelSummary,
+			VisualPrompt: panel.VisualPrompt,
+		})
+	}
+
+	legacyIndexes := make(map[int]int)
+	for _, detail := range details {
+		var idx int
+		if detail.PanelID != nil {
+			var ok bool
+			if idx, ok = panelIndexes[*detail.PanelID]; !ok {
+				continue
+			}
+		} else {
+			key := detail.Index / 100
+			var ok bool
+			if idx, ok = legacyIndexes[key]; !ok {
+				idx = len(storyboardPage.Panels)
+				legacyIndexes[key] = idx
+				storyboardPage.Panels = append(storyboardPage.Panels, gnxaigc.StoryboardPanel{})
+			}
+		}
+		storyboardPage.Panels[idx].SourceTextSegments = append(storyboardPage.Panels[idx].SourceTextSegments, segmentFromDetail(detail, roleNames))
+	}
+	return storyboardPage
+}
+
+func segmentFromDetail(detail models.ComicPageDetail, roleNames map[uint]string) gnxaigc.SourceTextSegment {
+	segment := gnxaigc.SourceTextSegment{
+		Text:           detail.Content,
+		VoiceName:      detail.VoiceName,
+		VoiceType:      detail.VoiceType,
+		SpeedRatio:     detail.SpeedRatio,
+		IsNarration:    detail.IsNarration,
+		CharacterNames: detail.CharacterNames,
+		Emotion:        detail.Emotion,
+	}
+	if len(segment.CharacterNames) == 0 && detail.RoleID != nil {
+		if name, ok := roleNames[*detail.RoleID]; ok {
+			segment.CharacterNames = []string{name}
+		}
+	}
+	return segment
+}
+
+// characterFeaturesFromRoles 由角色记录构造分镜与渲染使用的角色画像。
+func characterFeaturesFromRoles(roles []models.ComicRole) []gnxaigc.CharacterFeature {
+	features := make([]gnxaigc.CharacterFeature, 0, len(roles))
+	for i := range roles {
+		role := &roles[i]
+		conceptArtPrompt := role.ConceptArtPrompt
+		if conceptArtPrompt == "" {
+			conceptArtPrompt = defaultConceptArtPrompt(role)
+		}
+		features = append(features, gnxaigc.CharacterFeature{
+			Basic: gnxaigc.CharacterBasicProfile{
+				Name:   role.Name,
+				Gender: role.Gender,
+				Age:    role.Age,
+			},
+			TTS: gnxaigc.CharacterTTSProfile{
+				VoiceName:  role.VoiceName,
+				VoiceType:  role.VoiceType,
+				SpeedRatio: 1.0,
+			},
+			Comment:          role.Brief,
+			ConceptArtPrompt: conceptArtPrompt,
+		})
+	}
+	return features
+}
+
+// appearingFeatures 筛选出在分镜页面中出场的角色画像。
+func appearingFeatures(pages []gnxaigc.StoryboardPage, features []gnxaigc.CharacterFeature) []gnxaigc.CharacterFeature {
+	names := make(map[string]bool)
+	for _, page := range pages {
+		for _, panel := range page.Panels {
+			for _, segment := range panel.SourceTextSegments {
+				for _, name := range segment.CharacterNames {
+					names[name] = true
+				}
+			}
+		}
+	}
+
+	var appearing []gnxaigc.CharacterFeature
+	for _, feature := range features {
+		if names[feature.Basic.Name] {
+			appearing = append(appearing, feature)
+		}
+	}
+	return appearing
+}
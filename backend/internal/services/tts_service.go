@@ -2,52 +2,390 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
 
 	"github.com/cohesion-dev/GNX/ai/gnxaigc"
+	"github.com/cohesion-dev/GNX/backend_new/config"
+	"github.com/cohesion-dev/GNX/backend_new/internal/models"
 	"github.com/cohesion-dev/GNX/backend_new/internal/repositories"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/audioutil"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/logger"
+	"github.com/cohesion-dev/GNX/backend_new/pkg/storage"
+)
+
+const (
+	// MinSpeedRatio 与 MaxSpeedRatio 为试听时允许的语速范围
+	MinSpeedRatio = 0.5
+	MaxSpeedRatio = 2.0
+	// MaxAuditionRunes 试听文本的字数上限
+	MaxAuditionRunes = 200
+	// auditionLineRunes 从角色对白中挑选试听台词时的字数上限，过长的台词试听等待时间太久
+	auditionLineRunes = 60
+	// auditionCacheSize 进程内缓存的试听语音条数，超出后淘汰最早写入的一条
+	auditionCacheSize = 64
 )
 
 type TTSService struct {
-	pageRepo *repositories.PageRepository
-	roleRepo *repositories.RoleRepository
-	aigc     *gnxaigc.GnxAIGC
+	comicRepo   *repositories.ComicRepository
+	sectionRepo *repositories.SectionRepository
+	pageRepo    *repositories.PageRepository
+	roleRepo    *repositories.RoleRepository
+	events      *EventBroker
+	storage     *storage.Storage
+	aigc        *gnxaigc.GnxAIGC
+	cfg         *config.TTSConfig
+	// ttsSlots 限制进程内同时调用语音合成的数量
+	ttsSlots  chan struct{}
+	auditions *auditionCache
 }
 
 func NewTTSService(
+	comicRepo *repositories.ComicRepository,
+	sectionRepo *repositories.SectionRepository,
 	pageRepo *repositories.PageRepository,
 	roleRepo *repositories.RoleRepository,
+	events *EventBroker,
+	storage *storage.Storage,
 	aigc *gnxaigc.GnxAIGC,
+	cfg *config.TTSConfig,
 ) *TTSService {
 	return &TTSService{
-		pageRepo: pageRepo,
-		roleRepo: roleRepo,
-		aigc:     aigc,
+		comicRepo:   comicRepo,
+		sectionRepo: sectionRepo,
+		pageRepo:    pageRepo,
+		roleRepo:    roleRepo,
+		events:      events,
+		storage:     storage,
+		aigc:        aigc,
+		cfg:         cfg,
+		ttsSlots:    make(chan struct{}, max(1, cfg.Concurrency)),
+		auditions:   newAuditionCache(auditionCacheSize),
 	}
 }
 
+// RegisterJobHandlers 注册对白语音预合成任务的处理函数。
+func (s *TTSService) RegisterJobHandlers(runner *JobRunner) {
+	runner.Register(models.JobStageTTS, s.runTTSJob)
+}
+
+// GetTTSAudio 返回对白语音。预合成的语音与当前音色、语速一致时直接读取；否则按当前设置合成，
+// 音色在每次请求时重新解析，缓存 key 随之变化，因此更换角色或旁白音色后旧的语音不会再被读到。
 func (s *TTSService) GetTTSAudio(ctx context.Context, detailID uint) ([]byte, error) {
 	detail, err := s.pageRepo.FindDetailByID(detailID)
 	if err != nil {
 		return nil, fmt.Errorf("detail not found: %w", err)
 	}
+	comicID, err := s.pageRepo.FindComicIDByDetailID(detailID)
+	if err != nil {
+		return nil, fmt.Errorf("comic of detail %d not found: %w", detailID, err)
+	}
+	comic, err := s.comicRepo.FindByID(comicID)
+	if err != nil {
+		return nil, fmt.Errorf("comic %d not found: %w", comicID, err)
+	}
+
+	voiceType, speedRatio := s.resolveVoice(comic, detail)
+
+	if detail.AudioKey != "" && detail.AudioKey == ttsCacheKey(detail.Content, voiceType, speedRatio) {
+		audioData, err := s.storage.DownloadBytes(detail.AudioKey)
+		if err == nil && len(audioData) > 0 {
+			return audioData, nil
+		}
+		logger.Warn("[TTS] Detail ID=%d: Failed to load stored audio %s, synthesizing: %v", detailID, detail.AudioKey, err)
+	}
 
-	fmt.Printf("Generating TTS for detail ID %d with content: %s\n", detailID, detail.Content)
+	logger.Info("[TTS] Detail ID=%d: voice=%s, speed=%.2f", detailID, voiceType, speedRatio)
+	return s.synthesize(ctx, detail.Content, voiceType, speedRatio)
+}
 
-	voiceType := "qiniu_zh_male_whxkxg"
-	speedRatio := 1.0
+// resolveVoice 返回合成片段所用的音色与语速。旁白统一使用漫画的旁白音色；对白使用分镜为片段选择的音色，
+// 没有时依次回退到关联角色的音色与旁白音色。语速取分镜选择的值，并限制在试听允许的范围内。
+func (s *TTSService) resolveVoice(comic *models.Comic, detail *models.ComicPageDetail) (string, float64) {
+	speedRatio := detail.SpeedRatio
+	if speedRatio <= 0 {
+		speedRatio = 1.0
+	}
+	speedRatio = min(max(speedRatio, MinSpeedRatio), MaxSpeedRatio)
 
+	narratorVoice := comic.NarratorVoiceType
+	if narratorVoice == "" {
+		narratorVoice = s.cfg.NarratorVoiceType
+	}
+	if detail.IsNarration {
+		return narratorVoice, speedRatio
+	}
+	if detail.VoiceType != "" {
+		return detail.VoiceType, speedRatio
+	}
 	if detail.RoleID != nil {
-		role, err := s.roleRepo.FindByID(*detail.RoleID)
-		if err == nil {
-			voiceType = role.VoiceType
+		for _, role := range comic.Roles {
+			if role.ID == *detail.RoleID && role.VoiceType != "" {
+				return role.VoiceType, speedRatio
+			}
 		}
 	}
+	return narratorVoice, speedRatio
+}
+
+// runTTSJob 为章节内全部对白预先合成语音，写入存储 key 与时长。任务重试时跳过参数未变且已合成的对白；
+// 全部对白合成完毕后章节的 audio_status 标记为 completed，章节才算完整可用。
+func (s *TTSService) runTTSJob(ctx context.Context, job *models.ComicJob) error {
+	if job.SectionID == nil {
+		return errors.New("tts job has no section")
+	}
 
-	audioData, err := s.aigc.TextToSpeechSimple(ctx, detail.Content, voiceType, speedRatio)
+	comic, err := s.comicRepo.FindByID(job.ComicID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate TTS: %w", err)
+		return fmt.Errorf("failed to get comic %d: %w", job.ComicID, err)
+	}
+	section, err := s.sectionRepo.FindByID(*job.SectionID)
+	if err != nil {
+		return fmt.Errorf("failed to get section %d: %w", *job.SectionID, err)
+	}
+
+	if _, err := s.sectionRepo.UpdateAudioStatusForJob(section.ID, job.ID, models.SectionAudioStatusGenerating); err != nil {
+		logger.Warn("[TTS Jobs] Failed to mark audio generating for section ID=%d: %v", section.ID, err)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Int32
+		total  int
+	)
+	for _, page := range section.Pages {
+		for _, detail := range page.Details {
+			if strings.TrimSpace(detail.Content) == "" {
+				continue
+			}
+			voiceType, speedRatio := s.resolveVoice(comic, &detail)
+			if detail.AudioKey != "" && detail.AudioKey == ttsCacheKey(detail.Content, voiceType, speedRatio) {
+				continue
+			}
+
+			total++
+			wg.Add(1)
+			go func(detail models.ComicPageDetail) {
+				defer wg.Done()
+				if err := s.renderDetailAudio(ctx, &detail, voiceType, speedRatio); err != nil {
+					logger.Error("[TTS Jobs] Detail ID=%d: %v", detail.ID, err)
+					failed.Add(1)
+				}
+			}(detail)
+		}
+	}
+	wg.Wait()
+
+	// 漫画被暂停或取消时保持当前语音状态，恢复生成后由重新执行的任务写入
+	if jobStopped(ctx) {
+		logger.Info("[TTS Jobs] Section ID=%d: Generation stopped, leaving audio status unchanged", section.ID)
+		return context.Cause(ctx)
+	}
+
+	// 本任务开始后对白或音色又有变化时，由更新的任务负责写入最终状态，本任务不再重试
+	if n := failed.Load(); n > 0 {
+		updated, err := s.sectionRepo.UpdateAudioStatusForJob(section.ID, job.ID, models.SectionAudioStatusFailed)
+		if err != nil {
+			logger.Warn("[TTS Jobs] Failed to mark audio failed for section ID=%d: %v", section.ID, err)
+		} else if !updated {
+			logger.Info("[TTS Jobs] Section ID=%d has a newer audio job, dropping %d failures of job ID=%d", section.ID, n, job.ID)
+			return nil
+		}
+		return fmt.Errorf("%d of %d segments failed to synthesize", n, total)
+	}
+	updated, err := s.sectionRepo.UpdateAudioStatusForJob(section.ID, job.ID, models.SectionAudioStatusCompleted)
+	if err != nil {
+		return fmt.Errorf("failed to mark audio completed for section %d: %w", section.ID, err)
+	}
+	if !updated {
+		logger.Info("[TTS Jobs] Section ID=%d has a newer audio job, leaving its audio status to that job", section.ID)
+		return nil
+	}
+
+	logger.Info("[TTS Jobs] Synthesized %d segments for section ID=%d", total, section.ID)
+	s.events.Publish(job.ComicID, ProgressEvent{Type: EventSectionAudioReady, SectionID: formatID(section.ID), SectionIndex: section.Index})
+	return nil
+}
+
+// renderDetailAudio 合成单条对白并保存到以合成参数哈希为 key 的存储中。预合成的语音必须落盘，
+// 因此不受 CacheEnabled 影响；已有相同 key 的语音时直接复用。
+func (s *TTSService) renderDetailAudio(ctx context.Context, detail *models.ComicPageDetail, voiceType string, speedRatio float64) error {
+	key := ttsCacheKey(detail.Content, voiceType, speedRatio)
+	audioData, err := s.storage.DownloadBytes(key)
+	if err != nil || len(audioData) == 0 {
+		audioData, err = s.textToSpeech(ctx, detail.Content, voiceType, speedRatio)
+		if err != nil {
+			return err
+		}
+		if err := s.storage.UploadBytes(audioData, key); err != nil {
+			return fmt.Errorf("failed to store audio: %w", err)
+		}
+	}
+
+	duration, err := audioutil.MP3Duration(audioData)
+	if err != nil {
+		logger.Warn("[TTS Jobs] Detail ID=%d: Failed to measure audio duration: %v", detail.ID, err)
+	}
+	if err := s.pageRepo.UpdateDetailAudio(detail.ID, key, int(duration.Milliseconds())); err != nil {
+		return fmt.Errorf("failed to save audio key: %w", err)
+	}
+	return nil
+}
+
+// AuditionOptions 试听参数。VoiceType 为空时使用角色当前的音色，SpeedRatio 为 0 时按 1.0 合成，
+// Text 为空时从角色的对白中挑选一句台词。
+type AuditionOptions struct {
+	VoiceType  string
+	SpeedRatio float64
+	Text       string
+}
+
+// AuditionRole 用候选音色与语速为角色合成一段试听语音，便于在修改角色音色前比较效果。
+// 试听结果按文本、音色与语速在进程内缓存最近 auditionCacheSize 条，不受 CacheEnabled 影响。
+// 用户自定义的试听文本不写入对象存储；从角色对白中挑选的台词与预合成语音共用对象存储中的缓存。
+func (s *TTSService) AuditionRole(ctx context.Context, comicID, roleID uint, opts AuditionOptions) ([]byte, error) {
+	role, err := s.roleRepo.FindByID(roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role.ComicID != comicID {
+		return nil, ErrNotFound
+	}
+
+	voiceType := strings.TrimSpace(opts.VoiceType)
+	if voiceType == "" {
+		voiceType = role.VoiceType
+	}
+	if voiceType == "" {
+		return nil, fmt.Errorf("%w: voice_type is required for a role without voice", ErrInvalidRole)
+	}
+	speedRatio := opts.SpeedRatio
+	if speedRatio == 0 {
+		speedRatio = 1.0
 	}
 
+	text := strings.TrimSpace(opts.Text)
+	synthesize := s.textToSpeech
+	if text == "" {
+		text = s.auditionLine(role.ID, role.Name)
+		synthesize = s.synthesize
+	}
+
+	key := ttsCacheKey(text, voiceType, speedRatio)
+	if audioData, ok := s.auditions.get(key); ok {
+		return audioData, nil
+	}
+
+	logger.Info("[TTS] Audition for role %s: voice=%s, speed=%.2f", role.Name, voiceType, speedRatio)
+	audioData, err := synthesize(ctx, text, voiceType, speedRatio)
+	if err != nil {
+		return nil, err
+	}
+	s.auditions.put(key, audioData)
 	return audioData, nil
 }
+
+// auditionLine 挑选角色对白中不超过 auditionLineRunes 字的最长一句作为试听台词，没有对白时使用自我介绍。
+func (s *TTSService) auditionLine(roleID uint, name string) string {
+	details, err := s.pageRepo.FindDialogueByRoleID(roleID)
+	if err != nil {
+		logger.Warn("[TTS] Failed to load dialogue of role ID=%d: %v", roleID, err)
+	}
+
+	best, bestRunes := "", 0
+	for _, detail := range details {
+		line := strings.TrimSpace(detail.Content)
+		n := utf8.RuneCountInString(line)
+		if n > bestRunes && n <= auditionLineRunes {
+			best, bestRunes = line, n
+		}
+	}
+	if best == "" {
+		best = fmt.Sprintf("你好，我是%s。", name)
+	}
+	return best
+}
+
+// auditionCache 按合成参数缓存试听语音的有界缓存，超出容量时淘汰最早写入的条目。
+type auditionCache struct {
+	mu    sync.Mutex
+	size  int
+	order []string
+	items map[string][]byte
+}
+
+func newAuditionCache(size int) *auditionCache {
+	return &auditionCache{size: size, items: make(map[string][]byte, size)}
+}
+
+func (c *auditionCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.items[key]
+	return data, ok
+}
+
+func (c *auditionCache) put(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[key]; ok {
+		c.items[key] = data
+		return
+	}
+	if len(c.order) >= c.size {
+		delete(c.items, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.order = append(c.order, key)
+	c.items[key] = data
+}
+
+// synthesize 合成语音。开启缓存时优先读取对象存储中参数相同的缓存，未命中时合成并写入缓存。
+func (s *TTSService) synthesize(ctx context.Context, text, voiceType string, speedRatio float64) ([]byte, error) {
+	if !s.cfg.CacheEnabled {
+		return s.textToSpeech(ctx, text, voiceType, speedRatio)
+	}
+
+	key := ttsCacheKey(text, voiceType, speedRatio)
+	if audioData, err := s.storage.DownloadBytes(key); err == nil && len(audioData) > 0 {
+		logger.Info("[TTS] Cache hit: %s", key)
+		return audioData, nil
+	}
+
+	audioData, err := s.textToSpeech(ctx, text, voiceType, speedRatio)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storage.UploadBytes(audioData, key); err != nil {
+		logger.Warn("[TTS] Failed to cache audio %s: %v", key, err)
+	}
+	return audioData, nil
+}
+
+// textToSpeech 在语音合成名额内调用 TTS，进程内同时合成的数量不超过 TTS_CONCURRENCY。
+func (s *TTSService) textToSpeech(ctx context.Context, text, voiceType string, speedRatio float64) ([]byte, error) {
+	select {
+	case s.ttsSlots <- struct{}{}:
+		defer func() { <-s.ttsSlots }()
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+
+	audioData, err := s.aigc.TextToSpeechSimple(ctx, text, voiceType, speedRatio)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TTS: %w", err)
+	}
+	return audioData, nil
+}
+
+// ttsCacheKey 由合成参数计算缓存的存储 key。音频编码固定为 mp3，同样计入哈希，更换编码时不会读到旧格式的缓存。
+func ttsCacheKey(text, voiceType string, speedRatio float64) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("mp3\n%s\n%.2f\n%s", voiceType, speedRatio, text)))
+	return "tts_" + hex.EncodeToString(sum[:]) + ".mp3"
+}
@@ -0,0 +1,20 @@
+package services
+
+import "testing"
+
+func TestAuditionCacheEvictsOldest(t *testing.T) {
+	c := newAuditionCache(2)
+	c.put("a", []byte("1"))
+	c.put("b", []byte("2"))
+	c.put("a", []byte("3"))
+	c.put("c", []byte("4"))
+
+	if _, ok := c.get("a"); ok {
+		t.Error("oldest entry a was not evicted")
+	}
+	for key, want := range map[string]string{"b": "2", "c": "4"} {
+		if data, ok := c.get(key); !ok || string(data) != want {
+			t.Errorf("get(%q) = %q, %t; want %q", key, data, ok, want)
+		}
+	}
+}
@@ -11,5 +11,6 @@ func NewAIGC(cfg *config.AIConfig) *gnxaigc.GnxAIGC {
 		BaseURL:       cfg.BaseURL,
 		ImageModel:    cfg.ImageModel,
 		LanguageModel: cfg.LanguageModel,
+		VisionModel:   cfg.VisionModel,
 	})
 }
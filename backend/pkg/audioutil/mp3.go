@@ -0,0 +1,83 @@
+// Package audioutil inspects synthesized speech audio.
+package audioutil
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoFrames is returned when the data contains no MPEG audio frames.
+var ErrNoFrames = errors.New("no mp3 frames found")
+
+// Bitrates in kbit/s for MPEG-1 and MPEG-2/2.5 Layer III, indexed by the header bitrate field.
+var (
+	mpeg1Bitrates = [15]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320}
+	mpeg2Bitrates = [15]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160}
+)
+
+// Sample rates in Hz indexed by the header version field (2.5, reserved, 2, 1) and sample rate field.
+var sampleRates = [4][3]int{
+	{11025, 12000, 8000},
+	{},
+	{22050, 24000, 16000},
+	{44100, 48000, 32000},
+}
+
+// MP3Duration sums the durations of the MPEG Layer III frames in data. A leading
+// ID3v2 tag is skipped and bytes that do not form a valid frame header are
+// stepped over, so trailing ID3v1 tags and stray padding do not matter.
+// Walking the frames works for both constant and variable bitrate streams.
+func MP3Duration(data []byte) (time.Duration, error) {
+	pos := id3v2Size(data)
+	samples, rate := 0.0, 0
+	for pos+4 <= len(data) {
+		frameLen, frameSamples, sampleRate := parseFrameHeader(data[pos : pos+4])
+		if frameLen == 0 || pos+frameLen > len(data) {
+			pos++
+			continue
+		}
+		samples += float64(frameSamples) / float64(sampleRate)
+		rate = sampleRate
+		pos += frameLen
+	}
+	if rate == 0 {
+		return 0, ErrNoFrames
+	}
+	return time.Duration(samples * float64(time.Second)), nil
+}
+
+// id3v2Size returns the length of a leading ID3v2 tag including its header and footer.
+func id3v2Size(data []byte) int {
+	if len(data) < 10 || string(data[:3]) != "ID3" {
+		return 0
+	}
+	size := int(data[6]&0x7f)<<21 | int(data[7]&0x7f)<<14 | int(data[8]&0x7f)<<7 | int(data[9]&0x7f)
+	size += 10
+	if data[5]&0x10 != 0 {
+		size += 10
+	}
+	return min(size, len(data))
+}
+
+// parseFrameHeader decodes a 4-byte Layer III frame header, returning the frame
+// length in bytes, its sample count and sample rate, or a zero length when the
+// bytes are not a valid header.
+func parseFrameHeader(h []byte) (length, samples, sampleRate int) {
+	if h[0] != 0xff || h[1]&0xe0 != 0xe0 {
+		return 0, 0, 0
+	}
+	version := int(h[1]>>3) & 0x03
+	layer := int(h[1]>>1) & 0x03
+	bitrateIndex := int(h[2] >> 4)
+	rateIndex := int(h[2]>>2) & 0x03
+	padding := int(h[2]>>1) & 0x01
+	if version == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return 0, 0, 0
+	}
+
+	sampleRate = sampleRates[version][rateIndex]
+	if version == 3 {
+		return 144*mpeg1Bitrates[bitrateIndex]*1000/sampleRate + padding, 1152, sampleRate
+	}
+	return 72*mpeg2Bitrates[bitrateIndex]*1000/sampleRate + padding, 576, sampleRate
+}
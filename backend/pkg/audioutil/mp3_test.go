@@ -0,0 +1,61 @@
+package audioutil
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// frames builds n silent MPEG-1 Layer III frames at 128 kbit/s and 44.1 kHz.
+func frames(n int) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xff, 0xfb, 0x90, 0x00})
+	return bytes.Repeat(frame, n)
+}
+
+func TestMP3Duration(t *testing.T) {
+	want := 100 * 1152 * time.Second / 44100
+
+	got, err := MP3Duration(frames(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := got - want; diff < -time.Millisecond || diff > time.Millisecond {
+		t.Fatalf("duration = %v, want %v", got, want)
+	}
+}
+
+func TestMP3DurationSkipsTags(t *testing.T) {
+	id3 := append([]byte("ID3\x04\x00\x00\x00\x00\x00\x14"), make([]byte, 20)...)
+	data := append(append(id3, frames(10)...), []byte("TAG some trailing id3v1 tag")...)
+
+	got, err := MP3Duration(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := 10 * 1152 * time.Second / 44100
+	if diff := got - want; diff < -time.Millisecond || diff > time.Millisecond {
+		t.Fatalf("duration = %v, want %v", got, want)
+	}
+}
+
+func TestMP3DurationMPEG2(t *testing.T) {
+	// MPEG-2 Layer III, 64 kbit/s, 24 kHz: 72*64000/24000 = 192 bytes, 576 samples per frame.
+	frame := make([]byte, 192)
+	copy(frame, []byte{0xff, 0xf3, 0x84, 0x00})
+
+	got, err := MP3Duration(bytes.Repeat(frame, 50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 1200 * time.Millisecond; got != want {
+		t.Fatalf("duration = %v, want %v", got, want)
+	}
+}
+
+func TestMP3DurationRejectsNonAudio(t *testing.T) {
+	if _, err := MP3Duration([]byte("not an mp3 file at all")); !errors.Is(err, ErrNoFrames) {
+		t.Fatalf("err = %v, want ErrNoFrames", err)
+	}
+}
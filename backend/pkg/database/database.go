@@ -29,8 +29,12 @@ func autoMigrate(db *gorm.DB) error {
 	return db.AutoMigrate(
 		&models.Comic{},
 		&models.ComicRole{},
+		&models.ComicRoleAsset{},
 		&models.ComicSection{},
 		&models.ComicPage{},
+		&models.ComicPanel{},
 		&models.ComicPageDetail{},
+		&models.ComicJob{},
+		&models.AssetRevision{},
 	)
 }
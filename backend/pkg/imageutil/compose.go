@@ -0,0 +1,125 @@
+package imageutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	DefaultPageWidth  = 1200
+	DefaultPageHeight = 1700
+	DefaultGutter     = 24
+	DefaultBorder     = 4
+)
+
+// PageComposeOptions controls the canvas used to compose a comic page from panel images.
+type PageComposeOptions struct {
+	Width  int
+	Height int
+	Gutter int
+	// Border is the panel outline thickness in pixels; a negative value disables it.
+	Border     int
+	Background color.Color
+	BorderInk  color.Color
+}
+
+func (o PageComposeOptions) withDefaults() PageComposeOptions {
+	if o.Width <= 0 {
+		o.Width = DefaultPageWidth
+	}
+	if o.Height <= 0 {
+		o.Height = DefaultPageHeight
+	}
+	if o.Gutter <= 0 {
+		o.Gutter = DefaultGutter
+	}
+	if o.Border < 0 {
+		o.Border = 0
+	} else if o.Border == 0 {
+		o.Border = DefaultBorder
+	}
+	if o.Background == nil {
+		o.Background = color.White
+	}
+	if o.BorderInk == nil {
+		o.BorderInk = color.Black
+	}
+	return o
+}
+
+// ComposePage draws each panel image into its slot of the layout, cropping to fill the slot,
+// and outlines every panel with a border. It returns the PNG page and the panel rectangles.
+func ComposePage(panels [][]byte, layout PageLayout, opts PageComposeOptions) ([]byte, []image.Rectangle, error) {
+	if len(panels) == 0 {
+		return nil, nil, errors.New("composePage: no panel images provided")
+	}
+	if layout.PanelCount() != len(panels) {
+		return nil, nil, fmt.Errorf("composePage: layout holds %d panels but %d images provided", layout.PanelCount(), len(panels))
+	}
+
+	opts = opts.withDefaults()
+	rects := layout.Rects(opts.Width, opts.Height, opts.Gutter)
+
+	canvas := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	xdraw.Draw(canvas, canvas.Bounds(), image.NewUniform(opts.Background), image.Point{}, xdraw.Src)
+
+	for idx, data := range panels {
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("composePage: decoding panel image %d: %w", idx+1, err)
+		}
+
+		rect := rects[idx]
+		xdraw.CatmullRom.Scale(canvas, rect, img, coverCrop(img.Bounds(), rect), xdraw.Src, nil)
+		strokeRect(canvas, rect, opts.Border, opts.BorderInk)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, canvas); err != nil {
+		return nil, nil, fmt.Errorf("composePage: encoding page image: %w", err)
+	}
+
+	return buf.Bytes(), rects, nil
+}
+
+// coverCrop returns the centered part of src whose aspect ratio matches dst.
+func coverCrop(src, dst image.Rectangle) image.Rectangle {
+	sw, sh := src.Dx(), src.Dy()
+	dw, dh := dst.Dx(), dst.Dy()
+	if sw == 0 || sh == 0 || dw == 0 || dh == 0 {
+		return src
+	}
+
+	if sw*dh > sh*dw {
+		w := sh * dw / dh
+		x := src.Min.X + (sw-w)/2
+		return image.Rect(x, src.Min.Y, x+w, src.Max.Y)
+	}
+
+	h := sw * dh / dw
+	y := src.Min.Y + (sh-h)/2
+	return image.Rect(src.Min.X, y, src.Max.X, y+h)
+}
+
+// strokeRect draws an inner border of the given thickness along the edges of rect.
+func strokeRect(dst *image.RGBA, rect image.Rectangle, thickness int, ink color.Color) {
+	if thickness <= 0 {
+		return
+	}
+	src := image.NewUniform(ink)
+	edges := []image.Rectangle{
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+thickness),
+		image.Rect(rect.Min.X, rect.Max.Y-thickness, rect.Max.X, rect.Max.Y),
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+thickness, rect.Max.Y),
+		image.Rect(rect.Max.X-thickness, rect.Min.Y, rect.Max.X, rect.Max.Y),
+	}
+	for _, edge := range edges {
+		xdraw.Draw(dst, edge, src, image.Point{}, xdraw.Src)
+	}
+}
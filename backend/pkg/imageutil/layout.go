@@ -0,0 +1,127 @@
+package imageutil
+
+import (
+	"image"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PageLayout describes a comic page as rows of equally wide panels, top to bottom.
+type PageLayout struct {
+	// Rows holds the number of panels in each row.
+	Rows []int
+}
+
+// PanelCount returns how many panels the layout holds.
+func (l PageLayout) PanelCount() int {
+	total := 0
+	for _, n := range l.Rows {
+		total += n
+	}
+	return total
+}
+
+var (
+	gridHintPattern = regexp.MustCompile(`(\d+)\s*[x×*]\s*(\d+)`)
+	rowHintPattern  = regexp.MustCompile(`\bsingle row\b|\bin a row\b|\bone row\b`)
+)
+
+// ParseLayout turns a storyboard layout hint such as "2x2 grid", "3-panel vertical strip"
+// or "wide top panel with two below" into a row layout that holds exactly panelCount panels.
+// Grid hints are read as rows x columns. Unknown hints fall back to a layout chosen by panel count.
+func ParseLayout(hint string, panelCount int) PageLayout {
+	if panelCount < 1 {
+		panelCount = 1
+	}
+	if panelCount == 1 {
+		return PageLayout{Rows: []int{1}}
+	}
+
+	h := strings.ToLower(strings.TrimSpace(hint))
+
+	switch {
+	case gridHintPattern.MatchString(h):
+		m := gridHintPattern.FindStringSubmatch(h)
+		rows, _ := strconv.Atoi(m[1])
+		cols, _ := strconv.Atoi(m[2])
+		if rows > 0 && cols > 0 {
+			return fitGrid(cols, panelCount)
+		}
+	case containsAny(h, "wide top", "top wide", "large top", "big top", "top panel spanning", "top splash", "wide-top", "establishing shot on top"):
+		return PageLayout{Rows: []int{1, panelCount - 1}}
+	case containsAny(h, "wide bottom", "bottom wide", "large bottom", "big bottom", "bottom panel spanning", "bottom splash", "wide-bottom"):
+		return PageLayout{Rows: []int{panelCount - 1, 1}}
+	case containsAny(h, "vertical", "stacked", "tier", "triptych", "strip"):
+		if containsAny(h, "horizontal", "side by side", "side-by-side", "column") {
+			return PageLayout{Rows: []int{panelCount}}
+		}
+		rows := make([]int, panelCount)
+		for i := range rows {
+			rows[i] = 1
+		}
+		return PageLayout{Rows: rows}
+	case containsAny(h, "horizontal", "side by side", "side-by-side", "column") || rowHintPattern.MatchString(h):
+		return PageLayout{Rows: []int{panelCount}}
+	}
+
+	return defaultLayout(panelCount)
+}
+
+func defaultLayout(panelCount int) PageLayout {
+	switch panelCount {
+	case 2:
+		return PageLayout{Rows: []int{1, 1}}
+	case 3:
+		return PageLayout{Rows: []int{1, 2}}
+	default:
+		return fitGrid(int(math.Ceil(math.Sqrt(float64(panelCount)))), panelCount)
+	}
+}
+
+// fitGrid fills rows of cols panels, letting the final row hold the remainder.
+func fitGrid(cols, panelCount int) PageLayout {
+	if cols > panelCount {
+		cols = panelCount
+	}
+	var rows []int
+	for remaining := panelCount; remaining > 0; remaining -= cols {
+		rows = append(rows, min(cols, remaining))
+	}
+	return PageLayout{Rows: rows}
+}
+
+func containsAny(s string, needles ...string) bool {
+	for _, n := range needles {
+		if strings.Contains(s, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rects lays the panels out on a width x height page separated by gutter pixels,
+// returning rectangles in reading order (left to right, top to bottom).
+func (l PageLayout) Rects(width, height, gutter int) []image.Rectangle {
+	if len(l.Rows) == 0 {
+		return nil
+	}
+
+	rects := make([]image.Rectangle, 0, l.PanelCount())
+	rowHeight := (height - gutter*(len(l.Rows)+1)) / len(l.Rows)
+	y := gutter
+	for _, cols := range l.Rows {
+		if cols < 1 {
+			continue
+		}
+		cellWidth := (width - gutter*(cols+1)) / cols
+		x := gutter
+		for c := 0; c < cols; c++ {
+			rects = append(rects, image.Rect(x, y, x+cellWidth, y+rowHeight))
+			x += cellWidth + gutter
+		}
+		y += rowHeight + gutter
+	}
+	return rects
+}
@@ -0,0 +1,73 @@
+package imageutil
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+func TestParseLayout(t *testing.T) {
+	tests := []struct {
+		hint   string
+		panels int
+		want   []int
+	}{
+		{hint: "2x2 grid", panels: 4, want: []int{2, 2}},
+		{hint: "2x2 grid", panels: 3, want: []int{2, 1}},
+		{hint: "3-panel vertical strip", panels: 3, want: []int{1, 1, 1}},
+		{hint: "vertical triptych", panels: 3, want: []int{1, 1, 1}},
+		{hint: "wide top panel with two panels below", panels: 3, want: []int{1, 2}},
+		{hint: "large bottom panel", panels: 4, want: []int{3, 1}},
+		{hint: "two panels side by side", panels: 2, want: []int{2}},
+		{hint: "", panels: 1, want: []int{1}},
+		{hint: "dynamic diagonal layout", panels: 3, want: []int{1, 2}},
+		{hint: "dynamic diagonal layout", panels: 5, want: []int{3, 2}},
+	}
+
+	for _, tc := range tests {
+		got := ParseLayout(tc.hint, tc.panels)
+		if !reflect.DeepEqual(got.Rows, tc.want) {
+			t.Errorf("ParseLayout(%q, %d) = %v, want %v", tc.hint, tc.panels, got.Rows, tc.want)
+		}
+		if got.PanelCount() != tc.panels {
+			t.Errorf("ParseLayout(%q, %d) holds %d panels", tc.hint, tc.panels, got.PanelCount())
+		}
+	}
+}
+
+func TestComposePage(t *testing.T) {
+	panel := &bytes.Buffer{}
+	src := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	for i := range src.Pix {
+		src.Pix[i] = 0x80
+	}
+	if err := png.Encode(panel, src); err != nil {
+		t.Fatal(err)
+	}
+
+	layout := ParseLayout("wide top", 3)
+	page, rects, err := ComposePage([][]byte{panel.Bytes(), panel.Bytes(), panel.Bytes()}, layout, PageComposeOptions{Width: 300, Height: 400, Gutter: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rects) != 3 {
+		t.Fatalf("got %d rects, want 3", len(rects))
+	}
+	if rects[0].Dx() <= rects[1].Dx() {
+		t.Errorf("top panel %v should be wider than %v", rects[0], rects[1])
+	}
+
+	img, err := png.Decode(bytes.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := color.GrayModel.Convert(img.At(5, 5)).(color.Gray).Y; got != 0xff {
+		t.Errorf("gutter pixel = %d, want white", got)
+	}
+	if got := color.GrayModel.Convert(img.At(rects[0].Min.X, rects[0].Min.Y)).(color.Gray).Y; got != 0 {
+		t.Errorf("border pixel = %d, want black", got)
+	}
+}
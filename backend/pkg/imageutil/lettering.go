@@ -0,0 +1,416 @@
+package imageutil
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// The embedded face is a subset of Noto Sans CJK SC Bold (SIL Open Font License, see
+// fonts/LICENSE) covering ASCII and GB2312; cmd/fontsubset regenerates it. Rarer characters
+// render as .notdef unless SetFontFile points at a full font.
+//
+//go:embed fonts/NotoSansCJKsc-Bold-subset.ttf
+var letteringFontData []byte
+
+var (
+	letteringFontMu  sync.Mutex
+	letteringFont    *opentype.Font
+	letteringFontErr error
+)
+
+// SetFontFile replaces the embedded lettering font with the font file at path.
+// On error the previously loaded font stays in use.
+func SetFontFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("SetFontFile: %w", err)
+	}
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return fmt.Errorf("SetFontFile: parsing %s: %w", path, err)
+	}
+
+	letteringFontMu.Lock()
+	defer letteringFontMu.Unlock()
+	letteringFont, letteringFontErr = f, nil
+	return nil
+}
+
+func loadLetteringFont() (*opentype.Font, error) {
+	letteringFontMu.Lock()
+	defer letteringFontMu.Unlock()
+	if letteringFont == nil && letteringFontErr == nil {
+		letteringFont, letteringFontErr = opentype.Parse(letteringFontData)
+	}
+	return letteringFont, letteringFontErr
+}
+
+// BalloonKind selects how a lettering item is framed on the page.
+type BalloonKind int
+
+const (
+	// BalloonSpeech is a rounded speech bubble with a tail, used for character lines.
+	BalloonSpeech BalloonKind = iota
+	// BalloonCaption is a square caption box pinned to the panel's top edge, used for narration.
+	BalloonCaption
+)
+
+// LetteringItem is one piece of text to place inside a panel.
+type LetteringItem struct {
+	Kind BalloonKind
+	Text string
+}
+
+// PanelLettering groups the lettering items of one panel with the panel's rectangle on the page.
+type PanelLettering struct {
+	Rect  image.Rectangle
+	Items []LetteringItem
+}
+
+// LetteringOptions tunes text size and balloon geometry; zero values pick sizes relative to the page.
+type LetteringOptions struct {
+	// FontSize in pixels; defaults to 1/40 of the page width.
+	FontSize float64
+	// MinFontSize bounds how far text shrinks to fit a crowded panel; defaults to 60% of FontSize.
+	MinFontSize float64
+	// MaxWidthRatio caps a balloon's width relative to its panel; defaults to 0.45.
+	MaxWidthRatio float64
+}
+
+func (o LetteringOptions) withDefaults(pageWidth int) LetteringOptions {
+	if o.FontSize <= 0 {
+		o.FontSize = math.Max(14, float64(pageWidth)/40)
+	}
+	if o.MinFontSize <= 0 || o.MinFontSize > o.FontSize {
+		o.MinFontSize = o.FontSize * 0.6
+	}
+	if o.MaxWidthRatio <= 0 || o.MaxWidthRatio > 1 {
+		o.MaxWidthRatio = 0.45
+	}
+	return o
+}
+
+var (
+	balloonInk   = color.Black
+	balloonPaper = color.White
+	captionPaper = color.RGBA{R: 0xff, G: 0xf6, B: 0xd5, A: 0xff}
+)
+
+// LetterPage draws speech bubbles and caption boxes onto a copy of the page image and returns it as PNG.
+// Items are stacked top to bottom inside their panel; when a panel is too crowded the text shrinks
+// down to MinFontSize before the remaining balloons are allowed to overflow.
+func LetterPage(pageImage []byte, panels []PanelLettering, opts LetteringOptions) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(pageImage))
+	if err != nil {
+		return nil, fmt.Errorf("letterPage: decoding page image: %w", err)
+	}
+
+	f, err := loadLetteringFont()
+	if err != nil {
+		return nil, fmt.Errorf("letterPage: loading font: %w", err)
+	}
+
+	bounds := src.Bounds()
+	canvas := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), src, bounds.Min, draw.Src)
+
+	opts = opts.withDefaults(bounds.Dx())
+
+	for idx, panel := range panels {
+		if len(panel.Items) == 0 {
+			continue
+		}
+		rect := panel.Rect.Intersect(canvas.Bounds())
+		if rect.Empty() {
+			return nil, fmt.Errorf("letterPage: panel %d rect %v is outside the page", idx+1, panel.Rect)
+		}
+		if err := letterPanel(canvas, f, rect, panel.Items, opts); err != nil {
+			return nil, fmt.Errorf("letterPage: panel %d: %w", idx+1, err)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, canvas); err != nil {
+		return nil, fmt.Errorf("letterPage: encoding lettered page: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type placedBalloon struct {
+	kind  BalloonKind
+	box   image.Rectangle
+	lines []string
+	tail  bool
+}
+
+func letterPanel(dst *image.RGBA, f *opentype.Font, rect image.Rectangle, items []LetteringItem, opts LetteringOptions) error {
+	var (
+		face   font.Face
+		placed []placedBalloon
+	)
+
+	for size := opts.FontSize; ; size *= 0.85 {
+		if size < opts.MinFontSize {
+			size = opts.MinFontSize
+		}
+		var err error
+		face, err = opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			return err
+		}
+		var fits bool
+		placed, fits = layoutBalloons(face, rect, items, opts)
+		if fits || size <= opts.MinFontSize {
+			break
+		}
+		face.Close()
+	}
+	defer face.Close()
+
+	for _, b := range placed {
+		drawBalloon(dst, face, b)
+	}
+	return nil
+}
+
+// layoutBalloons stacks captions first and then speech bubbles, alternating sides, and reports
+// whether everything fits inside rect.
+func layoutBalloons(face font.Face, rect image.Rectangle, items []LetteringItem, opts LetteringOptions) ([]placedBalloon, bool) {
+	metrics := face.Metrics()
+	lineHeight := metrics.Height.Ceil()
+	pad := lineHeight / 2
+	margin := max(lineHeight/3, 4)
+	maxTextWidth := int(float64(rect.Dx())*opts.MaxWidthRatio) - 2*pad
+	if maxTextWidth < lineHeight {
+		maxTextWidth = lineHeight
+	}
+
+	var placed []placedBalloon
+	y := rect.Min.Y + margin
+	speechCount := 0
+
+	ordered := make([]LetteringItem, 0, len(items))
+	for _, item := range items {
+		if item.Kind == BalloonCaption {
+			ordered = append(ordered, item)
+		}
+	}
+	for _, item := range items {
+		if item.Kind != BalloonCaption {
+			ordered = append(ordered, item)
+		}
+	}
+
+	for _, item := range ordered {
+		text := strings.TrimSpace(item.Text)
+		if text == "" {
+			continue
+		}
+
+		textWidth := maxTextWidth
+		if item.Kind == BalloonCaption {
+			textWidth = rect.Dx() - 2*margin - 2*pad
+		}
+		lines := wrapText(face, text, textWidth)
+
+		widest := 0
+		for _, line := range lines {
+			widest = max(widest, font.MeasureString(face, line).Ceil())
+		}
+		w := widest + 2*pad
+		h := len(lines)*lineHeight + 2*pad
+
+		var x int
+		switch {
+		case item.Kind == BalloonCaption:
+			x = rect.Min.X + margin
+		case speechCount%2 == 0:
+			x = rect.Min.X + margin
+		default:
+			x = rect.Max.X - margin - w
+		}
+		if item.Kind == BalloonSpeech {
+			// round bubbles need extra room for the curved ends
+			w += lineHeight
+			if speechCount%2 == 1 {
+				x -= lineHeight
+			}
+			speechCount++
+		}
+
+		box := image.Rect(x, y, x+w, y+h)
+		tail := item.Kind == BalloonSpeech
+		placed = append(placed, placedBalloon{kind: item.Kind, box: box, lines: lines, tail: tail})
+
+		y = box.Max.Y + margin
+		if tail {
+			y += lineHeight / 2
+		}
+	}
+
+	return placed, y <= rect.Max.Y
+}
+
+// wrapText breaks text into lines no wider than maxWidth. CJK text may break between any two
+// characters; runs of Latin letters and digits stay together, and closing punctuation never
+// starts a line.
+func wrapText(face font.Face, text string, maxWidth int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		lines = append(lines, wrapParagraph(face, paragraph, maxWidth)...)
+	}
+	return lines
+}
+
+func wrapParagraph(face font.Face, text string, maxWidth int) []string {
+	tokens := tokenize(text)
+	var (
+		lines []string
+		line  strings.Builder
+	)
+	limit := fixed.I(maxWidth)
+
+	for _, tok := range tokens {
+		candidate := line.String() + tok
+		if line.Len() == 0 || font.MeasureString(face, candidate) <= limit || isClosingPunct(tok) {
+			line.WriteString(tok)
+			continue
+		}
+		lines = append(lines, strings.TrimRightFunc(line.String(), unicode.IsSpace))
+		line.Reset()
+		line.WriteString(strings.TrimLeftFunc(tok, unicode.IsSpace))
+	}
+	if line.Len() > 0 || len(lines) == 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+func tokenize(text string) []string {
+	var (
+		tokens []string
+		word   strings.Builder
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		if r < unicode.MaxLatin1 && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			word.WriteRune(r)
+			continue
+		}
+		flush()
+		tokens = append(tokens, string(r))
+	}
+	flush()
+	return tokens
+}
+
+func isClosingPunct(tok string) bool {
+	return len([]rune(tok)) == 1 && strings.Contains("，。！？、；：”’」』）》…,.!?;:)", tok)
+}
+
+func drawBalloon(dst *image.RGBA, face font.Face, b placedBalloon) {
+	stroke := max(2, face.Metrics().Height.Ceil()/12)
+	box := b.box
+
+	switch b.kind {
+	case BalloonCaption:
+		draw.Draw(dst, box, image.NewUniform(balloonInk), image.Point{}, draw.Src)
+		draw.Draw(dst, box.Inset(stroke), image.NewUniform(captionPaper), image.Point{}, draw.Src)
+	default:
+		radius := float32(min(box.Dx(), box.Dy())) / 2
+		tailBase := float32(box.Min.X) + float32(box.Dx())*0.3
+		tailLen := float32(face.Metrics().Height.Ceil()) * 0.9
+		tailWidth := float32(face.Metrics().Height.Ceil()) * 0.8
+
+		fillPath(dst, balloonInk, func(r *vector.Rasterizer, off image.Point) {
+			roundedRectPath(r, off, box, radius)
+			if b.tail {
+				tailPath(r, off, tailBase, float32(box.Max.Y), tailWidth, tailLen)
+			}
+		}, box.Union(image.Rect(box.Min.X, box.Min.Y, box.Max.X, box.Max.Y+int(tailLen)+1)))
+
+		inner := box.Inset(stroke)
+		s := float32(stroke)
+		fillPath(dst, balloonPaper, func(r *vector.Rasterizer, off image.Point) {
+			roundedRectPath(r, off, inner, radius-s)
+			if b.tail {
+				tailPath(r, off, tailBase+s, float32(box.Max.Y)-s*2, tailWidth-s*2, tailLen-s*1.5)
+			}
+		}, box.Union(image.Rect(box.Min.X, box.Min.Y, box.Max.X, box.Max.Y+int(tailLen)+1)))
+	}
+
+	metrics := face.Metrics()
+	lineHeight := metrics.Height.Ceil()
+	d := &font.Drawer{Dst: dst, Src: image.NewUniform(balloonInk), Face: face}
+	y := box.Min.Y + (box.Dy()-len(b.lines)*lineHeight)/2 + metrics.Ascent.Ceil()
+	for _, line := range b.lines {
+		w := font.MeasureString(face, line).Ceil()
+		d.Dot = fixed.P(box.Min.X+(box.Dx()-w)/2, y)
+		d.DrawString(line)
+		y += lineHeight
+	}
+}
+
+// fillPath rasterizes the path built by build within area and composites it onto dst.
+func fillPath(dst *image.RGBA, ink color.Color, build func(r *vector.Rasterizer, off image.Point), area image.Rectangle) {
+	area = area.Intersect(dst.Bounds())
+	if area.Empty() {
+		return
+	}
+	r := vector.NewRasterizer(area.Dx(), area.Dy())
+	build(r, area.Min)
+	r.Draw(dst, area, image.NewUniform(ink), image.Point{})
+}
+
+func roundedRectPath(r *vector.Rasterizer, off image.Point, box image.Rectangle, radius float32) {
+	x0, y0 := float32(box.Min.X-off.X), float32(box.Min.Y-off.Y)
+	x1, y1 := float32(box.Max.X-off.X), float32(box.Max.Y-off.Y)
+	if radius < 0 {
+		radius = 0
+	}
+	// kappa approximates a quarter circle with a cubic Bézier curve.
+	const kappa = 0.5523
+	k := radius * kappa
+
+	r.MoveTo(x0+radius, y0)
+	r.LineTo(x1-radius, y0)
+	r.CubeTo(x1-radius+k, y0, x1, y0+radius-k, x1, y0+radius)
+	r.LineTo(x1, y1-radius)
+	r.CubeTo(x1, y1-radius+k, x1-radius+k, y1, x1-radius, y1)
+	r.LineTo(x0+radius, y1)
+	r.CubeTo(x0+radius-k, y1, x0, y1-radius+k, x0, y1-radius)
+	r.LineTo(x0, y0+radius)
+	r.CubeTo(x0, y0+radius-k, x0+radius-k, y0, x0+radius, y0)
+	r.ClosePath()
+}
+
+func tailPath(r *vector.Rasterizer, off image.Point, baseX, baseY, width, length float32) {
+	if width <= 0 || length <= 0 {
+		return
+	}
+	ox, oy := float32(off.X), float32(off.Y)
+	r.MoveTo(baseX-ox, baseY-oy-width)
+	r.LineTo(baseX+width-ox, baseY-oy-width)
+	r.LineTo(baseX-width*0.6-ox, baseY+length-oy)
+	r.ClosePath()
+}
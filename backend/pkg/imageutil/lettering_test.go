@@ -0,0 +1,84 @@
+package imageutil
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+)
+
+func TestWrapTextKeepsPunctuationAndWords(t *testing.T) {
+	f, err := loadLetteringFont()
+	if err != nil {
+		t.Fatal(err)
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: 20, DPI: 72})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer face.Close()
+
+	width := font.MeasureString(face, "三叔好三叔").Ceil()
+	lines := wrapText(face, "三叔好三叔好，GNX漫画", width)
+	if len(lines) < 2 {
+		t.Fatalf("expected wrapped lines, got %q", lines)
+	}
+	for _, line := range lines {
+		if r := []rune(line); isClosingPunct(string(r[0])) {
+			t.Errorf("line %q starts with closing punctuation", line)
+		}
+	}
+	whole := false
+	for _, line := range lines {
+		whole = whole || bytes.Contains([]byte(line), []byte("GNX"))
+	}
+	if !whole {
+		t.Errorf("latin word split across lines: %q", lines)
+	}
+}
+
+func TestLetterPage(t *testing.T) {
+	page := image.NewRGBA(image.Rect(0, 0, 600, 800))
+	for i := range page.Pix {
+		page.Pix[i] = 0xff
+	}
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, page); err != nil {
+		t.Fatal(err)
+	}
+
+	rects := ParseLayout("2x2 grid", 4).Rects(600, 800, 12)
+	out, err := LetterPage(buf.Bytes(), []PanelLettering{
+		{Rect: rects[0], Items: []LetteringItem{
+			{Kind: BalloonCaption, Text: "第二天中午时分，韩立背着木柴从山里赶回家。"},
+			{Kind: BalloonSpeech, Text: "三叔好！"},
+		}},
+		{Rect: rects[3], Items: []LetteringItem{{Kind: BalloonSpeech, Text: "听话，懂事。"}}},
+	}, LetteringOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != page.Bounds() {
+		t.Fatalf("bounds = %v, want %v", img.Bounds(), page.Bounds())
+	}
+
+	inked := 0
+	for y := rects[0].Min.Y; y < rects[0].Max.Y; y++ {
+		for x := rects[0].Min.X; x < rects[0].Max.X; x++ {
+			if r, _, _, _ := img.At(x, y).RGBA(); r < 0x4000 {
+				inked++
+			}
+		}
+	}
+	if inked == 0 {
+		t.Error("expected lettering ink inside the first panel")
+	}
+}
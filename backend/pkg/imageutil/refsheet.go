@@ -0,0 +1,244 @@
+package imageutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+
+	_ "image/gif"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	DefaultReferenceTileHeight = 512
+	DefaultReferenceMaxSide    = 2048
+	DefaultReferenceMaxBytes   = 4 << 20
+
+	minReferenceTileHeight = 96
+)
+
+// ReferenceTile is one reference image on a reference sheet, labeled with the name printed under it.
+type ReferenceTile struct {
+	Label string
+	Image []byte
+}
+
+// ReferenceSheetOptions bounds the size of a composed reference sheet; zero values pick the defaults.
+type ReferenceSheetOptions struct {
+	// TileHeight is the height every image is scaled to before any downscaling to fit the caps.
+	TileHeight int
+	// MaxWidth and MaxHeight cap the sheet dimensions in pixels.
+	MaxWidth  int
+	MaxHeight int
+	// MaxBytes caps the encoded payload; the sheet falls back to JPEG and then shrinks to fit.
+	MaxBytes int
+	// Columns fixes the grid width; by default the grid is as square as possible.
+	Columns int
+	Gutter  int
+}
+
+func (o ReferenceSheetOptions) withDefaults(tileCount int) ReferenceSheetOptions {
+	if o.TileHeight <= 0 {
+		o.TileHeight = DefaultReferenceTileHeight
+	}
+	if o.MaxWidth <= 0 {
+		o.MaxWidth = DefaultReferenceMaxSide
+	}
+	if o.MaxHeight <= 0 {
+		o.MaxHeight = DefaultReferenceMaxSide
+	}
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = DefaultReferenceMaxBytes
+	}
+	if o.Columns <= 0 {
+		o.Columns = int(math.Ceil(math.Sqrt(float64(tileCount))))
+	}
+	o.Columns = min(o.Columns, tileCount)
+	if o.Gutter <= 0 {
+		o.Gutter = 16
+	}
+	return o
+}
+
+// ComposeReferenceSheet arranges reference images in a grid on a white sheet. Every image is scaled
+// to a common height and labeled underneath; the sheet is shrunk until it fits MaxWidth x MaxHeight
+// and its encoding fits MaxBytes. When even the smallest tiles exceed the caps, tiles are dropped
+// from the end, so callers should order them by priority. It returns PNG data, or JPEG when PNG
+// exceeds the payload cap, together with the number of leading tiles placed on the sheet.
+func ComposeReferenceSheet(tiles []ReferenceTile, opts ReferenceSheetOptions) ([]byte, int, error) {
+	if len(tiles) == 0 {
+		return nil, 0, errors.New("composeReferenceSheet: no reference images provided")
+	}
+
+	images := make([]image.Image, 0, len(tiles))
+	for idx, tile := range tiles {
+		img, _, err := image.Decode(bytes.NewReader(tile.Image))
+		if err != nil {
+			return nil, 0, fmt.Errorf("composeReferenceSheet: decoding reference image %d: %w", idx+1, err)
+		}
+		if img.Bounds().Empty() {
+			return nil, 0, fmt.Errorf("composeReferenceSheet: reference image %d is empty", idx+1)
+		}
+		images = append(images, img)
+	}
+
+	f, err := loadLetteringFont()
+	if err != nil {
+		return nil, 0, fmt.Errorf("composeReferenceSheet: loading font: %w", err)
+	}
+
+	for count := len(images); count > 0; count-- {
+		data, err := composeSheet(f, tiles[:count], images[:count], opts.withDefaults(count))
+		if err != nil {
+			return nil, 0, err
+		}
+		if data != nil {
+			return data, count, nil
+		}
+	}
+	caps := opts.withDefaults(1)
+	return nil, 0, fmt.Errorf(
+		"composeReferenceSheet: a single tile exceeds %dx%d pixels or %d bytes even at minimum size",
+		caps.MaxWidth, caps.MaxHeight, caps.MaxBytes,
+	)
+}
+
+// composeSheet lays out all the given tiles within the caps, returning nil data when they do not fit
+// even at the minimum tile height.
+func composeSheet(f *opentype.Font, tiles []ReferenceTile, images []image.Image, opts ReferenceSheetOptions) ([]byte, error) {
+	rows := (len(images) + opts.Columns - 1) / opts.Columns
+
+	// The widest image decides the cell width, so the sheet size is linear in the tile height.
+	maxAspect := 0.0
+	for _, img := range images {
+		maxAspect = math.Max(maxAspect, float64(img.Bounds().Dx())/float64(img.Bounds().Dy()))
+	}
+	sheetSize := func(tileHeight int) (int, int) {
+		cellWidth := int(math.Ceil(float64(tileHeight) * maxAspect))
+		width := opts.Columns*cellWidth + (opts.Columns+1)*opts.Gutter
+		height := rows*(tileHeight+labelHeight(tileHeight)) + (rows+1)*opts.Gutter
+		return width, height
+	}
+
+	tileHeight := opts.TileHeight
+	for tileHeight > minReferenceTileHeight {
+		width, height := sheetSize(tileHeight)
+		if width <= opts.MaxWidth && height <= opts.MaxHeight {
+			break
+		}
+		scale := math.Min(float64(opts.MaxWidth)/float64(width), float64(opts.MaxHeight)/float64(height))
+		tileHeight = max(minReferenceTileHeight, min(tileHeight-1, int(float64(tileHeight)*scale)))
+	}
+	if width, height := sheetSize(tileHeight); width > opts.MaxWidth || height > opts.MaxHeight {
+		return nil, nil
+	}
+
+	for {
+		width, height := sheetSize(tileHeight)
+		sheet, err := drawReferenceSheet(f, tiles, images, opts, tileHeight, width, height)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := encodeWithinBytes(sheet, opts.MaxBytes)
+		if err != nil || data != nil {
+			return data, err
+		}
+		if tileHeight <= minReferenceTileHeight {
+			return nil, nil
+		}
+		tileHeight = max(minReferenceTileHeight, tileHeight*3/4)
+	}
+}
+
+func labelHeight(tileHeight int) int {
+	return max(20, tileHeight/8)
+}
+
+func drawReferenceSheet(
+	f *opentype.Font,
+	tiles []ReferenceTile,
+	images []image.Image,
+	opts ReferenceSheetOptions,
+	tileHeight, width, height int,
+) (*image.RGBA, error) {
+	sheet := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(sheet, sheet.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	cellWidth := (width - (opts.Columns+1)*opts.Gutter) / opts.Columns
+	cellHeight := tileHeight + labelHeight(tileHeight)
+
+	for idx, img := range images {
+		col, row := idx%opts.Columns, idx/opts.Columns
+		x := opts.Gutter + col*(cellWidth+opts.Gutter)
+		y := opts.Gutter + row*(cellHeight+opts.Gutter)
+
+		b := img.Bounds()
+		tileWidth := min(cellWidth, int(math.Round(float64(b.Dx())*float64(tileHeight)/float64(b.Dy()))))
+		left := x + (cellWidth-tileWidth)/2
+		draw.CatmullRom.Scale(sheet, image.Rect(left, y, left+tileWidth, y+tileHeight), img, b, draw.Src, nil)
+
+		if err := drawLabel(sheet, f, tiles[idx].Label, image.Rect(x, y+tileHeight, x+cellWidth, y+cellHeight)); err != nil {
+			return nil, fmt.Errorf("composeReferenceSheet: drawing label %d: %w", idx+1, err)
+		}
+	}
+	return sheet, nil
+}
+
+// drawLabel centers label inside box, shrinking the text until it fits the box width.
+func drawLabel(dst *image.RGBA, f *opentype.Font, label string, box image.Rectangle) error {
+	if label == "" {
+		return nil
+	}
+
+	size := float64(box.Dy()) * 0.7
+	for {
+		face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			return err
+		}
+
+		width := font.MeasureString(face, label).Ceil()
+		if width <= box.Dx() || size <= 8 {
+			metrics := face.Metrics()
+			baseline := box.Min.Y + (box.Dy()+metrics.Ascent.Ceil()-metrics.Descent.Ceil())/2
+			d := &font.Drawer{Dst: dst, Src: image.NewUniform(color.Black), Face: face}
+			d.Dot = fixed.P(box.Min.X+(box.Dx()-width)/2, baseline)
+			d.DrawString(label)
+			return face.Close()
+		}
+
+		face.Close()
+		size *= 0.85
+	}
+}
+
+// encodeWithinBytes encodes img as PNG, falling back to JPEG when the PNG is larger than maxBytes.
+// It returns nil data when neither encoding fits.
+func encodeWithinBytes(img image.Image, maxBytes int) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		return nil, fmt.Errorf("composeReferenceSheet: encoding sheet: %w", err)
+	}
+	if buf.Len() <= maxBytes {
+		return buf.Bytes(), nil
+	}
+
+	buf.Reset()
+	if err := jpeg.Encode(buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, fmt.Errorf("composeReferenceSheet: encoding sheet: %w", err)
+	}
+	if buf.Len() <= maxBytes {
+		return buf.Bytes(), nil
+	}
+	return nil, nil
+}
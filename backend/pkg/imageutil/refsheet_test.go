@@ -0,0 +1,99 @@
+package imageutil
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"testing"
+)
+
+func noisePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	rng := rand.New(rand.NewSource(int64(width * height)))
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(rng.Intn(256)), G: uint8(rng.Intn(256)), B: uint8(rng.Intn(256)), A: 0xff})
+		}
+	}
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestComposeReferenceSheet(t *testing.T) {
+	portrait := noisePNG(t, 1024, 1024)
+	tiles := []ReferenceTile{
+		{Label: "韩立", Image: portrait},
+		{Label: "厉飞雨", Image: portrait},
+		{Label: "Mo Juren", Image: noisePNG(t, 640, 1280)},
+		{Label: "张铁", Image: portrait},
+	}
+
+	data, placed, err := ComposeReferenceSheet(tiles, ReferenceSheetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if placed != len(tiles) {
+		t.Errorf("placed %d tiles, want all %d", placed, len(tiles))
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width > DefaultReferenceMaxSide || cfg.Height > DefaultReferenceMaxSide {
+		t.Errorf("sheet %dx%d exceeds the default cap", cfg.Width, cfg.Height)
+	}
+	if cfg.Width >= cfg.Height*3 {
+		t.Errorf("expected a grid, got a %dx%d strip", cfg.Width, cfg.Height)
+	}
+	if len(data) > DefaultReferenceMaxBytes {
+		t.Errorf("sheet payload %d exceeds the default cap", len(data))
+	}
+
+	small, _, err := ComposeReferenceSheet(tiles, ReferenceSheetOptions{MaxWidth: 800, MaxHeight: 800, MaxBytes: 200 << 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, _, err = image.DecodeConfig(bytes.NewReader(small))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width > 800 || cfg.Height > 800 || len(small) > 200<<10 {
+		t.Errorf("sheet %dx%d (%d bytes) exceeds the requested caps", cfg.Width, cfg.Height, len(small))
+	}
+}
+
+func TestComposeReferenceSheetDropsTilesBeyondCaps(t *testing.T) {
+	square := noisePNG(t, 256, 256)
+	tiles := []ReferenceTile{
+		{Label: "韩立", Image: square},
+		{Label: "厉飞雨", Image: square},
+		{Label: "张铁", Image: square},
+		{Label: "墨大夫", Image: square},
+	}
+
+	// Two rows of minimum-height tiles are taller than 200px, so only the first row fits.
+	data, placed, err := ComposeReferenceSheet(tiles, ReferenceSheetOptions{MaxWidth: 300, MaxHeight: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if placed != 2 {
+		t.Errorf("placed %d tiles, want 2", placed)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width > 300 || cfg.Height > 200 {
+		t.Errorf("sheet %dx%d exceeds the requested caps", cfg.Width, cfg.Height)
+	}
+
+	if _, _, err := ComposeReferenceSheet(tiles, ReferenceSheetOptions{MaxWidth: 64, MaxHeight: 64}); err == nil {
+		t.Error("expected an error when a single tile cannot fit the caps")
+	}
+}
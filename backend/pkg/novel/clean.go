@@ -0,0 +1,157 @@
+package novel
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Names of the cleanup rules reported in a CleanReport.
+const (
+	RulePageBreak     = "page_break"
+	RuleSiteAd        = "site_ad"
+	RuleAuthorNote    = "author_note"
+	RuleURL           = "url"
+	RuleRepeatedTitle = "repeated_title"
+	RuleIndent        = "indent"
+	RuleCustom        = "custom"
+)
+
+// MaxReportedRemovals caps the removed snippets kept in a CleanReport; Counts stay exact.
+const MaxReportedRemovals = 200
+
+// maxJunkLineRunes keeps whole-line rules from dropping long prose that merely mentions a keyword.
+const maxJunkLineRunes = 80
+
+// cleanRule removes either the matched text or, when wholeLine is set, every line it matches.
+type cleanRule struct {
+	name      string
+	re        *regexp.Regexp
+	wholeLine bool
+}
+
+// builtinCleanRules match the junk that crawlers leave in web novels. Whole-line rules run before
+// substring rules so an ad line carrying a URL is dropped as one removal.
+var builtinCleanRules = []cleanRule{
+	{name: RuleSiteAd, wholeLine: true, re: regexp.MustCompile(`一秒记住|一秒記住|请记住本站|請記住本站|本站域名|最快更新|最新章节|最新章節|无弹窗|無彈窗|天才一秒|手机用户请|手機用戶請|手机阅读|手機閱讀|免费阅读|免費閱讀|请收藏本站|請收藏本站`)},
+	{name: RuleAuthorNote, wholeLine: true, re: regexp.MustCompile(`^(作者有话要说|作者有話要說|作者的话|作者的話|作者留言|PS|P\.S\.|ps)\s*[:：]`)},
+	{name: RuleAuthorNote, wholeLine: true, re: regexp.MustCompile(`^[（(【].{0,20}(求收藏|求推荐|求推薦|求月票|求订阅|求訂閱|求打赏|求打賞|推荐票|推薦票).{0,20}[)）】]$`)},
+	{name: RulePageBreak, re: regexp.MustCompile(`[（(]?(本章未完|本章尚未完结|本章尚未完結).{0,12}?(下一页|下一頁|继续阅读|繼續閱讀|后面精彩内容|後面精彩內容)+[!！)）]*`)},
+	{name: RulePageBreak, re: regexp.MustCompile(`^[（(【]?本章完[)）】]?$`)},
+	{name: RuleURL, re: regexp.MustCompile(`(?i)(https?://|www\.)[a-z0-9\-._~:/?#@!$&'*+,;=%]+|\b[a-z0-9\-]+(\.[a-z0-9\-]+)*\.(com|net|org|cc|la|info|cn|me|tw|top|xyz|vip|co)\b(/[a-z0-9\-._~/?=&%]*)?`)},
+}
+
+// Removal is one piece of text dropped by a Cleaner.
+type Removal struct {
+	Rule    string `json:"rule"`
+	Chapter string `json:"chapter,omitempty"`
+	Text    string `json:"text"`
+}
+
+// CleanReport summarizes a cleanup: how often each rule fired and a sample of the removed text.
+// Indent trimming is only counted.
+type CleanReport struct {
+	Counts  map[string]int `json:"counts"`
+	Removed []Removal      `json:"removed"`
+}
+
+func (r *CleanReport) add(rule, chapter, text string) {
+	r.Counts[rule]++
+	if rule != RuleIndent && len(r.Removed) < MaxReportedRemovals {
+		r.Removed = append(r.Removed, Removal{Rule: rule, Chapter: chapter, Text: text})
+	}
+}
+
+// Cleaner strips web-novel noise from chapter text.
+type Cleaner struct {
+	rules []cleanRule
+}
+
+// NewCleaner combines the built-in rules with custom patterns. Text matched by a custom pattern is
+// removed, and lines left empty are dropped.
+func NewCleaner(patterns []string) (*Cleaner, error) {
+	c := &Cleaner{rules: slices.Clone(builtinCleanRules)}
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cleanup pattern %q: %w", pattern, err)
+		}
+		c.rules = append(c.rules, cleanRule{name: RuleCustom, re: re})
+	}
+	return c, nil
+}
+
+// Clean cleans every chapter and drops untitled chapters left without text.
+func (c *Cleaner) Clean(chapters []Chapter) ([]Chapter, *CleanReport) {
+	report := &CleanReport{Counts: map[string]int{}}
+	cleaned := make([]Chapter, 0, len(chapters))
+	for _, chapter := range chapters {
+		chapter.Content = c.CleanText(chapter.Title, chapter.Content, report)
+		if chapter.Title == "" && chapter.Content == "" {
+			continue
+		}
+		cleaned = append(cleaned, chapter)
+	}
+	return cleaned, report
+}
+
+// CleanText cleans the content of one chapter and records the removals in report. Lines repeating
+// the chapter title are dropped; blank lines are kept since they mark scene breaks.
+func (c *Cleaner) CleanText(title, content string, report *CleanReport) string {
+	titleKey := strings.Join(strings.Fields(normalizeHeadingCandidate(title)), "")
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		trimmed := strings.TrimRight(line, " \t\r\u3000")
+		stripped := strings.TrimLeft(trimmed, " \t\u3000")
+		if stripped == "" {
+			kept = append(kept, "")
+			continue
+		}
+		if stripped != trimmed {
+			report.add(RuleIndent, title, "")
+		}
+		if titleKey != "" && strings.Join(strings.Fields(normalizeHeadingCandidate(stripped)), "") == titleKey {
+			report.add(RuleRepeatedTitle, title, stripped)
+			continue
+		}
+		if line, ok := c.cleanLine(title, stripped, report); ok {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Trim(strings.Join(kept, "\n"), "\n")
+}
+
+// cleanLine applies the rules to a single non-blank line and reports whether anything is left of it.
+func (c *Cleaner) cleanLine(title, line string, report *CleanReport) (string, bool) {
+	for _, rule := range c.rules {
+		if rule.wholeLine {
+			if utf8.RuneCountInString(line) <= maxJunkLineRunes && rule.re.MatchString(line) {
+				report.add(rule.name, title, line)
+				return "", false
+			}
+			continue
+		}
+		removed := false
+		for _, match := range rule.re.FindAllString(line, -1) {
+			if match != "" {
+				report.add(rule.name, title, match)
+				removed = true
+			}
+		}
+		if !removed {
+			continue
+		}
+		line = strings.TrimSpace(rule.re.ReplaceAllString(line, ""))
+		if line == "" {
+			return "", false
+		}
+	}
+	return line, true
+}
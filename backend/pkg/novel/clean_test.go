@@ -0,0 +1,68 @@
+package novel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCleanRemovesJunk(t *testing.T) {
+	content := strings.Join([]string{
+		"第一章  少年",
+		"　　少年站在石碑前。",
+		"　　一秒记住【笔趣阁 www.biquge.com】，精彩小说无弹窗免费阅读！",
+		"",
+		"　　他握紧了拳头。本章未完，请点击下一页继续阅读",
+		"　　远处传来钟声，详见biquge.la/book/1。",
+		"作者有话要说：今天加更。",
+		"（求收藏求推荐）",
+	}, "\n")
+
+	cleaner, err := NewCleaner([]string{`【广告】`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chapters, report := cleaner.Clean([]Chapter{
+		{Title: "第一章 少年", Content: content},
+		{Content: "【广告】"},
+	})
+
+	want := []Chapter{{Title: "第一章 少年", Content: "少年站在石碑前。\n\n他握紧了拳头。\n远处传来钟声，详见。"}}
+	if !reflect.DeepEqual(chapters, want) {
+		t.Fatalf("Clean() = %#v", chapters)
+	}
+
+	wantCounts := map[string]int{
+		RuleRepeatedTitle: 1,
+		RuleSiteAd:        1,
+		RulePageBreak:     1,
+		RuleURL:           1,
+		RuleAuthorNote:    2,
+		RuleIndent:        4,
+		RuleCustom:        1,
+	}
+	if !reflect.DeepEqual(report.Counts, wantCounts) {
+		t.Errorf("Counts = %v, want %v", report.Counts, wantCounts)
+	}
+	if len(report.Removed) != 7 {
+		t.Errorf("Removed has %d entries, want 7: %#v", len(report.Removed), report.Removed)
+	}
+	if r := report.Removed[2]; r.Rule != RulePageBreak || r.Text != "本章未完，请点击下一页继续阅读" || r.Chapter != "第一章 少年" {
+		t.Errorf("page break removal = %#v", r)
+	}
+}
+
+func TestCleanKeepsProse(t *testing.T) {
+	content := "他说：“最新章节我还没看。”" + strings.Repeat("这是一段很长的正文。", 10)
+	cleaner, err := NewCleaner(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cleaner.CleanText("第一章", content, &CleanReport{Counts: map[string]int{}}); got != content {
+		t.Errorf("CleanText() = %q", got)
+	}
+
+	if _, err := NewCleaner([]string{"("}); err == nil {
+		t.Error("NewCleaner() with invalid pattern succeeded")
+	}
+}
@@ -0,0 +1,136 @@
+package novel
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	textunicode "golang.org/x/text/encoding/unicode"
+)
+
+// Encoding names reported by DetectEncoding.
+const (
+	EncodingUTF8    = "UTF-8"
+	EncodingUTF16LE = "UTF-16LE"
+	EncodingUTF16BE = "UTF-16BE"
+	EncodingGB18030 = "GB18030"
+	EncodingBig5    = "Big5"
+)
+
+// detectSampleSize bounds how much of the file is decoded when scoring candidate encodings.
+const detectSampleSize = 64 * 1024
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// candidateEncodings are tried in order when the text is neither marked by a BOM nor valid UTF-8;
+// earlier candidates win ties.
+var candidateEncodings = []struct {
+	name     string
+	encoding encoding.Encoding
+}{
+	{EncodingGB18030, simplifiedchinese.GB18030},
+	{EncodingBig5, traditionalchinese.Big5},
+	{EncodingUTF16LE, textunicode.UTF16(textunicode.LittleEndian, textunicode.IgnoreBOM)},
+	{EncodingUTF16BE, textunicode.UTF16(textunicode.BigEndian, textunicode.IgnoreBOM)},
+}
+
+// commonHan holds frequent Chinese characters in both simplified and traditional forms. Text decoded
+// with the right encoding is dense in them, while a wrong legacy decoding yields mostly rare characters.
+var commonHan = func() map[rune]bool {
+	const simplified = "的一是不了人我在有他这中大来上个国到说们为子和你地出道也时年得就那要下以生会自着去之过家学对可她里后小么心多天而能好都然没日于起还发成事只作当想看文无开手十用主行方又如前所本见经头面公同三已老从动两长知民样现分将外但身些与高意进把法此实回二理美点月明其种声全工己话儿者向情部正名定女问力机给等几很业最间新什打便位因重被走电四第门相次东海口使西再平真听世气信北少关并内加化由却代产入先山五太水万眼体别处总才场师书比住九笑性通目报立马命张活难神数件安表原车白应路期叫死常提感金何更反合放做系计或利受光王果亲界及今务制解各任至清物台象记边共风战干接它许八特觉望直服林题建南度色字请交爱让认算论百吃义怎元六功指思非流每青管夫连远资跟带花快条院变联言往该领传近留红决周保达办运武半候七必城父强步完深区即求品士转量空甚众轻程告江语英基满式李息写呢识极令黄德收脸钱倒未持取设始双历越史千片容像找友孩站广改形早房音火际则首单据导影失拿网香似专石若兵弟谁校读志飞观争究包造落视喜离虽坐集宝谈拉黑且随格尽剑讲布杀微怕母调局根曾准团段终乐切级克精哪官示冷假拍球"
+	const traditional = "這來個國說為時會著過學對裡後麼沒於還發當無開見經頭從動兩長樣現將與進實點種聲話兒問機給幾業間電門東聽氣關內產萬體別處總場師書報馬張難數車應親務記邊戰許覺題狀請愛讓認論義條聯言該領傳遠資帶變連雙歷臉錢設導網專誰讀飛觀爭寶談隨盡劍講殺調團終樂級準視離雖"
+	set := make(map[rune]bool)
+	for _, r := range simplified + traditional {
+		set[r] = true
+	}
+	return set
+}()
+
+// DecodeText transcodes raw novel text to UTF-8 and reports the detected encoding. A leading byte
+// order mark decides the encoding outright; otherwise valid UTF-8 is kept as is and the remaining
+// candidates are scored on a sample of the file.
+func DecodeText(data []byte) (string, string, error) {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return string(data[len(bomUTF8):]), EncodingUTF8, nil
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return decodeWith(textunicode.UTF16(textunicode.LittleEndian, textunicode.IgnoreBOM), data[len(bomUTF16LE):], EncodingUTF16LE)
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return decodeWith(textunicode.UTF16(textunicode.BigEndian, textunicode.IgnoreBOM), data[len(bomUTF16BE):], EncodingUTF16BE)
+	}
+
+	name := DetectEncoding(data)
+	for _, candidate := range candidateEncodings {
+		if candidate.name == name {
+			return decodeWith(candidate.encoding, data, name)
+		}
+	}
+	return string(data), EncodingUTF8, nil
+}
+
+// DetectEncoding guesses the encoding of text without a byte order mark.
+func DetectEncoding(data []byte) string {
+	if utf8.Valid(data) && !looksLikeUTF16(data) {
+		return EncodingUTF8
+	}
+
+	sample := data[:min(len(data), detectSampleSize)]
+	best, bestScore := EncodingUTF8, scoreText(string(sample))
+	for _, candidate := range candidateEncodings {
+		decoded, err := candidate.encoding.NewDecoder().Bytes(sample)
+		if err != nil {
+			continue
+		}
+		if score := scoreText(string(decoded)); score > bestScore {
+			best, bestScore = candidate.name, score
+		}
+	}
+	return best
+}
+
+func decodeWith(enc encoding.Encoding, data []byte, name string) (string, string, error) {
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", name, err
+	}
+	return string(decoded), name, nil
+}
+
+// looksLikeUTF16 catches BOM-less UTF-16 with Latin text, which is valid UTF-8 byte-wise because
+// every other byte is NUL.
+func looksLikeUTF16(data []byte) bool {
+	sample := data[:min(len(data), detectSampleSize)]
+	return bytes.Count(sample, []byte{0}) > len(sample)/4
+}
+
+// scoreText rates how plausible decoded text is as a Chinese or Latin novel.
+func scoreText(s string) int {
+	score := 0
+	for _, r := range s {
+		switch {
+		case r == utf8.RuneError:
+			score -= 20
+		case commonHan[r]:
+			score += 3
+		case r == '\n' || r == '\r' || r == '\t':
+			score++
+		case r < 0x20 || r == 0x7F:
+			score -= 5
+		case r < 0x80:
+			score++
+		case r >= 0x3000 && r <= 0x303F, r >= 0xFF00 && r <= 0xFFEF, r >= 0x2010 && r <= 0x2027:
+			score += 2
+		case unicode.Is(unicode.Han, r):
+		default:
+			score--
+		}
+	}
+	return score
+}
@@ -0,0 +1,60 @@
+package novel
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	textunicode "golang.org/x/text/encoding/unicode"
+)
+
+const (
+	simplifiedSample  = "第一章 陨落的天才\n　　“斗之力，三段！”望着测验魔石碑上面闪亮得甚至有些刺眼的五个大字，少年面无表情，唇角有着一抹自嘲，紧握的手掌，因为大力，而导致略微尖锐的指甲深深的刺进了掌心之中，带来一阵阵钻心的疼痛……\n"
+	traditionalSample = "第一章 隕落的天才\n　　「鬥之力，三段！」望著測驗魔石碑上面閃亮得甚至有些刺眼的五個大字，少年面無表情，唇角有著一抹自嘲，緊握的手掌，因為大力，而導致略微尖銳的指甲深深的刺進了掌心之中，帶來一陣陣鑽心的疼痛……\n"
+)
+
+func encode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	data, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDecodeText(t *testing.T) {
+	utf16LE := textunicode.UTF16(textunicode.LittleEndian, textunicode.IgnoreBOM)
+	utf16BE := textunicode.UTF16(textunicode.BigEndian, textunicode.IgnoreBOM)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		want     string
+		encoding string
+	}{
+		{name: "utf8", data: []byte(simplifiedSample), want: simplifiedSample, encoding: EncodingUTF8},
+		{name: "utf8 bom", data: append([]byte{0xEF, 0xBB, 0xBF}, simplifiedSample...), want: simplifiedSample, encoding: EncodingUTF8},
+		{name: "gb18030", data: encode(t, simplifiedchinese.GB18030, simplifiedSample), want: simplifiedSample, encoding: EncodingGB18030},
+		{name: "gbk", data: encode(t, simplifiedchinese.GBK, simplifiedSample), want: simplifiedSample, encoding: EncodingGB18030},
+		{name: "big5", data: encode(t, traditionalchinese.Big5, traditionalSample), want: traditionalSample, encoding: EncodingBig5},
+		{name: "utf16le bom", data: append([]byte{0xFF, 0xFE}, encode(t, utf16LE, simplifiedSample)...), want: simplifiedSample, encoding: EncodingUTF16LE},
+		{name: "utf16be bom", data: append([]byte{0xFE, 0xFF}, encode(t, utf16BE, simplifiedSample)...), want: simplifiedSample, encoding: EncodingUTF16BE},
+		{name: "utf16le", data: encode(t, utf16LE, simplifiedSample), want: simplifiedSample, encoding: EncodingUTF16LE},
+		{name: "utf16be latin", data: encode(t, utf16BE, "Chapter 1\nThe quick brown fox.\n"), want: "Chapter 1\nThe quick brown fox.\n", encoding: EncodingUTF16BE},
+	}
+
+	for _, tc := range tests {
+		got, enc, err := DecodeText(tc.data)
+		if err != nil {
+			t.Errorf("%s: DecodeText() error = %v", tc.name, err)
+			continue
+		}
+		if enc != tc.encoding {
+			t.Errorf("%s: encoding = %s, want %s", tc.name, enc, tc.encoding)
+		}
+		if got != tc.want {
+			t.Errorf("%s: text = %q", tc.name, got)
+		}
+	}
+}
@@ -0,0 +1,569 @@
+package novel
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+)
+
+const epubContainerPath = "META-INF/container.xml"
+
+// Limits on decompressed archive content, so a small upload cannot expand into gigabytes of memory.
+const (
+	maxEPUBEntrySize = 32 << 20
+	maxEPUBTotalSize = 128 << 20
+)
+
+// ErrInvalidEPUB is returned when an archive is missing the container, package document or readable content.
+var ErrInvalidEPUB = errors.New("invalid epub")
+
+// IsEPUB reports whether data is a zip archive carrying an EPUB container document.
+func IsEPUB(data []byte) bool {
+	if !bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return false
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return false
+	}
+	for _, f := range zr.File {
+		if f.Name == epubContainerPath {
+			return true
+		}
+	}
+	return false
+}
+
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type opfPackage struct {
+	Metadata struct {
+		Titles   []string `xml:"title"`
+		Creators []string `xml:"creator"`
+	} `xml:"metadata"`
+	Manifest []opfItem `xml:"manifest>item"`
+	Spine    struct {
+		Toc      string `xml:"toc,attr"`
+		Itemrefs []struct {
+			IDRef  string `xml:"idref,attr"`
+			Linear string `xml:"linear,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+type opfItem struct {
+	ID         string `xml:"id,attr"`
+	Href       string `xml:"href,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	Properties string `xml:"properties,attr"`
+}
+
+type ncxDocument struct {
+	NavPoints []ncxNavPoint `xml:"navMap>navPoint"`
+}
+
+type ncxNavPoint struct {
+	Label   string `xml:"navLabel>text"`
+	Content struct {
+		Src string `xml:"src,attr"`
+	} `xml:"content"`
+	Children []ncxNavPoint `xml:"navPoint"`
+}
+
+// tocEntry is one table-of-contents link resolved to an archive path and an optional fragment id.
+// Volume is the title of the top-level entry a nested entry sits under.
+type tocEntry struct {
+	Path     string
+	Fragment string
+	Title    string
+	Volume   string
+}
+
+// ParseEPUB reads an EPUB archive: it follows the OPF spine for reading order, uses the EPUB 3 nav
+// document (or the EPUB 2 NCX) for chapter titles and boundaries, and strips XHTML down to paragraphs.
+// When the book has no usable table of contents every spine document becomes a chapter titled by its
+// first heading. Untitled front matter before the first table-of-contents entry is dropped.
+func ParseEPUB(data []byte) (*Book, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEPUB, err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	// read enforces the size limits against both the declared size and the bytes actually
+	// inflated, since the zip header can under-report an entry's size.
+	var total int64
+	var errTooLarge error
+	read := func(name string) ([]byte, error) {
+		if errTooLarge != nil {
+			return nil, errTooLarge
+		}
+		f, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: missing %s", ErrInvalidEPUB, name)
+		}
+		limit := min(int64(maxEPUBEntrySize), maxEPUBTotalSize-total)
+		if f.UncompressedSize64 > uint64(limit) {
+			errTooLarge = fmt.Errorf("%w: %s exceeds the size limit", ErrInvalidEPUB, name)
+			return nil, errTooLarge
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		raw, err := io.ReadAll(io.LimitReader(rc, limit+1))
+		total += int64(len(raw))
+		if int64(len(raw)) > limit {
+			errTooLarge = fmt.Errorf("%w: %s exceeds the size limit", ErrInvalidEPUB, name)
+			return nil, errTooLarge
+		}
+		return raw, err
+	}
+
+	raw, err := read(epubContainerPath)
+	if err != nil {
+		return nil, err
+	}
+	var container epubContainer
+	if err := decodeXML(raw, &container); err != nil {
+		return nil, fmt.Errorf("%w: container: %v", ErrInvalidEPUB, err)
+	}
+	if len(container.Rootfiles) == 0 || container.Rootfiles[0].FullPath == "" {
+		return nil, fmt.Errorf("%w: no rootfile in container", ErrInvalidEPUB)
+	}
+	opfPath := container.Rootfiles[0].FullPath
+
+	raw, err = read(opfPath)
+	if err != nil {
+		return nil, err
+	}
+	var pkg opfPackage
+	if err := decodeXML(raw, &pkg); err != nil {
+		return nil, fmt.Errorf("%w: package document: %v", ErrInvalidEPUB, err)
+	}
+
+	opfDir := path.Dir(opfPath)
+	items := make(map[string]opfItem, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		items[item.ID] = item
+	}
+
+	toc := loadTOC(pkg, items, opfDir, read)
+	if errTooLarge != nil {
+		return nil, errTooLarge
+	}
+	tocByPath := make(map[string][]tocEntry)
+	for _, entry := range toc {
+		tocByPath[entry.Path] = append(tocByPath[entry.Path], entry)
+	}
+
+	var chapters []Chapter
+	start := func(entry tocEntry) {
+		chapters = append(chapters, Chapter{Title: entry.Title, Volume: entry.Volume})
+	}
+	for _, ref := range pkg.Spine.Itemrefs {
+		item, ok := items[ref.IDRef]
+		if !ok || ref.Linear == "no" || !isXHTML(item.MediaType) {
+			continue
+		}
+		docPath := resolveHref(opfDir, item.Href)
+		raw, err := read(docPath)
+		if errTooLarge != nil {
+			return nil, errTooLarge
+		}
+		if err != nil {
+			continue
+		}
+
+		// When several entries point at the same place (a volume and its first chapter), the last and
+		// therefore deepest entry names the chapter.
+		var fileEntry *tocEntry
+		anchors := make(map[string]tocEntry)
+		for _, entry := range tocByPath[docPath] {
+			if entry.Fragment == "" {
+				fileEntry = &entry
+			} else {
+				anchors[entry.Fragment] = entry
+			}
+		}
+
+		blocks := extractBlocks(raw, anchors)
+		switch {
+		case fileEntry != nil:
+			start(*fileEntry)
+		case len(toc) == 0:
+			start(tocEntry{Title: firstHeading(blocks)})
+		}
+		for _, block := range blocks {
+			if block.Anchor != "" {
+				start(anchors[block.Anchor])
+				continue
+			}
+			if len(chapters) == 0 {
+				start(tocEntry{})
+			}
+			last := &chapters[len(chapters)-1]
+			if last.Content == "" && sameText(block.Text, last.Title) {
+				continue
+			}
+			if last.Content != "" {
+				last.Content += "\n"
+			}
+			last.Content += block.Text
+		}
+	}
+
+	book := &Book{
+		Title:    firstNonEmpty(pkg.Metadata.Titles),
+		Author:   strings.Join(nonEmpty(pkg.Metadata.Creators), "、"),
+		Encoding: EncodingUTF8,
+	}
+	for _, chapter := range chapters {
+		if chapter.Content == "" || (chapter.Title == "" && len(toc) > 0) {
+			continue
+		}
+		book.Chapters = append(book.Chapters, chapter)
+	}
+	if len(book.Chapters) == 0 {
+		return nil, fmt.Errorf("%w: no readable chapters", ErrInvalidEPUB)
+	}
+	return book, nil
+}
+
+// loadTOC prefers the EPUB 3 nav document and falls back to the NCX referenced by the spine.
+func loadTOC(pkg opfPackage, items map[string]opfItem, opfDir string, read func(string) ([]byte, error)) []tocEntry {
+	for _, item := range pkg.Manifest {
+		if !hasProperty(item.Properties, "nav") {
+			continue
+		}
+		navPath := resolveHref(opfDir, item.Href)
+		if raw, err := read(navPath); err == nil {
+			if entries := parseNav(raw, path.Dir(navPath)); len(entries) > 0 {
+				return entries
+			}
+		}
+	}
+
+	ncxItem, ok := items[pkg.Spine.Toc]
+	if !ok {
+		for _, item := range pkg.Manifest {
+			if item.MediaType == "application/x-dtbncx+xml" {
+				ncxItem, ok = item, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil
+	}
+	ncxPath := resolveHref(opfDir, ncxItem.Href)
+	raw, err := read(ncxPath)
+	if err != nil {
+		return nil
+	}
+	var ncx ncxDocument
+	if err := decodeXML(raw, &ncx); err != nil {
+		return nil
+	}
+	var entries []tocEntry
+	var walk func(points []ncxNavPoint, volume string)
+	walk = func(points []ncxNavPoint, volume string) {
+		for _, point := range points {
+			if entry, ok := newTOCEntry(path.Dir(ncxPath), point.Content.Src, point.Label); ok {
+				entry.Volume = volume
+				entries = append(entries, entry)
+			}
+			if volume == "" {
+				walk(point.Children, collapseSpaces(point.Label))
+			} else {
+				walk(point.Children, volume)
+			}
+		}
+	}
+	walk(ncx.NavPoints, "")
+	return entries
+}
+
+// parseNav collects the links of the toc nav element (or the first nav when none is marked) in document order.
+func parseNav(raw []byte, baseDir string) []tocEntry {
+	decoder := newXMLDecoder(raw)
+	var (
+		entries  []tocEntry
+		navDepth int
+		navDone  bool
+		href     string
+		inLink   bool
+		label    strings.Builder
+		// items holds the link titles of the open list items, outermost first
+		items []string
+	)
+	for !navDone {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Local == "nav" && navDepth == 0:
+				if navType := attr(t, "type"); navType == "" || hasProperty(navType, "toc") {
+					navDepth = 1
+				}
+			case navDepth > 0:
+				navDepth++
+				switch t.Name.Local {
+				case "li":
+					items = append(items, "")
+				case "a":
+					href, inLink = attr(t, "href"), true
+					label.Reset()
+				}
+			}
+		case xml.EndElement:
+			if navDepth == 0 {
+				continue
+			}
+			navDepth--
+			if navDepth == 0 {
+				navDone = len(entries) > 0
+				continue
+			}
+			switch {
+			case t.Name.Local == "li" && len(items) > 0:
+				items = items[:len(items)-1]
+			case t.Name.Local == "a" && inLink:
+				inLink = false
+				title := collapseSpaces(label.String())
+				if len(items) > 0 {
+					items[len(items)-1] = title
+				}
+				if entry, ok := newTOCEntry(baseDir, href, title); ok {
+					if len(items) > 1 {
+						entry.Volume = items[0]
+					}
+					entries = append(entries, entry)
+				}
+			}
+		case xml.CharData:
+			if inLink {
+				label.Write(t)
+			}
+		}
+	}
+	return entries
+}
+
+func newTOCEntry(baseDir, href, title string) (tocEntry, bool) {
+	title = collapseSpaces(title)
+	href, fragment, _ := strings.Cut(href, "#")
+	if href == "" || title == "" {
+		return tocEntry{}, false
+	}
+	return tocEntry{Path: resolveHref(baseDir, href), Fragment: fragment, Title: title}, true
+}
+
+// textBlock is either a paragraph of text or a marker for a table-of-contents anchor.
+type textBlock struct {
+	Text    string
+	Heading bool
+	Anchor  string
+}
+
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "hr": true, "li": true, "dt": true, "dd": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "pre": true, "section": true, "article": true, "header": true,
+	"footer": true, "aside": true, "figure": true, "figcaption": true, "table": true,
+	"tr": true, "ul": true, "ol": true, "body": true,
+}
+
+var skippedElements = map[string]bool{
+	"head": true, "script": true, "style": true, "svg": true, "math": true,
+}
+
+// extractBlocks strips an XHTML document down to paragraphs, emitting an anchor marker where an element
+// carries one of the given fragment ids.
+func extractBlocks(raw []byte, anchors map[string]tocEntry) []textBlock {
+	decoder := newXMLDecoder(raw)
+	var (
+		blocks    []textBlock
+		text      strings.Builder
+		skipDepth int
+		headings  int
+		seen      = make(map[string]bool)
+	)
+	flush := func() {
+		if s := collapseSpaces(text.String()); s != "" {
+			blocks = append(blocks, textBlock{Text: s, Heading: headings > 0})
+		}
+		text.Reset()
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if skipDepth > 0 || skippedElements[t.Name.Local] {
+				skipDepth++
+				continue
+			}
+			if blockElements[t.Name.Local] {
+				flush()
+			}
+			if isHeading(t.Name.Local) {
+				headings++
+			}
+			for _, key := range []string{"id", "name"} {
+				if id := attr(t, key); id != "" {
+					if _, ok := anchors[id]; ok && !seen[id] {
+						flush()
+						blocks = append(blocks, textBlock{Anchor: id})
+						seen[id] = true
+					}
+				}
+			}
+		case xml.EndElement:
+			if skipDepth > 0 {
+				skipDepth--
+				continue
+			}
+			if blockElements[t.Name.Local] {
+				flush()
+			}
+			if isHeading(t.Name.Local) && headings > 0 {
+				headings--
+			}
+		case xml.CharData:
+			if skipDepth == 0 {
+				text.Write(t)
+			}
+		}
+	}
+	flush()
+	return blocks
+}
+
+func firstHeading(blocks []textBlock) string {
+	for _, block := range blocks {
+		if block.Heading {
+			return block.Text
+		}
+	}
+	return ""
+}
+
+func newXMLDecoder(raw []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return decoder
+}
+
+func decodeXML(raw []byte, v any) error {
+	return newXMLDecoder(raw).Decode(v)
+}
+
+func attr(t xml.StartElement, local string) string {
+	for _, a := range t.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func resolveHref(baseDir, href string) string {
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	return path.Clean(path.Join(baseDir, href))
+}
+
+func hasProperty(properties, want string) bool {
+	for _, p := range strings.Fields(properties) {
+		if p == want {
+			return true
+		}
+	}
+	return false
+}
+
+func isXHTML(mediaType string) bool {
+	return mediaType == "application/xhtml+xml" || mediaType == "text/html"
+}
+
+func isHeading(name string) bool {
+	return len(name) == 2 && name[0] == 'h' && name[1] >= '1' && name[1] <= '6'
+}
+
+// collapseSpaces trims whitespace, including full-width spaces, and joins inner runs into a single
+// space. Runs that contain a line break between CJK characters are dropped, since they only come from
+// source line wrapping.
+func collapseSpaces(s string) string {
+	var b strings.Builder
+	var prev rune
+	pending, wrapped := false, false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			pending = true
+			wrapped = wrapped || r == '\n' || r == '\r'
+			continue
+		}
+		if pending && b.Len() > 0 && !(wrapped && isCJK(prev) && isCJK(r)) {
+			b.WriteByte(' ')
+		}
+		pending, wrapped = false, false
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		unicode.In(r, unicode.Ideographic) || (r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF)
+}
+
+func sameText(a, b string) bool {
+	strip := func(s string) string {
+		return strings.Join(strings.FieldsFunc(s, unicode.IsSpace), "")
+	}
+	return strip(a) != "" && strip(a) == strip(b)
+}
+
+func firstNonEmpty(values []string) string {
+	if v := nonEmpty(values); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func nonEmpty(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if v = collapseSpaces(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
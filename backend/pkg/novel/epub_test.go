@@ -0,0 +1,208 @@
+package novel
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testContainer = `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`
+
+func buildEPUB(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	names := []string{"mimetype", "META-INF/container.xml"}
+	files["mimetype"] = "application/epub+zip"
+	files["META-INF/container.xml"] = testContainer
+	for name := range files {
+		if name != "mimetype" && name != "META-INF/container.xml" {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func xhtml(body string) string {
+	return `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>ignored</title><style>p{}</style></head><body>` + body + `</body></html>`
+}
+
+func TestParseEPUBWithNCX(t *testing.T) {
+	data := buildEPUB(t, map[string]string{
+		"OEBPS/content.opf": `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>斗破苍穹</dc:title><dc:creator>天蚕土豆</dc:creator>
+  </metadata>
+  <manifest>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="cover" href="Text/cover.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c1" href="Text/chapter%201.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c2" href="Text/chapter2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine toc="ncx">
+    <itemref idref="cover"/><itemref idref="c1"/><itemref idref="c2"/>
+  </spine>
+</package>`,
+		"OEBPS/toc.ncx": `<?xml version="1.0"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/"><navMap>
+  <navPoint id="v1"><navLabel><text>第一卷</text></navLabel><content src="Text/chapter%201.xhtml"/>
+    <navPoint id="n1"><navLabel><text>第一章 陨落的天才</text></navLabel><content src="Text/chapter%201.xhtml"/></navPoint>
+    <navPoint id="n2"><navLabel><text>第二章 斗气大陆</text></navLabel><content src="Text/chapter2.xhtml#c2"/></navPoint>
+    <navPoint id="n3"><navLabel><text>第三章 客人</text></navLabel><content src="Text/chapter2.xhtml#c3"/></navPoint>
+  </navPoint>
+</navMap></ncx>`,
+		"OEBPS/Text/cover.xhtml":     xhtml(`<p>版权所有</p>`),
+		"OEBPS/Text/chapter 1.xhtml": xhtml(`<h1>第一章 陨落的天才</h1><p>　　“斗之力，三段！”</p><p>望着测验魔石碑，<span>少年</span>面无表情。</p>`),
+		"OEBPS/Text/chapter2.xhtml":  xhtml(`<h2 id="c2">第二章  斗气大陆</h2><p>月如银盘，</p><p>漫天繁星。<br/>山崖之颠。</p><h2 id="c3">第三章 客人</h2><p>&nbsp;&nbsp;客人来了&amp;走了。</p>`),
+	})
+
+	if !IsEPUB(data) {
+		t.Fatal("IsEPUB() = false")
+	}
+	book, err := ParseEPUB(data)
+	if err != nil {
+		t.Fatalf("ParseEPUB() error = %v", err)
+	}
+	if book.Title != "斗破苍穹" || book.Author != "天蚕土豆" {
+		t.Errorf("metadata = %q / %q", book.Title, book.Author)
+	}
+
+	want := []Chapter{
+		{Title: "第一章 陨落的天才", Volume: "第一卷", Content: "“斗之力，三段！”\n望着测验魔石碑，少年面无表情。"},
+		{Title: "第二章 斗气大陆", Volume: "第一卷", Content: "月如银盘，\n漫天繁星。\n山崖之颠。"},
+		{Title: "第三章 客人", Volume: "第一卷", Content: "客人来了&走了。"},
+	}
+	if !reflect.DeepEqual(book.Chapters, want) {
+		t.Errorf("chapters = %#v\nwant %#v", book.Chapters, want)
+	}
+}
+
+func TestParseEPUBWithNav(t *testing.T) {
+	data := buildEPUB(t, map[string]string{
+		"OEBPS/content.opf": `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title> Nav Book </dc:title></metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="a" href="a.xhtml" media-type="application/xhtml+xml"/>
+    <item id="b" href="b.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="nav" linear="no"/><itemref idref="a"/><itemref idref="b"/></spine>
+</package>`,
+		"OEBPS/nav.xhtml": xhtml(`<nav epub:type="landmarks"><ol><li><a href="b.xhtml">Landmark</a></li></ol></nav>
+<nav epub:type="toc"><ol><li><a href="a.xhtml">序章</a></li>
+<li><a href="b.xhtml">第一卷</a><ol><li><a href="b.xhtml"><span>第一章</span> 开端</a></li></ol></li></ol></nav>`),
+		"OEBPS/a.xhtml": xhtml(`<p>序章内容</p>`),
+		"OEBPS/b.xhtml": xhtml(`<div><p>Hello
+world</p></div>`),
+	})
+
+	book, err := ParseEPUB(data)
+	if err != nil {
+		t.Fatalf("ParseEPUB() error = %v", err)
+	}
+	want := []Chapter{
+		{Title: "序章", Content: "序章内容"},
+		{Title: "第一章 开端", Volume: "第一卷", Content: "Hello world"},
+	}
+	if book.Title != "Nav Book" || !reflect.DeepEqual(book.Chapters, want) {
+		t.Errorf("book = %q %#v", book.Title, book.Chapters)
+	}
+}
+
+func TestParseEPUBWithoutTOC(t *testing.T) {
+	data := buildEPUB(t, map[string]string{
+		"OEBPS/content.opf": `<package><metadata/><manifest>
+<item id="a" href="a.xhtml" media-type="application/xhtml+xml"/>
+<item id="b" href="b.xhtml" media-type="application/xhtml+xml"/>
+</manifest><spine><itemref idref="a"/><itemref idref="b"/></spine></package>`,
+		"OEBPS/a.xhtml": xhtml(`<h1>楔子</h1><p>开篇</p>`),
+		"OEBPS/b.xhtml": xhtml(`<p>没有标题</p>`),
+	})
+
+	book, err := ParseEPUB(data)
+	if err != nil {
+		t.Fatalf("ParseEPUB() error = %v", err)
+	}
+	want := []Chapter{
+		{Title: "楔子", Content: "开篇"},
+		{Title: "", Content: "没有标题"},
+	}
+	if !reflect.DeepEqual(book.Chapters, want) {
+		t.Errorf("chapters = %#v", book.Chapters)
+	}
+}
+
+func TestParseEPUBInvalid(t *testing.T) {
+	if IsEPUB([]byte("第一章 plain text")) {
+		t.Error("IsEPUB(text) = true")
+	}
+	data := buildEPUB(t, map[string]string{})
+	if _, err := ParseEPUB(data); !errors.Is(err, ErrInvalidEPUB) {
+		t.Errorf("ParseEPUB(no opf) error = %v, want ErrInvalidEPUB", err)
+	}
+}
+
+func TestParseEPUBRejectsOversizedEntries(t *testing.T) {
+	opf := `<package><metadata/><manifest>
+<item id="a" href="a.xhtml" media-type="application/xhtml+xml"/>
+</manifest><spine><itemref idref="a"/></spine></package>`
+	huge := xhtml("<p>" + strings.Repeat("啊", maxEPUBEntrySize/3) + "</p>")
+
+	data := buildEPUB(t, map[string]string{"OEBPS/content.opf": opf, "OEBPS/a.xhtml": huge})
+	if _, err := ParseEPUB(data); !errors.Is(err, ErrInvalidEPUB) {
+		t.Errorf("ParseEPUB(oversized entry) error = %v, want ErrInvalidEPUB", err)
+	}
+
+	// An entry whose header under-reports its size is still rejected rather than inflated in full.
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{"META-INF/container.xml": testContainer, "OEBPS/content.opf": opf} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	var deflated bytes.Buffer
+	fw, _ := flate.NewWriter(&deflated, flate.BestSpeed)
+	fw.Write([]byte(huge))
+	fw.Close()
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "OEBPS/a.xhtml",
+		Method:             zip.Deflate,
+		CompressedSize64:   uint64(deflated.Len()),
+		UncompressedSize64: 1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(deflated.Bytes())
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseEPUB(buf.Bytes()); !errors.Is(err, ErrInvalidEPUB) {
+		t.Errorf("ParseEPUB(forged entry size) error = %v, want ErrInvalidEPUB", err)
+	}
+}
@@ -0,0 +1,21 @@
+// Package novel turns uploaded novel files into titled chapters ready to be stored as comic sections.
+package novel
+
+// Chapter is a titled chunk of novel text. Content keeps one paragraph per line.
+type Chapter struct {
+	Title string
+	// Volume is the title of the volume the chapter belongs to, empty for books without volumes.
+	Volume  string
+	Content string
+	// SourceTitles lists the original chapter titles a re-sectioned chapter was built from.
+	SourceTitles []string
+}
+
+// Book is a parsed novel together with the metadata found in the source file.
+type Book struct {
+	Title  string
+	Author string
+	// Encoding is the character encoding the source text was decoded from.
+	Encoding string
+	Chapters []Chapter
+}
@@ -0,0 +1,195 @@
+package novel
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ResectionOptions sizes the sections produced by Resection.
+type ResectionOptions struct {
+	// TargetRunes is the preferred section length.
+	TargetRunes int
+	// MinRunes marks chapters short enough to merge with the following ones, TargetRunes/2 when zero.
+	MinRunes int
+	// MaxRunes marks chapters long enough to split, TargetRunes*2 when zero. Merging never grows a
+	// section past it.
+	MaxRunes int
+}
+
+// sceneBreakPattern matches separator lines such as "***", "* * *", "———" or "◆◇◆".
+var sceneBreakPattern = regexp.MustCompile(`^[\s*＊\-—－=＝~～#＃·•◆◇○●☆★※]{3,}$|^[*＊※◆◇☆★]$`)
+
+// timeJumpPattern matches short paragraphs opening with a jump in time, a common scene transition.
+var timeJumpPattern = regexp.MustCompile(`^(次日|翌日|第二天|隔天|当晚|當晚|当夜|與此同時|与此同时|[零一二两三四五六七八九十百千几數数半0-9]+(个|個)?(天|日|月|年|星期|周|週|小时|小時|时辰|時辰)(之|以)?后|[零一二两三四五六七八九十百千几數数半0-9]+(个|個)?(天|日|月|年|星期|周|週|小时|小時|时辰|時辰)(之|以)?後)`)
+
+// maxTimeJumpRunes keeps time-jump detection to short transition paragraphs.
+const maxTimeJumpRunes = 30
+
+// Resection evens out chapter lengths: runs of short chapters are merged into one section and long
+// chapters are split into parts of roughly TargetRunes, preferring scene breaks over plain paragraph
+// boundaries. Chapters from different volumes are never merged. Every section keeps the titles of the
+// chapters it came from in SourceTitles; merged sections also keep the later titles as lines in the text.
+func Resection(chapters []Chapter, opts ResectionOptions) []Chapter {
+	if opts.TargetRunes <= 0 {
+		return chapters
+	}
+	if opts.MinRunes <= 0 {
+		opts.MinRunes = opts.TargetRunes / 2
+	}
+	if opts.MaxRunes <= 0 {
+		opts.MaxRunes = opts.TargetRunes * 2
+	}
+
+	var sections []Chapter
+	for i := 0; i < len(chapters); {
+		chapter := chapters[i]
+		size := contentRunes(chapter.Content)
+		if size > opts.MaxRunes {
+			sections = append(sections, splitChapter(chapter, opts.TargetRunes)...)
+			i++
+			continue
+		}
+
+		merged := chapter
+		merged.SourceTitles = sourceTitles(chapter)
+		i++
+		for size < opts.MinRunes && i < len(chapters) && chapters[i].Volume == chapter.Volume {
+			next := chapters[i]
+			nextSize := contentRunes(next.Content)
+			if size+nextSize > opts.MaxRunes {
+				break
+			}
+			merged.Content = strings.Trim(merged.Content+"\n\n"+strings.TrimSpace(next.Title+"\n"+next.Content), "\n")
+			merged.SourceTitles = append(merged.SourceTitles, sourceTitles(next)...)
+			size += nextSize
+			i++
+		}
+		if len(merged.SourceTitles) > 1 {
+			merged.Title = fmt.Sprintf("%s ~ %s", merged.SourceTitles[0], merged.SourceTitles[len(merged.SourceTitles)-1])
+		}
+		sections = append(sections, merged)
+	}
+	return sections
+}
+
+// splitChapter cuts a long chapter into parts of about target runes. Each ideal cut point snaps to the
+// nearest scene break within a third of the target, otherwise to the nearest paragraph boundary.
+func splitChapter(chapter Chapter, target int) []Chapter {
+	paragraphs := strings.Split(chapter.Content, "\n")
+	// offsets[i] counts the runes before paragraph i
+	offsets := make([]int, len(paragraphs)+1)
+	for i, p := range paragraphs {
+		offsets[i+1] = offsets[i] + contentRunes(p)
+	}
+	total := offsets[len(paragraphs)]
+	parts := max(2, (total+target/2)/target)
+
+	var cuts []int
+	last := 0
+	for k := 1; k < parts; k++ {
+		ideal := total * k / parts
+		cut := nearestBoundary(paragraphs, offsets, ideal, target/3, last)
+		if cut <= last || cut >= len(paragraphs) {
+			continue
+		}
+		cuts = append(cuts, cut)
+		last = cut
+	}
+	cuts = append(cuts, len(paragraphs))
+
+	var sections []Chapter
+	start := 0
+	for _, cut := range cuts {
+		content := strings.Trim(strings.Join(trimSeparators(paragraphs[start:cut]), "\n"), "\n")
+		start = cut
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		sections = append(sections, Chapter{Volume: chapter.Volume, Content: content, SourceTitles: sourceTitles(chapter)})
+	}
+	if len(sections) > 1 {
+		for i := range sections {
+			sections[i].Title = fmt.Sprintf("%s（%d/%d）", chapter.Title, i+1, len(sections))
+		}
+	} else if len(sections) == 1 {
+		sections[0].Title = chapter.Title
+	}
+	return sections
+}
+
+// nearestBoundary returns the paragraph index to cut before. Scene breaks within window runes of the
+// ideal offset win; among them, and among plain boundaries, the closest one wins.
+func nearestBoundary(paragraphs []string, offsets []int, ideal, window, after int) int {
+	best, bestDistance := -1, 0
+	bestScene := false
+	for i := after + 1; i < len(paragraphs); i++ {
+		distance := abs(offsets[i] - ideal)
+		scene := distance <= window && isSceneBreak(paragraphs, i)
+		switch {
+		case best < 0,
+			scene && !bestScene,
+			scene == bestScene && distance < bestDistance:
+			best, bestDistance, bestScene = i, distance, scene
+		}
+	}
+	return best
+}
+
+// isSceneBreak reports whether a new scene starts at paragraph i: after a run of blank lines, at or
+// right after a separator line, or at a short time-jump paragraph.
+func isSceneBreak(paragraphs []string, i int) bool {
+	line := strings.TrimSpace(paragraphs[i])
+	if sceneBreakPattern.MatchString(line) && line != "" {
+		return true
+	}
+	if i > 0 && sceneBreakPattern.MatchString(strings.TrimSpace(paragraphs[i-1])) && strings.TrimSpace(paragraphs[i-1]) != "" {
+		return true
+	}
+	if i > 1 && strings.TrimSpace(paragraphs[i-1]) == "" && strings.TrimSpace(paragraphs[i-2]) == "" {
+		return true
+	}
+	return line != "" && utf8.RuneCountInString(line) <= maxTimeJumpRunes && timeJumpPattern.MatchString(line)
+}
+
+// trimSeparators drops separator lines left at the edges of a part after cutting at a scene break.
+func trimSeparators(lines []string) []string {
+	isSeparator := func(line string) bool {
+		line = strings.TrimSpace(line)
+		return line != "" && sceneBreakPattern.MatchString(line)
+	}
+	for len(lines) > 0 && isSeparator(lines[0]) {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && isSeparator(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func sourceTitles(chapter Chapter) []string {
+	if len(chapter.SourceTitles) > 0 {
+		return chapter.SourceTitles
+	}
+	if chapter.Title == "" {
+		return nil
+	}
+	return []string{chapter.Title}
+}
+
+// contentRunes counts the characters of text, ignoring whitespace.
+func contentRunes(text string) int {
+	n := 0
+	for _, field := range strings.Fields(text) {
+		n += utf8.RuneCountInString(field)
+	}
+	return n
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,76 @@
+package novel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResectionMergesShortChapters(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "第一章", Volume: "第一卷", Content: strings.Repeat("甲", 100)},
+		{Title: "第二章", Volume: "第一卷", Content: strings.Repeat("乙", 100)},
+		{Title: "第三章", Volume: "第一卷", Content: strings.Repeat("丙", 900)},
+		{Title: "第四章", Volume: "第二卷", Content: strings.Repeat("丁", 100)},
+	}
+
+	got := Resection(chapters, ResectionOptions{TargetRunes: 1000})
+	if len(got) != 2 {
+		t.Fatalf("Resection() returned %d sections, want 2", len(got))
+	}
+	if got[0].Title != "第一章 ~ 第三章" {
+		t.Errorf("merged title = %q", got[0].Title)
+	}
+	if want := []string{"第一章", "第二章", "第三章"}; !reflect.DeepEqual(got[0].SourceTitles, want) {
+		t.Errorf("merged SourceTitles = %q, want %q", got[0].SourceTitles, want)
+	}
+	if !strings.Contains(got[0].Content, "\n\n第二章\n乙") {
+		t.Errorf("merged content lost the original chapter title: %q", got[0].Content)
+	}
+	if got[1].Title != "第四章" || got[1].Volume != "第二卷" {
+		t.Errorf("merged across volumes: %#v", got[1])
+	}
+}
+
+func TestResectionSplitsAtSceneBreaks(t *testing.T) {
+	paragraph := strings.Repeat("字", 100)
+	var lines []string
+	for i := 0; i < 8; i++ {
+		lines = append(lines, paragraph)
+	}
+	lines = append(lines, "* * *")
+	for i := 0; i < 12; i++ {
+		lines = append(lines, paragraph)
+	}
+	lines = append(lines, "三天后，他们到了京城。")
+	for i := 0; i < 10; i++ {
+		lines = append(lines, paragraph)
+	}
+	chapter := Chapter{Title: "第一章 远行", Volume: "第一卷", Content: strings.Join(lines, "\n")}
+
+	got := Resection([]Chapter{chapter}, ResectionOptions{TargetRunes: 1000})
+	if len(got) != 3 {
+		t.Fatalf("Resection() returned %d sections, want 3", len(got))
+	}
+	for i, section := range got {
+		if want := "第一章 远行（" + string(rune('1'+i)) + "/3）"; section.Title != want {
+			t.Errorf("section %d title = %q, want %q", i, section.Title, want)
+		}
+		if section.Volume != "第一卷" || !reflect.DeepEqual(section.SourceTitles, []string{"第一章 远行"}) {
+			t.Errorf("section %d lost its source: %#v", i, section)
+		}
+		if strings.Contains(section.Content, "* * *") {
+			t.Errorf("section %d kept the separator line", i)
+		}
+	}
+	if !strings.HasPrefix(got[2].Content, "三天后") {
+		t.Errorf("third section does not start at the time jump: %q", got[2].Content[:30])
+	}
+}
+
+func TestResectionDisabled(t *testing.T) {
+	chapters := []Chapter{{Title: "第一章", Content: "短"}, {Title: "第二章", Content: "短"}}
+	if got := Resection(chapters, ResectionOptions{}); !reflect.DeepEqual(got, chapters) {
+		t.Errorf("Resection() without target = %#v", got)
+	}
+}
@@ -0,0 +1,216 @@
+package novel
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// numeral matches chapter numbers written in Chinese or Arabic numerals, including full-width digits.
+const numeral = `[零〇一二两三四五六七八九十百千万0-9０-９]+`
+
+// DefaultChapterPatterns match the chapter headings common in Chinese web novels.
+var DefaultChapterPatterns = []string{
+	`^第` + numeral + `[章回节節集话話幕]`,
+	`^(楔子|序章|序言|序幕|引子|前言|尾声|尾聲|终章|終章|后记|後記|番外)([\s:：·、.．\-—（(0-9０-９一二三四五六七八九十]|$)`,
+	`^[0-9０-９]{1,4}([、.．:：]|\s)`,
+	`(?i)^chapter\s*[0-9]+`,
+}
+
+// DefaultVolumePatterns match volume headings that group the following chapters.
+var DefaultVolumePatterns = []string{
+	`^第` + numeral + `[卷部册冊篇]`,
+	`^卷` + numeral,
+	`(?i)^(volume|book)\s*[0-9]+`,
+}
+
+// DefaultFallbackRunes is the target chapter length used when a text has no recognizable headings.
+const DefaultFallbackRunes = 3000
+
+// maxHeadingRunes rejects long lines that merely mention a chapter, such as dialogue quoting one.
+const maxHeadingRunes = 40
+
+// sentenceEndings mark a line as prose rather than a heading, e.g. "第一回合他就输了。".
+const sentenceEndings = "。！？!?…”」』"
+
+// inlineChapterPattern finds a chapter heading that follows a volume title on the same line.
+var inlineChapterPattern = regexp.MustCompile(`第` + numeral + `[章回节節集话話]`)
+
+// Splitter splits plain novel text into chapters at heading lines.
+type Splitter struct {
+	chapters      []*regexp.Regexp
+	volumes       []*regexp.Regexp
+	fallbackRunes int
+}
+
+// SplitOptions configures a Splitter. Empty pattern lists fall back to the defaults.
+type SplitOptions struct {
+	ChapterPatterns []string
+	VolumePatterns  []string
+	// FallbackRunes is the target chapter length when no heading matches, DefaultFallbackRunes when zero.
+	FallbackRunes int
+}
+
+// NewSplitter compiles the configured patterns.
+func NewSplitter(opts SplitOptions) (*Splitter, error) {
+	chapterPatterns := opts.ChapterPatterns
+	if len(chapterPatterns) == 0 {
+		chapterPatterns = DefaultChapterPatterns
+	}
+	volumePatterns := opts.VolumePatterns
+	if len(volumePatterns) == 0 {
+		volumePatterns = DefaultVolumePatterns
+	}
+
+	s := &Splitter{fallbackRunes: opts.FallbackRunes}
+	if s.fallbackRunes <= 0 {
+		s.fallbackRunes = DefaultFallbackRunes
+	}
+	var err error
+	if s.chapters, err = compilePatterns(chapterPatterns); err != nil {
+		return nil, err
+	}
+	if s.volumes, err = compilePatterns(volumePatterns); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid heading pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Split cuts text into chapters at heading lines. Volume headings are not chapters themselves; they
+// name the volume of the chapters that follow, and a chapter heading on the same line as the volume
+// title is split off. Text before the first heading becomes an untitled chapter. When no heading
+// matches at all the text is cut at paragraph boundaries near the fallback length.
+func (s *Splitter) Split(text string) []Chapter {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	var (
+		chapters []Chapter
+		volume   string
+		current  *Chapter
+		buffer   []string
+		headings int
+	)
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Content = strings.Trim(strings.Join(buffer, "\n"), "\n")
+		if current.Title != "" || strings.TrimSpace(current.Content) != "" {
+			chapters = append(chapters, *current)
+		}
+		current, buffer = nil, buffer[:0]
+	}
+
+	for _, line := range lines {
+		candidate := normalizeHeadingCandidate(line)
+		if s.isHeading(candidate, s.volumes) {
+			flush()
+			headings++
+			volume = candidate
+			if loc := inlineChapterPattern.FindStringIndex(candidate); loc != nil && loc[0] > 0 {
+				volume = strings.TrimSpace(candidate[:loc[0]])
+				current = &Chapter{Title: strings.TrimSpace(candidate[loc[0]:]), Volume: volume}
+			}
+			continue
+		}
+		if s.isHeading(candidate, s.chapters) {
+			flush()
+			headings++
+			current = &Chapter{Title: candidate, Volume: volume}
+			continue
+		}
+		if current == nil {
+			if candidate == "" {
+				continue
+			}
+			current = &Chapter{Volume: volume}
+		}
+		buffer = append(buffer, line)
+	}
+	flush()
+
+	if headings == 0 {
+		return s.splitByLength(text)
+	}
+	return chapters
+}
+
+func (s *Splitter) isHeading(line string, patterns []*regexp.Regexp) bool {
+	if line == "" || utf8.RuneCountInString(line) > maxHeadingRunes {
+		return false
+	}
+	if last, _ := utf8.DecodeLastRuneInString(line); strings.ContainsRune(sentenceEndings, last) {
+		return false
+	}
+	for _, re := range patterns {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitByLength groups paragraphs into untitled chapters, cutting at whichever paragraph boundary
+// lands closest to the fallback length.
+func (s *Splitter) splitByLength(text string) []Chapter {
+	var chapters []Chapter
+	var buffer []string
+	size := 0
+	flush := func() {
+		if content := strings.Trim(strings.Join(buffer, "\n"), "\n"); strings.TrimSpace(content) != "" {
+			chapters = append(chapters, Chapter{Content: content})
+		}
+		buffer, size = buffer[:0], 0
+	}
+
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		n := utf8.RuneCountInString(strings.TrimSpace(paragraph))
+		if size > 0 && size+n > s.fallbackRunes && s.fallbackRunes-size < size+n-s.fallbackRunes {
+			flush()
+		}
+		buffer = append(buffer, paragraph)
+		size += n
+		if size >= s.fallbackRunes {
+			flush()
+		}
+	}
+	flush()
+	return chapters
+}
+
+// normalizeHeadingCandidate strips zero-width characters that often wrap headings in web-crawled novels.
+func normalizeHeadingCandidate(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return trimmed
+	}
+
+	var b strings.Builder
+	b.Grow(len(trimmed))
+	for _, r := range trimmed {
+		switch r {
+		case '\u200B', '\u200C', '\u200D', '\uFEFF':
+			continue
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return strings.TrimSpace(b.String())
+}
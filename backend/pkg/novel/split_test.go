@@ -0,0 +1,82 @@
+package novel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func newTestSplitter(t *testing.T, opts SplitOptions) *Splitter {
+	t.Helper()
+	s, err := NewSplitter(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSplitHeadings(t *testing.T) {
+	text := strings.Join([]string{
+		"书名：测试",
+		"",
+		"楔子",
+		"很久以前。",
+		"第一卷 风起云涌",
+		"第一章 少年",
+		"​第一回合他就输了。",
+		"第2回 再战",
+		"“我翻到第三章了。”",
+		"第二卷 天下 第三节 远行",
+		"上路。",
+		"番外：后日谈",
+		"完。",
+	}, "\n")
+
+	got := newTestSplitter(t, SplitOptions{}).Split(text)
+	want := []Chapter{
+		{Content: "书名：测试"},
+		{Title: "楔子", Content: "很久以前。"},
+		{Title: "第一章 少年", Volume: "第一卷 风起云涌", Content: "​第一回合他就输了。"},
+		{Title: "第2回 再战", Volume: "第一卷 风起云涌", Content: "“我翻到第三章了。”"},
+		{Title: "第三节 远行", Volume: "第二卷 天下", Content: "上路。"},
+		{Title: "番外：后日谈", Volume: "第二卷 天下", Content: "完。"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Split() = %#v\nwant %#v", got, want)
+	}
+}
+
+func TestSplitCustomPatterns(t *testing.T) {
+	s := newTestSplitter(t, SplitOptions{ChapterPatterns: []string{`^【.+】$`}})
+	got := s.Split("【开端】\n正文一\n第一章 不再是标题\n【结局】\n正文二")
+	want := []Chapter{
+		{Title: "【开端】", Content: "正文一\n第一章 不再是标题"},
+		{Title: "【结局】", Content: "正文二"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Split() = %#v", got)
+	}
+
+	if _, err := NewSplitter(SplitOptions{ChapterPatterns: []string{"("}}); err == nil {
+		t.Error("NewSplitter() with invalid pattern succeeded")
+	}
+}
+
+func TestSplitFallbackByLength(t *testing.T) {
+	paragraph := strings.Repeat("字", 400)
+	text := strings.Repeat(paragraph+"\n", 10)
+
+	got := newTestSplitter(t, SplitOptions{FallbackRunes: 1000}).Split(text)
+	if len(got) != 4 {
+		t.Fatalf("Split() returned %d chapters, want 4", len(got))
+	}
+	for i, chapter := range got {
+		if chapter.Title != "" {
+			t.Errorf("chapter %d title = %q, want untitled", i, chapter.Title)
+		}
+		if n := utf8.RuneCountInString(strings.ReplaceAll(chapter.Content, "\n", "")); i < 3 && n != 1200 {
+			t.Errorf("chapter %d has %d runes, want 1200", i, n)
+		}
+	}
+}
@@ -3,6 +3,7 @@ import type {
   GetComicsParams,
   GetComicsData,
   CreateComicData,
+  PreviewComicData,
   ComicDetail,
 } from './types';
 import { API_BASE } from './config';
@@ -36,7 +37,32 @@ export async function createComic(
   return response.json();
 }
 
+export async function previewComic(file: File): Promise<ApiResponse<PreviewComicData>> {
+  const formData = new FormData();
+  formData.append('file', file);
+
+  const response = await fetch(`${API_BASE}/comics/preview`, {
+    method: 'POST',
+    body: formData,
+  });
+  return response.json();
+}
+
 export async function getComic(comicId: string): Promise<ApiResponse<ComicDetail>> {
   const response = await fetch(`${API_BASE}/comics/${comicId}/`);
   return response.json();
 }
+
+export async function updateNarrator(
+  comicId: string,
+  voiceType: string
+): Promise<ApiResponse<{ id: string; narrator_voice_type: string; narrator_voice_name: string }>> {
+  const formData = new FormData();
+  formData.append('voice_type', voiceType);
+
+  const response = await fetch(`${API_BASE}/comics/${comicId}/narrator`, {
+    method: 'PUT',
+    body: formData,
+  });
+  return response.json();
+}
@@ -1,5 +1,6 @@
 export * from './types';
 export * from './comics';
 export * from './sections';
+export * from './roles';
 export * from './images';
 export * from './tts';
@@ -0,0 +1,63 @@
+import type { ApiResponse, RoleDetail, RoleInput } from './types';
+import { API_BASE } from './config';
+
+function roleFormData(input: RoleInput): FormData {
+  // 只提交填写的字段，未提交的字段后端保持不变
+  const formData = new FormData();
+  Object.entries(input).forEach(([key, value]) => {
+    if (value !== undefined) formData.append(key, value);
+  });
+  return formData;
+}
+
+export async function getRoles(comicId: string): Promise<ApiResponse<{ roles: RoleDetail[] }>> {
+  const response = await fetch(`${API_BASE}/comics/${comicId}/roles/`);
+  return response.json();
+}
+
+export async function getRole(comicId: string, roleId: string): Promise<ApiResponse<RoleDetail>> {
+  const response = await fetch(`${API_BASE}/comics/${comicId}/roles/${roleId}/`);
+  return response.json();
+}
+
+export async function createRole(comicId: string, input: RoleInput): Promise<ApiResponse<RoleDetail>> {
+  const response = await fetch(`${API_BASE}/comics/${comicId}/roles/`, {
+    method: 'POST',
+    body: roleFormData(input),
+  });
+  return response.json();
+}
+
+export async function updateRole(
+  comicId: string,
+  roleId: string,
+  input: RoleInput
+): Promise<ApiResponse<RoleDetail>> {
+  const response = await fetch(`${API_BASE}/comics/${comicId}/roles/${roleId}/`, {
+    method: 'PUT',
+    body: roleFormData(input),
+  });
+  return response.json();
+}
+
+export async function deleteRole(comicId: string, roleId: string): Promise<ApiResponse<{ id: string }>> {
+  const response = await fetch(`${API_BASE}/comics/${comicId}/roles/${roleId}/`, {
+    method: 'DELETE',
+  });
+  return response.json();
+}
+
+export async function uploadRolePortrait(
+  comicId: string,
+  roleId: string,
+  image: File
+): Promise<ApiResponse<RoleDetail>> {
+  const formData = new FormData();
+  formData.append('image', image);
+
+  const response = await fetch(`${API_BASE}/comics/${comicId}/roles/${roleId}/portrait`, {
+    method: 'POST',
+    body: formData,
+  });
+  return response.json();
+}
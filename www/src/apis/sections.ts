@@ -4,11 +4,12 @@ import { API_BASE } from './config';
 export async function createSection(
   comicId: string,
   title: string,
-  content: string
+  file: File
 ): Promise<ApiResponse<CreateSectionData>> {
+  // 直接上传原始文件，由后端识别 GBK、Big5 等编码
   const formData = new FormData();
   formData.append('title', title);
-  formData.append('content', content);
+  formData.append('file', file);
 
   const response = await fetch(`${API_BASE}/comics/${comicId}/sections/`, {
     method: 'POST',
@@ -4,3 +4,20 @@ export async function getTTSAudio(ttsId: string): Promise<Blob> {
   const response = await fetch(`${API_BASE}/tts/${ttsId}/`);
   return response.blob();
 }
+
+export interface AuditionParams {
+  voiceType?: string;
+  speedRatio?: number;
+  text?: string;
+}
+
+// 试听地址可直接作为 <audio> 的 src，后端在进程内缓存最近的试听结果，自定义文本不会持久保存
+export function getRoleAuditionUrl(comicId: string, roleId: string, params?: AuditionParams): string {
+  const queryParams = new URLSearchParams();
+  if (params?.voiceType) queryParams.append('voice_type', params.voiceType);
+  if (params?.speedRatio) queryParams.append('speed_ratio', params.speedRatio.toString());
+  if (params?.text) queryParams.append('text', params.text);
+
+  const query = queryParams.toString();
+  return `${API_BASE}/comics/${comicId}/roles/${roleId}/audition${query ? `?${query}` : ''}`;
+}
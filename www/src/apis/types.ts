@@ -17,6 +17,7 @@ export interface Comic {
   icon_image_id: string;
   background_image_id: string;
   title: string;
+  author?: string;
   brief: string;
   status: ComicStatus;
   created_at: string;
@@ -28,8 +29,11 @@ export interface ComicDetail {
   icon_image_id: string;
   background_image_id: string;
   title: string;
+  author?: string;
   brief: string;
   status: ComicStatus;
+  narrator_voice_type?: string;
+  narrator_voice_name?: string;
   roles: Role[];
   sections: Section[];
   created_at: string;
@@ -40,31 +44,70 @@ export interface Role {
   name: string;
   brief: string;
   image_id: string;
+  gender?: string;
+  age?: string;
+  voice_name?: string;
+  voice_type?: string;
   created_at: string;
   updated_at: string;
 }
 
+export interface RoleDetail extends Role {
+  id: string;
+  concept_art_prompt: string;
+  revision_id?: string;
+  assets?: RoleAsset[];
+}
+
+export interface RoleAsset {
+  kind: 'view' | 'expression';
+  name: string;
+  image_id: string;
+}
+
+export interface RoleInput {
+  name?: string;
+  brief?: string;
+  gender?: string;
+  age?: string;
+  voice_type?: string;
+  concept_art_prompt?: string;
+}
+
 export interface Section {
   id: string;
   title: string;
+  volume?: string;
+  source_titles?: string[];
   index: number;
   status: ComicStatus;
+  audio_status?: SectionAudioStatus;
+  ready?: boolean;
   created_at: string;
   updated_at: string;
 }
 
+export type SectionAudioStatus = 'pending' | 'generating' | 'completed' | 'failed';
+
 export interface SectionDetail {
   id: string;
   title: string;
   index: number;
   status: ComicStatus;
+  audio_status?: SectionAudioStatus;
+  ready?: boolean;
   pages: Page[];
   created_at: string;
   updated_at: string;
 }
 
+export type PageImageStatus = 'pending' | 'generating' | 'completed' | 'failed';
+
 export interface Page {
   id: string;
+  image_status?: PageImageStatus;
+  image_id?: string;
+  image_error?: string;
   created_at: string;
   updated_at: string;
   details: PageDetail[];
@@ -73,6 +116,11 @@ export interface Page {
 export interface PageDetail {
   id: string;
   content: string;
+  voice_type?: string;
+  speed_ratio?: number;
+  is_narration?: boolean;
+  audio_key?: string;
+  audio_duration_ms?: number;
   created_at: string;
   updated_at: string;
 }
@@ -89,13 +137,44 @@ export interface GetComicsData {
   limit: number;
 }
 
+export interface CleanupRemoval {
+  rule: string;
+  chapter?: string;
+  text: string;
+}
+
+export interface CleanupReport {
+  counts: Record<string, number>;
+  removed: CleanupRemoval[] | null;
+}
+
 export interface CreateComicData {
   id: string;
+  encoding: string;
+  cleanup: CleanupReport;
+}
+
+export interface PreviewChapter {
+  title: string;
+  volume: string;
+  runes: number;
+  source_titles: string[] | null;
+}
+
+export interface PreviewComicData {
+  title: string;
+  author: string;
+  encoding: string;
+  chapters: PreviewChapter[];
+  cleanup: CleanupReport;
 }
 
 export interface CreateSectionData {
   id: string;
   index: number;
+  job_id: string;
+  encoding?: string;
+  cleanup?: CleanupReport;
 }
 
 export interface ImageUrlData {
@@ -46,8 +46,11 @@ const ComicAddMobile = () => {
     }
   }, [])
 
+  // EPUB 自带书名，标题可以留空
+  const isEpub = !!file && file.name.toLowerCase().endsWith('.epub')
+
   const handleSubmit = useCallback(async () => {
-    if (!title.trim() || !userPrompt.trim() || !file) {
+    if ((!title.trim() && !isEpub) || !userPrompt.trim() || !file) {
       return
     }
 
@@ -67,9 +70,9 @@ const ComicAddMobile = () => {
     } finally {
       setSubmitting(false)
     }
-  }, [title, userPrompt, file, router])
+  }, [title, userPrompt, file, isEpub, router])
 
-  const isFormValid = title.trim() && userPrompt.trim() && file
+  const isFormValid = (title.trim() || isEpub) && userPrompt.trim() && file
 
   return (
     <div className="min-h-screen text-white flex flex-col">
@@ -135,7 +138,7 @@ const ComicAddMobile = () => {
               ref={fileInputRef}
               type="file"
               onChange={handleFileChange}
-              accept=".txt,.pdf,.doc,.docx"
+              accept=".txt,.epub,.pdf,.doc,.docx"
               className="hidden"
             />
             <button
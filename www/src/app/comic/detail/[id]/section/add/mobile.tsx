@@ -57,14 +57,13 @@ const ComicSectionAddMobile = () => {
 
     setSubmitting(true)
     try {
-      const fileContent = await file.text()
       const response = await createSection(
         params.id,
         title.trim(),
-        fileContent
+        file
       )
 
-      if (response.code === 200) {
+      if (response.code === 200 || response.code === 202) {
         router.push(`/comic/detail/${params.id}`)
       }
     } catch (error) {
@@ -125,6 +125,12 @@ export class ComicReadStore {
     }
   }
 
+  private async fetchPage(pageId: string): Promise<Page | null> {
+    const response = await getSection(this.comicId, this.sectionId)
+    if (response.code !== 200) return null
+    return response.data.pages.find(p => String(p.id) === String(pageId)) || null
+  }
+
   private async checkAndLoadResources(): Promise<void> {
     const page = this.currentPage
     if (!page) return
@@ -136,7 +142,7 @@ export class ComicReadStore {
     
     try {
       const audioIds = page.details.map(d => d.id)
-      this.pageManager.initialize(page.id, audioIds)
+      this.pageManager.initialize(page, audioIds, () => this.fetchPage(page.id))
       
       await this.pageManager.loadImage()
       
@@ -1,5 +1,5 @@
 import { makeAutoObservable } from 'mobx'
-import { getImageUrl, getTTSAudio } from '@/apis'
+import { getImageUrl, getTTSAudio, type Page } from '@/apis'
 
 interface AudioData {
   id: string
@@ -8,13 +8,17 @@ interface AudioData {
   error: string | null
 }
 
+class ImageFailedError extends Error {}
+
 export class PageManager {
   pageId: string = ''
+  imageId: string | null = null
   imageUrl: string | null = null
   imageLoading: boolean = false
   imageError: string | null = null
   audioMap: Map<string, AudioData> = new Map()
   
+  private refreshPage: (() => Promise<Page | null>) | null = null
   private imagePollingTimer: NodeJS.Timeout | null = null
   private imagePollingAttempts: number = 0
   private readonly MAX_POLLING_ATTEMPTS = 30
@@ -36,8 +40,10 @@ export class PageManager {
     return true
   }
 
-  initialize(pageId: string, audioIds: string[]): void {
-    this.pageId = pageId
+  initialize(page: Page, audioIds: string[], refreshPage: () => Promise<Page | null>): void {
+    this.pageId = page.id
+    this.imageId = page.image_id || null
+    this.refreshPage = refreshPage
     this.imageUrl = null
     this.imageError = null
     this.audioMap.clear()
@@ -73,8 +79,9 @@ export class PageManager {
       const poll = async () => {
         try {
           this.imagePollingAttempts++
-          
-          const response = await getImageUrl(this.pageId)
+
+          const imageId = await this.resolveImageId()
+          const response = await getImageUrl(imageId)
           
           if (response.code === 200 && response.data?.url) {
             if (this.imagePollingTimer) {
@@ -96,7 +103,7 @@ export class PageManager {
           
           this.imagePollingTimer = setTimeout(poll, this.POLLING_INTERVAL)
         } catch (error) {
-          if (this.imagePollingAttempts >= this.MAX_POLLING_ATTEMPTS) {
+          if (error instanceof ImageFailedError || this.imagePollingAttempts >= this.MAX_POLLING_ATTEMPTS) {
             if (this.imagePollingTimer) {
               clearTimeout(this.imagePollingTimer)
               this.imagePollingTimer = null
@@ -113,6 +120,20 @@ export class PageManager {
     })
   }
 
+  // 图片尚未生成时重新获取页面状态，生成失败则不再轮询；早期数据没有 image_id，图片以页面 ID 存储
+  private async resolveImageId(): Promise<string> {
+    if (this.imageId) return this.imageId
+
+    const page = await this.refreshPage?.()
+    if (page?.image_status === 'failed') {
+      throw new ImageFailedError(page.image_error || 'Image generation failed')
+    }
+    if (page?.image_id) {
+      this.imageId = page.image_id
+    }
+    return this.imageId || this.pageId
+  }
+
   async loadAudio(audioId: string, retryCount = 3): Promise<void> {
     const audioData = this.audioMap.get(audioId)
     if (!audioData) return